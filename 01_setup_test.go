@@ -4,11 +4,376 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"QA-Bug-Hunter-jr/internal/fakeapi"
+	"QA-Bug-Hunter-jr/internal/faultproxy"
+	"QA-Bug-Hunter-jr/internal/invariant"
+	"QA-Bug-Hunter-jr/internal/latency"
+	"QA-Bug-Hunter-jr/internal/persist"
+	"QA-Bug-Hunter-jr/internal/traffic"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runSuite(m))
+}
+
+// runSuite runs the tests and returns the exit code,
+// BUGHUNTER_TARGET=fake runs them against in-process fakes of Release and Dev,
+// BUGHUNTER_FAKE_DEV_LATENCY slows the fake Dev down by a duration such as 20ms
+func runSuite(m *testing.M) int {
+	if os.Getenv("BUGHUNTER_TARGET") == "fake" {
+		devOpts := fakeapi.Options{Dev: true}
+		if latency := os.Getenv("BUGHUNTER_FAKE_DEV_LATENCY"); latency != "" {
+			d, err := time.ParseDuration(latency)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "BUGHUNTER_FAKE_DEV_LATENCY: %v\n", err)
+				return 2
+			}
+			devOpts.Latency = d
+		}
+		release := fakeapi.New(fakeapi.Options{})
+		releaseServer := httptest.NewServer(release)
+		devServer := httptest.NewServer(release.Share(devOpts))
+		defer releaseServer.Close()
+		defer devServer.Close()
+		useEnvironments(releaseServer.URL+fakeapi.BasePath, devServer.URL+fakeapi.BasePath)
+	}
+
+	// the price catalog keeps reading the real Release when the environments are proxied
+	catalogURL := ReleaseURL
+
+	// every environment is reached through a fault proxy, BUGHUNTER_FAULTS=<schedule> enables it
+	if schedule := os.Getenv("BUGHUNTER_FAULTS"); schedule != "" {
+		rules, err := faultproxy.ParseSchedule(schedule)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "BUGHUNTER_FAULTS: %v\n", err)
+			return 2
+		}
+		envs, stop, err := proxyEnvironments(rules)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "BUGHUNTER_FAULTS: %v\n", err)
+			return 2
+		}
+		defer stop()
+		fmt.Printf("fault proxy: %s\n", schedule)
+		useEnvironments(envs[0].URL, envs[1].URL)
+	}
+
+	// the suite's own lookups and read backs go around the latency transport, so only
+	// the tests' requests are timed whatever checks are enabled
+	direct := http.DefaultTransport
+
+	// request durations are compared with a baseline file, BUGHUNTER_BASELINE=<path> enables it
+	var durations *latency.Recorder
+	if os.Getenv("BUGHUNTER_BASELINE") != "" {
+		durations = latency.NewRecorder()
+		next := http.DefaultTransport
+		http.DefaultTransport = &latency.Transport{Next: next, Key: latencyKey, Recorder: durations}
+		defer func() { http.DefaultTransport = next }()
+	}
+
+	// price invariants are checked on every response, BUGHUNTER_INVARIANTS=off disables them
+	if os.Getenv("BUGHUNTER_INVARIANTS") != "off" {
+		next := http.DefaultTransport
+		http.DefaultTransport = &invariant.Transport{
+			Next:    next,
+			Catalog: &releaseCatalog{client: &http.Client{Transport: direct}, base: catalogURL, prices: map[string]int{}},
+			Report:  recordViolation,
+		}
+		defer func() { http.DefaultTransport = next }()
+	}
+
+	// successful mutations are read back to check they persisted, BUGHUNTER_VERIFY=1 enables it
+	if os.Getenv("BUGHUNTER_VERIFY") == "1" {
+		next := http.DefaultTransport
+		http.DefaultTransport = &persist.Transport{Next: next, Read: direct, Report: recordGap}
+		defer func() { http.DefaultTransport = next }()
+	}
+
+	// requests and responses are logged for bughunter replay, BUGHUNTER_RECORD=<file> enables it
+	var recorder *traffic.Recorder
+	if path := os.Getenv("BUGHUNTER_RECORD"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "BUGHUNTER_RECORD: %v\n", err)
+			return 2
+		}
+		defer f.Close()
+		next := http.DefaultTransport
+		recorder = traffic.NewRecorder(next, f, locate)
+		http.DefaultTransport = recorder
+		defer func() { http.DefaultTransport = next }()
+	}
+
+	code := m.Run()
+	if recorder != nil && recorder.Err() != nil {
+		fmt.Fprintf(os.Stderr, "BUGHUNTER_RECORD: %v\n", recorder.Err())
+		code = 1
+	}
+	if violations.report() && code == 0 {
+		code = 1
+	}
+	if gaps.report() && code == 0 {
+		code = 1
+	}
+	if durations != nil {
+		regressed, err := checkBaseline(durations.Baseline())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "latency baseline: %v\n", err)
+			regressed = true
+		}
+		if regressed && code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+// latencyKey names a request by environment and endpoint template, "" for other hosts
+// and for the injection probes, whose hostile paths are not endpoints
+func latencyKey(req *http.Request) string {
+	if req.Header.Get("X-Task-Id") == "api-probe" {
+		return ""
+	}
+	if env, path, ok := locate(req); ok {
+		// the query stays out of the key, or every search and page would be an endpoint of its own
+		path, _, _ = strings.Cut(path, "?")
+		return env + " " + latency.Endpoint(req.Method, path)
+	}
+	return ""
+}
+
+func TestLatencyKey(t *testing.T) {
+	for _, c := range []struct {
+		method, url, task, want string
+	}{
+		{"GET", DevURL + "/users/00000000-0000-4000-8000-000000000001/cart", "api-12", "Dev GET /users/{uuid}/cart"},
+		{"GET", DevURL + "/games/search?query=Hades&offset=0&limit=100", "api-2", "Dev GET /games/search"},
+		{"GET", ReleaseURL + "/users?offset=0&limit=10", "api-6", "Release GET /users"},
+		{"GET", DevURL + "/games/search?query=%27", "api-probe", ""},
+		{"GET", "http://elsewhere.example/api/v1/users", "api-6", ""},
+	} {
+		req, err := http.NewRequest(c.method, c.url, nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Task-Id", c.task)
+		assert.Equal(t, c.want, latencyKey(req), c.url)
+	}
+}
+
+// locate returns the environment a request is sent to and its path below the environment URL
+func locate(req *http.Request) (env, path string, ok bool) {
+	u := req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+	for _, e := range Environments {
+		if path, ok := strings.CutPrefix(u, e.URL); ok {
+			if req.URL.RawQuery != "" {
+				path += "?" + req.URL.RawQuery
+			}
+			return e.Name, path, true
+		}
+	}
+	return "", "", false
+}
+
+// checkBaseline compares this run with the BUGHUNTER_BASELINE file and reports whether an
+// endpoint regressed. The file is written when it is missing or BUGHUNTER_BASELINE_UPDATE=1,
+// BUGHUNTER_BASELINE_FACTOR sets how many times slower than its baseline an endpoint may get.
+func checkBaseline(current latency.Baseline) (bool, error) {
+	path := os.Getenv("BUGHUNTER_BASELINE")
+	th := latency.DefaultThresholds
+	if factor := os.Getenv("BUGHUNTER_BASELINE_FACTOR"); factor != "" {
+		f, err := strconv.ParseFloat(factor, 64)
+		if err != nil || f <= 1 {
+			return false, fmt.Errorf("BUGHUNTER_BASELINE_FACTOR must be a number above 1, got %q", factor)
+		}
+		th.Factor = f
+	}
+
+	base, err := latency.Load(path)
+	if err != nil {
+		return false, err
+	}
+	if base == nil || os.Getenv("BUGHUNTER_BASELINE_UPDATE") == "1" {
+		fmt.Printf("latency baseline: %d endpoints written to %s\n", len(current), path)
+		return false, current.Save(path)
+	}
+
+	regressions := latency.Compare(base, current, th)
+	if len(regressions) == 0 {
+		return false, nil
+	}
+	fmt.Printf("--- FAIL: latency baseline (%d endpoints slower than x%g)\n", len(regressions), th.Factor)
+	for _, r := range regressions {
+		fmt.Printf("    %s\n", r)
+	}
+	return true, nil
+}
+
+// releaseCatalog looks up reference values on Release, bypassing the invariant checks; base
+// is fixed when the suite starts, so tests that point ReleaseURL elsewhere do not affect it
+type releaseCatalog struct {
+	client *http.Client
+	base   string
+	mu     sync.Mutex
+	prices map[string]int
+}
+
+func (c *releaseCatalog) get(path string, v interface{}) error {
+	req, err := http.NewRequest("GET", c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", AuthHeader)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (c *releaseCatalog) Price(itemUUID string) (int, error) {
+	c.mu.Lock()
+	price, ok := c.prices[itemUUID]
+	c.mu.Unlock()
+	if ok {
+		return price, nil
+	}
+
+	var game Game
+	if err := c.get("/games/"+itemUUID, &game); err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.prices[itemUUID] = game.Price
+	c.mu.Unlock()
+	return game.Price, nil
+}
+
+func (c *releaseCatalog) OrderTotal(orderUUID string) (int, error) {
+	var order struct {
+		TotalPrice int `json:"total_price"`
+	}
+	err := c.get("/orders/"+orderUUID, &order)
+	return order.TotalPrice, err
+}
+
+// findings are problems the transports see during the run, with how often each occurred
+type findings struct {
+	sync.Mutex
+	title  string
+	unit   string
+	counts map[string]int
+	order  []string
+}
+
+func newFindings(title, unit string) *findings {
+	return &findings{title: title, unit: unit, counts: map[string]int{}}
+}
+
+func (f *findings) add(msg string) {
+	f.Lock()
+	defer f.Unlock()
+	if f.counts[msg] == 0 {
+		f.order = append(f.order, msg)
+	}
+	f.counts[msg]++
+}
+
+// report prints the findings and reports whether there were any
+func (f *findings) report() bool {
+	f.Lock()
+	defer f.Unlock()
+	if len(f.order) == 0 {
+		return false
+	}
+	fmt.Printf("--- FAIL: %s (%d distinct %s)\n", f.title, len(f.order), f.unit)
+	for _, msg := range f.order {
+		fmt.Printf("    %s (x%d)\n", msg, f.counts[msg])
+	}
+	return true
+}
+
+var (
+	violations = newFindings("price invariants", "violations")
+	gaps       = newFindings("persistence", "gaps")
 )
 
+func recordViolation(req *http.Request, err error) {
+	violations.add(fmt.Sprintf("%s %s %s: %v", req.Header.Get("X-Task-Id"), req.Method, req.URL.Path, err))
+}
+
+// recordGap notes a mutation whose change is not visible when read back, e.g.
+// "api-15 Dev POST /users/<uuid>/cart/clear: cart clear: 1 items are still stored"
+func recordGap(req *http.Request, v persist.Verification, gap string) {
+	where := req.Method + " " + req.URL.Path
+	if env, path, ok := locate(req); ok {
+		where = env + " " + req.Method + " " + path
+	}
+	gaps.add(fmt.Sprintf("%s %s: %s: %s", req.Header.Get("X-Task-Id"), where, v.Name, gap))
+}
+
+// jsonField returns obj[key] as a T, failing the test cleanly when obj is not
+// a JSON object or the field is missing or of another type
+func jsonField[T any](t *testing.T, obj interface{}, key string) T {
+	t.Helper()
+	m, ok := obj.(map[string]interface{})
+	require.True(t, ok, "expected a JSON object holding %q, got %T", key, obj)
+	v, ok := m[key].(T)
+	require.True(t, ok, "field %q is %T, want %T", key, m[key], *new(T))
+	return v
+}
+
+// useEnvironments points the suite at other Release and Dev base URLs
+func useEnvironments(releaseURL, devURL string) {
+	ReleaseURL, DevURL = releaseURL, devURL
+	Environments = []Environment{
+		{Name: "Release", URL: ReleaseURL},
+		{Name: "Dev", URL: DevURL},
+	}
+}
+
+// proxyEnvironments puts a fault proxy with rules in front of every environment and
+// returns the proxied environments with a func that stops the proxies
+func proxyEnvironments(rules []faultproxy.Rule) ([]Environment, func(), error) {
+	var envs []Environment
+	var servers []*httptest.Server
+	stop := func() {
+		for _, srv := range servers {
+			srv.Close()
+		}
+	}
+	for _, env := range Environments {
+		u, err := url.Parse(env.URL)
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		proxy, err := faultproxy.New(u.Scheme+"://"+u.Host, rules)
+		if err != nil {
+			stop()
+			return nil, nil, err
+		}
+		srv := httptest.NewServer(proxy)
+		servers = append(servers, srv)
+		envs = append(envs, Environment{Name: env.Name, URL: srv.URL + u.Path})
+	}
+	return envs, stop, nil
+}
+
 func sendSetupRequest(url string) (*http.Response, error) {
 	client := &http.Client{}
 
@@ -33,7 +398,7 @@ func TestSetupRelease(t *testing.T) {
 	t.Run("Test Setup on Release Environment", func(t *testing.T) {
 		// Send the setup request
 		resp, err := sendSetupRequest(ReleaseURL)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		// Check that the response status code is 205 (ResetContent)
 		assert.Equal(t, http.StatusResetContent, resp.StatusCode)
@@ -45,7 +410,7 @@ func TestGetAllUsers(t *testing.T) {
 	t.Run("Test Get All Users", func(t *testing.T) {
 		var taskID = "api-6"
 		resp, err := SendGetRequest(fmt.Sprintf("%s/users", ReleaseURL), taskID)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
@@ -53,7 +418,7 @@ func TestGetAllUsers(t *testing.T) {
 		defer resp.Body.Close()
 
 		err = json.NewDecoder(resp.Body).Decode(&responseBody)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		meta, ok := responseBody["meta"].(map[string]interface{})
 		assert.True(t, ok, "meta field is missing or incorrect format")
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // api-21: List All Users
@@ -13,25 +14,25 @@ func TestListAllUsers(t *testing.T) {
 	t.Run("List All Users on Release and Dev", func(t *testing.T) {
 
 		releaseResp, err := SendGetRequest(fmt.Sprintf("%s/users", ReleaseURL), "api-21")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseResp.StatusCode)
 
 		releaseBody, err := ParseJSONResponse(releaseResp)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Contains(t, releaseBody, "meta")
 		assert.Contains(t, releaseBody["meta"], "total")
-		releaseTotal := releaseBody["meta"].(map[string]interface{})["total"].(float64)
+		releaseTotal := jsonField[float64](t, jsonField[interface{}](t, releaseBody, "meta"), "total")
 		assert.Equal(t, releaseTotal, 11.0)
 
 		devResp, err := SendGetRequest(fmt.Sprintf("%s/users", DevURL), "api-21")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, devResp.StatusCode)
 
 		devBody, err := ParseJSONResponse(devResp)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Contains(t, devBody, "meta")
 		assert.Contains(t, devBody["meta"], "total")
-		devTotal := devBody["meta"].(map[string]interface{})["total"].(float64)
+		devTotal := jsonField[float64](t, jsonField[interface{}](t, devBody, "meta"), "total")
 
 		// mismatch in the 'total' between Release and Dev
 		assert.NotEqual(t, releaseTotal, devTotal, "Release and Dev 'total' values should not match")
@@ -42,7 +43,7 @@ func TestListAllUsers(t *testing.T) {
 func TestUserLogin(t *testing.T) {
 	t.Run("User Login on Release and Dev", func(t *testing.T) {
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		email := user.Email
 
@@ -53,12 +54,12 @@ func TestUserLogin(t *testing.T) {
 
 		// release req
 		releaseResp, err := SendPostRequest(fmt.Sprintf("%s/users/login", ReleaseURL), loginData, "api-7")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseResp.StatusCode)
 
 		// dev req
 		devResp, err := SendPostRequest(fmt.Sprintf("%s/users/login", DevURL), loginData, "api-7")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusNotFound, devResp.StatusCode)
 
 		// The test compares the response codes between Release and Dev
@@ -70,7 +71,7 @@ func TestUserLogin(t *testing.T) {
 func TestCreateUser(t *testing.T) {
 	t.Run("Create User on Release and Dev with Existing Nickname", func(t *testing.T) {
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		createUserData := UserCreateRequest{
 			Email:    "new.1@gmail.com",
@@ -81,12 +82,12 @@ func TestCreateUser(t *testing.T) {
 
 		// release req
 		releaseResp, err := SendPostRequest(fmt.Sprintf("%s/users", ReleaseURL), createUserData, "api-3")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusConflict, releaseResp.StatusCode) // Expect 409 due to conflict (duplicate nickname)
 
 		// dev req
 		devResp, err := SendPostRequest(fmt.Sprintf("%s/users", DevURL), createUserData, "api-3")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, devResp.StatusCode) // Expect 200 even though the nickname is duplicated
 
 		// should Mismatch codes between Release and Dev
@@ -94,7 +95,7 @@ func TestCreateUser(t *testing.T) {
 
 		// reset server as it bricks next calls
 		setupResp, err := SendPostRequest("https://release-gs.qa-playground.com/api/v1/setup", "", "api-6")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusResetContent, setupResp.StatusCode, "Setup should return a 205 Content Reset")
 	})
 }
@@ -103,7 +104,7 @@ func TestCreateUser(t *testing.T) {
 func TestCreateUser2(t *testing.T) {
 	t.Run("Create User with Duplicate Nickname, Existing Email, Short Password, and Valid Data", func(t *testing.T) {
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		existingNicknameUserData := UserCreateRequest{
 			Email:    "max@gmail.com",
@@ -114,12 +115,12 @@ func TestCreateUser2(t *testing.T) {
 
 		// release req
 		releaseResp, err := SendPostRequest(fmt.Sprintf("%s/users", ReleaseURL), existingNicknameUserData, "api-22")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusConflict, releaseResp.StatusCode) // Expect 409 due to conflict (duplicate nickname)
 
 		// dev req
 		devResp, err := SendPostRequest(fmt.Sprintf("%s/users", DevURL), existingNicknameUserData, "api-22")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, devResp.StatusCode) // Expect 500 (server issue)
 
 		// release: Test with existing Email
@@ -131,12 +132,12 @@ func TestCreateUser2(t *testing.T) {
 		}
 
 		releaseRespEmail, err := SendPostRequest(fmt.Sprintf("%s/users", ReleaseURL), existingEmailData, "api-22")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusConflict, releaseRespEmail.StatusCode) // Expect 409
 
 		// dev: Test with Existing Email
 		devRespEmail, err := SendPostRequest(fmt.Sprintf("%s/users", DevURL), existingEmailData, "api-22")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, devRespEmail.StatusCode) // Expect 500 (server issue)
 
 		// release: Test with short Pass
@@ -148,12 +149,12 @@ func TestCreateUser2(t *testing.T) {
 		}
 
 		releaseRespPassword, err := SendPostRequest(fmt.Sprintf("%s/users", ReleaseURL), shortPasswordData, "api-22")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusBadRequest, releaseRespPassword.StatusCode) // Expect 400
 
 		// dev: Test with short Pass
 		devRespPassword, err := SendPostRequest(fmt.Sprintf("%s/users", DevURL), shortPasswordData, "api-22")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusBadRequest, devRespPassword.StatusCode) // Expect 400
 
 		// perfect data to create new user
@@ -166,17 +167,17 @@ func TestCreateUser2(t *testing.T) {
 
 		// release req
 		releaseRespValid, err := SendPostRequest(fmt.Sprintf("%s/users", ReleaseURL), validData, "api-22")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseRespValid.StatusCode) // Expect 200 due to valid input
 
 		// dev req
 		devRespValid, err := SendPostRequest(fmt.Sprintf("%s/users", DevURL), validData, "api-22")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusInternalServerError, devRespValid.StatusCode) // Expect 500 due to server issue
 
 		// reset server as it bricks next calls
 		setupResp, err := SendPostRequest("https://release-gs.qa-playground.com/api/v1/setup", "", "api-6")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusResetContent, setupResp.StatusCode, "Setup should return a 205 Content Reset")
 	})
 }
@@ -186,10 +187,10 @@ func TestUpdateUserWithDuplicateEmail(t *testing.T) {
 	t.Run("Update User with Duplicate Email", func(t *testing.T) {
 		// Fetch an existing user to reuse their email for the test
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		userLast, err := FetchExistingUser(1)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		updateUserData := UserUpdateRequest{
 			Email:    user.Email, // Reusing the existing email
@@ -200,12 +201,12 @@ func TestUpdateUserWithDuplicateEmail(t *testing.T) {
 
 		// release req
 		releaseResp, err := SendPatchRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, userLast.UUID), updateUserData, "api-4")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusConflict, releaseResp.StatusCode)
 
 		// dev req
 		devResp, err := SendPatchRequest(fmt.Sprintf("%s/users/%s", DevURL, userLast.UUID), updateUserData, "api-4")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.NotEqual(t, http.StatusConflict, devResp.StatusCode)
 
 		// Compare response codes between Release and Dev for mismatch
@@ -217,7 +218,7 @@ func TestUpdateUserWithDuplicateEmail(t *testing.T) {
 func TestUpdateUserAndLogin(t *testing.T) {
 	t.Run("Update User and Test Login", func(t *testing.T) {
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		updateUserData := UserCreateRequest{
 			Email:    user.Email,
@@ -228,7 +229,7 @@ func TestUpdateUserAndLogin(t *testing.T) {
 
 		// release req
 		releaseResp, err := SendPatchRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), updateUserData, "api-24")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseResp.StatusCode)
 
 		// login
@@ -239,13 +240,13 @@ func TestUpdateUserAndLogin(t *testing.T) {
 
 		// release req login
 		releaseLoginResp, err := SendPostRequest(fmt.Sprintf("%s/users/login", ReleaseURL), loginData, "api-24")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseLoginResp.StatusCode)
 
 		// Update in dev -----------------------------------
 
 		userLast, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		updateUserLastData := UserCreateRequest{
 			Email:    userLast.Email,
@@ -256,7 +257,7 @@ func TestUpdateUserAndLogin(t *testing.T) {
 
 		// dev req
 		devResp, err := SendPatchRequest(fmt.Sprintf("%s/users/%s", DevURL, userLast.UUID), updateUserLastData, "api-24")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, devResp.StatusCode)
 
 		// login
@@ -267,7 +268,7 @@ func TestUpdateUserAndLogin(t *testing.T) {
 
 		// release req login
 		devLoginResp, err := SendPostRequest(fmt.Sprintf("%s/users/login", ReleaseURL), loginLastData, "api-24")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusNotFound, devLoginResp.StatusCode)
 
 		// Compare update response codes between Release and Dev for match
@@ -286,23 +287,23 @@ func TestOffsetHandlingMismatch(t *testing.T) {
 
 		// release req
 		releaseResp, err := SendGetRequest(fmt.Sprintf("%s/users?offset=%d&limit=%d", ReleaseURL, offset, limit), "api-6")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseResp.StatusCode)
 
 		releaseBody, err := ParseJSONResponse(releaseResp)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		releaseUsers := releaseBody["users"].([]interface{})
+		releaseUsers := jsonField[[]interface{}](t, releaseBody, "users")
 
 		// dev req
 		devResp, err := SendGetRequest(fmt.Sprintf("%s/users?offset=%d&limit=%d", DevURL, offset, limit), "api-6")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, devResp.StatusCode)
 
 		devBody, err := ParseJSONResponse(devResp)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		devUsers := devBody["users"].([]interface{})
+		devUsers := jsonField[[]interface{}](t, devBody, "users")
 
 		// Compare the lengths: Release should return users based on offset, Dev should return all users
 		assert.NotEqual(t, len(releaseUsers), len(devUsers), "The user list length mismatch: Release and Dev servers handle offset differently.")
@@ -313,28 +314,28 @@ func TestOffsetHandlingMismatch(t *testing.T) {
 func TestFetchUserByUUID(t *testing.T) {
 	t.Run("Test Fetch User by UUID Mismatch", func(t *testing.T) {
 		user, err := FetchExistingUser(2)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		// release req
 		releaseResp, err := SendGetRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-23")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseResp.StatusCode)
 
 		releaseBody, err := ParseJSONResponse(releaseResp)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		releaseUUID := releaseBody["uuid"].(string)
+		releaseUUID := jsonField[string](t, releaseBody, "uuid")
 		assert.Equal(t, user.UUID, releaseUUID, "The UUID from Release server does not match the requested UUID")
 
 		// dev req
 		devResp, err := SendGetRequest(fmt.Sprintf("%s/users/%s", DevURL, user.UUID), "api-23")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, devResp.StatusCode)
 
 		devBody, err := ParseJSONResponse(devResp)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		devUUID := devBody["uuid"].(string)
+		devUUID := jsonField[string](t, devBody, "uuid")
 
 		// compare uuids returned in response for same user.UUID for mismatch
 		assert.NotEqual(t, user.UUID, devUUID, "The UUID from Dev server matches the requested UUID")
@@ -345,23 +346,23 @@ func TestFetchUserByUUID(t *testing.T) {
 func TestDeleteUserByUUID(t *testing.T) {
 	t.Run("Test DELETE User by UUID", func(t *testing.T) {
 		existingUser, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		// release req existing uuid
 		releaseResp, err := SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, existingUser.UUID), "api-1")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusNoContent, releaseResp.StatusCode, "Release server should return 204 for existing UUID")
 
 		nonExistingUUID := existingUser.UUID
 
 		// release req non existing uuid
 		releaseRespNonExist, err := SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, nonExistingUUID), "api-1")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusNotFound, releaseRespNonExist.StatusCode, "Release server should return 404 for non-existing UUID")
 
 		// dev req with non-existing UUID
 		devRespNonExist, err := SendDeleteRequest(fmt.Sprintf("%s/users/%s", DevURL, nonExistingUUID), "api-1")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		// dev server incapable of handling this request
 		assert.Equal(t, http.StatusInternalServerError, devRespNonExist.StatusCode, "Dev server should return 500 for non-existing UUID")
@@ -6,19 +6,20 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // api-5 Add an item to user's wishlist
 func TestAddItemToWishlist(t *testing.T) {
 	t.Run("Test Add Item to Wishlist", func(t *testing.T) {
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		game, err := FetchExistingGame(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		game2, err := FetchExistingGame(1)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		userUUID := user.UUID
 		gameUUID := game.UUID
@@ -34,44 +35,44 @@ func TestAddItemToWishlist(t *testing.T) {
 
 		// release req
 		releaseResp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/add", ReleaseURL, userUUID), releaseBody, "api-5")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, 200, releaseResp.StatusCode, "Release server should return 200")
 
 		// dev req
 		devResp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/add", DevURL, userUUID), devBody, "api-5")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, 422, devResp.StatusCode, "Dev server should return 422 when wishlist limit is reached")
 
 		// release req whishlist items
 		releaseWishlistResp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/wishlist", ReleaseURL, userUUID), "api-5")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		var releaseWishlist map[string]interface{}
 		err = json.NewDecoder(releaseWishlistResp.Body).Decode(&releaseWishlist)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		// get items from wishlist
-		items := releaseWishlist["items"].([]interface{})
+		items := jsonField[[]interface{}](t, releaseWishlist, "items")
 		// Check if the gameUUID is in the wishlist items
 		var itemUUIDs []string
 		for _, item := range items {
-			itemUUIDs = append(itemUUIDs, item.(map[string]interface{})["uuid"].(string))
+			itemUUIDs = append(itemUUIDs, jsonField[string](t, item, "uuid"))
 		}
 		assert.Contains(t, itemUUIDs, gameUUID, "Wishlist should contain the added item on release")
 
 		// dev req wishlist items
 		devWishlistResp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/wishlist", DevURL, userUUID), "api-5")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		var devWishlist map[string]interface{}
 		err = json.NewDecoder(devWishlistResp.Body).Decode(&devWishlist)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		// get items
-		devItems := devWishlist["items"].([]interface{})
+		devItems := jsonField[[]interface{}](t, devWishlist, "items")
 		var devItemUUIDs []string
 		for _, item := range devItems {
-			devItemUUIDs = append(devItemUUIDs, item.(map[string]interface{})["uuid"].(string))
+			devItemUUIDs = append(devItemUUIDs, jsonField[string](t, item, "uuid"))
 		}
 		assert.NotContains(t, devItemUUIDs, game2UUID, "Wishlist should not contain the item on dev due to error")
 
@@ -84,13 +85,13 @@ func TestAddItemToWishlist(t *testing.T) {
 func TestAddItemToWishlistAPI25(t *testing.T) {
 	t.Run("Test Add Item to Wishlist (API-25)", func(t *testing.T) {
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		game, err := FetchExistingGame(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		game2, err := FetchExistingGame(1)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		userUUID := user.UUID
 		gameUUID := game.UUID
@@ -105,44 +106,44 @@ func TestAddItemToWishlistAPI25(t *testing.T) {
 		}
 
 		wishlistResp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/wishlist", ReleaseURL, userUUID), "api-25")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		var wishlist map[string]interface{}
 		err = json.NewDecoder(wishlistResp.Body).Decode(&wishlist)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		items := wishlist["items"].([]interface{})
+		items := jsonField[[]interface{}](t, wishlist, "items")
 		for _, item := range items {
-			itemUUID := item.(map[string]interface{})["uuid"].(string)
+			itemUUID := jsonField[string](t, item, "uuid")
 			removeBody := WishlistBody{
 				ItemUUID: itemUUID,
 			}
 			_, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/remove", ReleaseURL, userUUID), removeBody, "api-25")
-			assert.NoError(t, err)
+			require.NoError(t, err)
 		}
 
 		// release req - Add game to wishlist
 		releaseResp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/add", ReleaseURL, userUUID), releaseBody, "api-25")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, 200, releaseResp.StatusCode, "Release server should return 200")
 
 		// dev req - Add game to wishlist
 		devResp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/add", DevURL, userUUID), devBody, "api-25")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, 200, devResp.StatusCode, "Dev server should return 200")
 
 		// Verify wishlist items in Release server
 		releaseWishlistResp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/wishlist", ReleaseURL, userUUID), "api-25")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		var releaseWishlist map[string]interface{}
 		err = json.NewDecoder(releaseWishlistResp.Body).Decode(&releaseWishlist)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		items = releaseWishlist["items"].([]interface{})
+		items = jsonField[[]interface{}](t, releaseWishlist, "items")
 		var releaseItemUUIDs []string
 		for _, item := range items {
-			releaseItemUUIDs = append(releaseItemUUIDs, item.(map[string]interface{})["uuid"].(string))
+			releaseItemUUIDs = append(releaseItemUUIDs, jsonField[string](t, item, "uuid"))
 		}
 
 		// Verify the item was added to the wishlist
@@ -153,16 +154,16 @@ func TestAddItemToWishlistAPI25(t *testing.T) {
 
 		// Verify wishlist items in Dev server
 		devWishlistResp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/wishlist", DevURL, userUUID), "api-25")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		var devWishlist map[string]interface{}
 		err = json.NewDecoder(devWishlistResp.Body).Decode(&devWishlist)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		devItems := devWishlist["items"].([]interface{})
+		devItems := jsonField[[]interface{}](t, devWishlist, "items")
 		var devItemUUIDs []string
 		for _, item := range devItems {
-			devItemUUIDs = append(devItemUUIDs, item.(map[string]interface{})["uuid"].(string))
+			devItemUUIDs = append(devItemUUIDs, jsonField[string](t, item, "uuid"))
 		}
 
 		// Verify that the item is NOT in the dev wishlist (since it is not actually saved)
@@ -174,13 +175,13 @@ func TestAddItemToWishlistAPI25(t *testing.T) {
 func TestRemoveItemFromWishlistAPI8(t *testing.T) {
 	t.Run("Test Remove Item from Wishlist (API-8)", func(t *testing.T) {
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		game1, err := FetchExistingGame(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		game2, err := FetchExistingGame(1)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		userUUID := user.UUID
 		game1UUID := game1.UUID
@@ -196,42 +197,42 @@ func TestRemoveItemFromWishlistAPI8(t *testing.T) {
 
 		// Add two games to the wishlist on release and Dev servers
 		_, err = SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/add", ReleaseURL, userUUID), releaseBody, "api-8")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		_, err = SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/add", ReleaseURL, userUUID), devBody, "api-8")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		// Try removing the first game on Release server
 		releaseResp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/remove", ReleaseURL, userUUID), releaseBody, "api-8")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, 200, releaseResp.StatusCode, "Release server should return 200 when removing an existing item")
 
 		// Try removing the first game again on Release server -  should return 404
 		releaseResp2, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/remove", ReleaseURL, userUUID), releaseBody, "api-8")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, 404, releaseResp2.StatusCode, "Release server should return 404 after item has been removed")
 
 		// Try removing the second game on dev server
 		devResp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/remove", DevURL, userUUID), devBody, "api-8")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, 200, devResp.StatusCode, "Dev server should return 200 when attempting to remove an item")
 
 		// Try removing the second game again on Dev server - returning 200 - indicates not removed in previous call
 		devResp2, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/remove", DevURL, userUUID), devBody, "api-8")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, 200, devResp2.StatusCode, "Dev server should still return 200 after an item is not actually removed")
 
 		// Verify wishlist items on server after removal
 		releaseWishlistResp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/wishlist", ReleaseURL, userUUID), "api-8")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		var releaseWishlist map[string]interface{}
 		err = json.NewDecoder(releaseWishlistResp.Body).Decode(&releaseWishlist)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		items := releaseWishlist["items"].([]interface{})
+		items := jsonField[[]interface{}](t, releaseWishlist, "items")
 		var releaseItemUUIDs []string
 		for _, item := range items {
-			releaseItemUUIDs = append(releaseItemUUIDs, item.(map[string]interface{})["uuid"].(string))
+			releaseItemUUIDs = append(releaseItemUUIDs, jsonField[string](t, item, "uuid"))
 		}
 
 		// Assert that the first game was removed (not in wishlist)
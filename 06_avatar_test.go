@@ -6,27 +6,35 @@ import (
 	"net/http"
 	"testing"
 
+	"QA-Bug-Hunter-jr/internal/avatarkit"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // api-11 Update user's avatar
 func TestUpdateUserAvatar(t *testing.T) {
 	t.Run("Update User Avatar and Test Login", func(t *testing.T) {
 		user, err := FetchExistingUser(0)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
-		avatarFilePath := "/path/to/valid/avatar.jpg"
+		avatar, err := avatarkit.Generate("jpeg", 128, 128)
+		require.NoError(t, err)
 
 		// release req
-		releaseAvatarResp, err := SendPutRequestWithFile(fmt.Sprintf("%s/users/%s/avatar", ReleaseURL, user.UUID), avatarFilePath, "api-11")
-		assert.NoError(t, err)
+		releaseAvatarResp, err := SendPutRequestWithData(fmt.Sprintf("%s/users/%s/avatar", ReleaseURL, user.UUID), avatar.Filename, avatar.Data, "api-11")
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseAvatarResp.StatusCode)
 
 		// storing the avatar_url
 		var releaseAvatar map[string]interface{}
 		err = json.NewDecoder(releaseAvatarResp.Body).Decode(&releaseAvatar)
-		assert.NoError(t, err)
-		releaseAvatarURL := releaseAvatar["avatar_url"].(string)
+		require.NoError(t, err)
+		releaseAvatarURL := jsonField[string](t, releaseAvatar, "avatar_url")
+
+		// the returned url serves the uploaded image
+		assert.NoError(t, verifyServedAvatar(&avatar, releaseAvatarURL), "Release should serve the uploaded avatar")
+		assert.NoError(t, verifyAvatarPersisted(Environments[0], user, releaseAvatarURL), "Release should persist the avatar")
 
 		loginData := LoginRequest{
 			Email:    user.Email,
@@ -35,37 +43,44 @@ func TestUpdateUserAvatar(t *testing.T) {
 
 		// Test on Release server - login to fetch the avatar
 		releaseLoginResp, err := SendPostRequest(fmt.Sprintf("%s/users/login", ReleaseURL), loginData, "api-11")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, releaseLoginResp.StatusCode)
 
 		var releaseLoginData map[string]interface{}
 		err = json.NewDecoder(releaseLoginResp.Body).Decode(&releaseLoginData)
-		assert.NoError(t, err)
-		releaseLoginAvatarURL := releaseLoginData["avatar_url"].(string)
+		require.NoError(t, err)
+		releaseLoginAvatarURL := jsonField[string](t, releaseLoginData, "avatar_url")
 
 		// Assert that the avatar URL matches the one returned by the avatar update
 		assert.Equal(t, releaseAvatarURL, releaseLoginAvatarURL, "Avatar URL on release should match after update")
 
 		// dev req
-		devAvatarResp, err := SendPutRequestWithFile(fmt.Sprintf("%s/users/%s/avatar", DevURL, user.UUID), avatarFilePath, "api-11")
-		assert.NoError(t, err)
+		devAvatarResp, err := SendPutRequestWithData(fmt.Sprintf("%s/users/%s/avatar", DevURL, user.UUID), avatar.Filename, avatar.Data, "api-11")
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, devAvatarResp.StatusCode)
 
 		// Store the avatar_url
 		var devAvatar map[string]interface{}
 		err = json.NewDecoder(devAvatarResp.Body).Decode(&devAvatar)
-		assert.NoError(t, err)
-		devAvatarURL := devAvatar["avatar_url"].(string)
+		require.NoError(t, err)
+		devAvatarURL := jsonField[string](t, devAvatar, "avatar_url")
+
+		// whether dev serves the file is logged only, the bug is the user record keeping the old url
+		if err := verifyServedAvatar(&avatar, devAvatarURL); err != nil {
+			t.Logf("Dev avatar %s: %v", devAvatarURL, err)
+		} else {
+			t.Logf("Dev avatar %s: serves the uploaded image", devAvatarURL)
+		}
 
 		// Login to fetch the user details after avatar update on dev
 		devLoginResp, err := SendPostRequest(fmt.Sprintf("%s/users/login", DevURL), loginData, "api-11")
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, http.StatusOK, devLoginResp.StatusCode)
 
 		var devLoginData map[string]interface{}
 		err = json.NewDecoder(devLoginResp.Body).Decode(&devLoginData)
-		assert.NoError(t, err)
-		devLoginAvatarURL := devLoginData["avatar_url"].(string)
+		require.NoError(t, err)
+		devLoginAvatarURL := jsonField[string](t, devLoginData, "avatar_url")
 
 		// Assert that the avatar URL on dev is NOT updated correctly (since the bug exists)
 		assert.NotEqual(t, devAvatarURL, devLoginAvatarURL, "Avatar URL on dev should not match after update, indicating the bug")
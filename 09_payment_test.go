@@ -1,11 +1,14 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"testing"
 
+	"QA-Bug-Hunter-jr/internal/chain"
+	"QA-Bug-Hunter-jr/internal/vars"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // api-19 get a payment
@@ -13,77 +16,219 @@ func TestGetPayment(t *testing.T) {
 	t.Run("Get payment and compare Release and Dev", func(t *testing.T) {
 		// create an order in Release
 		user, err := FetchExistingUser(9)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 
 		// Fetch an existing game
 		game, err := FetchExistingGame(2)
-		assert.NoError(t, err)
-
-		orderData := struct {
-			Items []struct {
-				ItemUUID string `json:"item_uuid"`
-				Quantity int    `json:"quantity"`
-			} `json:"items"`
-		}{
-			Items: []struct {
-				ItemUUID string `json:"item_uuid"`
-				Quantity int    `json:"quantity"`
-			}{
-				{ItemUUID: game.UUID, Quantity: 2},
-			},
-		}
+		require.NoError(t, err)
+
+		set := vars.New()
+		set.Set("user.uuid", user.UUID)
+		set.Set("game.uuid", game.UUID)
+		release := chain.New(Environment{Name: "Release", URL: ReleaseURL}, AuthHeader, set)
+		dev := chain.New(Environment{Name: "Dev", URL: DevURL}, AuthHeader, set)
 
 		// creating an order
-		releaseOrderResp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/orders", ReleaseURL, user.UUID), orderData, "api-16")
-		assert.NoError(t, err)
-
-		var releaseOrderRespBody map[string]interface{}
-		err = json.NewDecoder(releaseOrderResp.Body).Decode(&releaseOrderRespBody)
-		assert.NoError(t, err)
-
-		orderUUIDRelease, _ := releaseOrderRespBody["uuid"].(string)
-
-		// create payment data for Release
-		paymentDataRelease := struct {
-			OrderUUID     string `json:"order_uuid"`
-			PaymentMethod string `json:"payment_method"`
-		}{
-			OrderUUID:     orderUUIDRelease,
-			PaymentMethod: "mir_pay",
-		}
+		order := OrderCreateRequest{Items: []OrderItem{{ItemUUID: "{{game.uuid}}", Quantity: 2}}}
+		_, err = release.Post("/users/{{user.uuid}}/orders", order).Task("api-16").
+			Capture("order.uuid", "$.uuid", vars.UUID).Send()
+		require.NoError(t, err)
 
 		// creating a payment in release
-		releasePaymentResp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/payments", ReleaseURL, user.UUID), paymentDataRelease, "api-20")
-		assert.NoError(t, err)
+		payment := PaymentCreateRequest{OrderUUID: "{{order.uuid}}", PaymentMethod: "mir_pay"}
+		_, err = release.Post("/users/{{user.uuid}}/payments", payment).Task("api-20").
+			Capture("payment.uuid", "$.uuid", vars.UUID).Send()
+		require.NoError(t, err)
 
-		var releasePaymentRespBody map[string]interface{}
-		err = json.NewDecoder(releasePaymentResp.Body).Decode(&releasePaymentRespBody)
-		assert.NoError(t, err)
+		// get payment details from release and dev
+		releaseGet, err := release.Get("/payments/{{payment.uuid}}").Task("api-19").Expect(200).Send()
+		require.NoError(t, err)
+		devGet, err := dev.Get("/payments/{{payment.uuid}}").Task("api-19").Expect(200).Send()
+		require.NoError(t, err)
 
-		paymentUUIDRelease, _ := releasePaymentRespBody["uuid"].(string)
+		// Release response contains both created_at and updated_at
+		assert.Contains(t, releaseGet.Object(), "created_at", "Release response should contain created_at")
+		assert.Contains(t, releaseGet.Object(), "updated_at", "Release response should contain updated_at")
 
-		// get payment details from release
-		releaseGetResp, err := SendGetRequest(fmt.Sprintf("%s/payments/%s", ReleaseURL, paymentUUIDRelease), "api-19")
-		assert.NoError(t, err)
+		// dev response doesn't contains both created_at and updated_at
+		assert.NotContains(t, devGet.Object(), "created_at", "Dev response should not contain created_at")
+		assert.NotContains(t, devGet.Object(), "updated_at", "Dev response should not contain updated_at")
+	})
+}
 
-		var releaseGetRespBody map[string]interface{}
-		err = json.NewDecoder(releaseGetResp.Body).Decode(&releaseGetRespBody)
-		assert.NoError(t, err)
+// payment methods tried during discovery, the documentation only shows mir_pay
+var paymentMethodCandidates = []string{
+	"card", "sbp", "mir_pay", "yoomoney", "apple_pay", "google_pay", "paypal", "cash", "crypto",
+}
 
-		// get payment details from dev
-		devGetResp, err := SendGetRequest(fmt.Sprintf("%s/payments/%s", DevURL, paymentUUIDRelease), "api-19")
-		assert.NoError(t, err)
+// createOrder creates an open order on Release and returns its payload
+func createOrder(userUUID string, items ...OrderItem) (map[string]interface{}, error) {
+	resp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/orders", ReleaseURL, userUUID), OrderCreateRequest{Items: items}, "api-16")
+	if err != nil {
+		return nil, err
+	}
+	body, err := ParseJSONResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := body["uuid"].(string); !ok {
+		return nil, fmt.Errorf("creating order: status %d without uuid", resp.StatusCode)
+	}
+	return body, nil
+}
 
-		var devGetRespBody map[string]interface{}
-		err = json.NewDecoder(devGetResp.Body).Decode(&devGetRespBody)
-		assert.NoError(t, err)
+// payOrder pays an order on envURL and returns the status code and payload
+func payOrder(envURL, userUUID, orderUUID, method string) (int, map[string]interface{}, error) {
+	payment := PaymentCreateRequest{OrderUUID: orderUUID, PaymentMethod: method}
+	resp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/payments", envURL, userUUID), payment, "api-20")
+	if err != nil {
+		return 0, nil, err
+	}
+	body, err := ParseJSONResponse(resp)
+	if err != nil {
+		body = nil
+	}
+	return resp.StatusCode, body, nil
+}
 
-		// Release response contains both created_at and updated_at
-		assert.Contains(t, releaseGetRespBody, "created_at", "Release response should contain created_at")
-		assert.Contains(t, releaseGetRespBody, "updated_at", "Release response should contain updated_at")
+// discoverPaymentMethods pays a fresh order with every candidate and keeps the accepted methods
+func discoverPaymentMethods(env Environment, userUUID, itemUUID string) ([]string, error) {
+	var methods []string
+	for _, method := range paymentMethodCandidates {
+		order, err := createOrder(userUUID, OrderItem{ItemUUID: itemUUID, Quantity: 1})
+		if err != nil {
+			return nil, err
+		}
+		code, _, err := payOrder(env.URL, userUUID, order["uuid"].(string), method)
+		if err != nil {
+			return nil, err
+		}
+		if code >= 200 && code < 300 {
+			methods = append(methods, method)
+		}
+	}
+	return methods, nil
+}
 
-		// dev response doesn't contains both created_at and updated_at
-		assert.NotContains(t, devGetRespBody, "created_at", "Dev response should not contain created_at")
-		assert.NotContains(t, devGetRespBody, "updated_at", "Dev response should not contain updated_at")
+// api-20 Create a payment
+func TestCreatePayment(t *testing.T) {
+	games, err := FetchGames(2)
+	if !assert.NoError(t, err) {
+		return
+	}
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+	release := Environments[0]
+	methods := map[string][]string{}
+	t.Run("Discover supported payment methods", func(t *testing.T) {
+		for _, env := range Environments {
+			found, err := discoverPaymentMethods(env, user.UUID, games[0].UUID)
+			if !assert.NoError(t, err, env.Name) {
+				return
+			}
+			t.Logf("%s accepts payment methods %v", env.Name, found)
+			methods[env.Name] = found
+		}
+		assert.NotEmpty(t, methods[release.Name], "Release should accept at least one payment method")
+		for _, env := range Environments[1:] {
+			assert.Equal(t, methods[release.Name], methods[env.Name], "%s should accept the same payment methods as %s", env.Name, release.Name)
+		}
+	})
+
+	t.Run("Payment amount equals order total", func(t *testing.T) {
+		items := []OrderItem{{ItemUUID: games[0].UUID, Quantity: 2}, {ItemUUID: games[1].UUID, Quantity: 1}}
+		wantTotal := 2*games[0].Price + games[1].Price
+		require.NotEmpty(t, methods[release.Name], "no payment methods were discovered on %s", release.Name)
+
+		for _, env := range Environments {
+			for _, method := range methods[release.Name] {
+				order, err := createOrder(user.UUID, items...)
+				if !assert.NoError(t, err) {
+					return
+				}
+				orderUUID := order["uuid"].(string)
+				assert.EqualValues(t, wantTotal, order["total_price"], "order total should be priced from the catalog")
+
+				code, payment, err := payOrder(env.URL, user.UUID, orderUUID, method)
+				require.NoError(t, err)
+				if !assert.Equal(t, 200, code, "%s should accept payment method %s", env.Name, method) {
+					continue
+				}
+				assert.Equal(t, order["total_price"], payment["amount"], "%s %s payment amount should equal the order total", env.Name, method)
+				assert.Equal(t, orderUUID, payment["order_uuid"], env.Name)
+				assert.Equal(t, user.UUID, payment["user_uuid"], env.Name)
+				assert.Equal(t, method, payment["payment_method"], env.Name)
+
+				resp, err := SendGetRequest(fmt.Sprintf("%s/orders/%s", ReleaseURL, orderUUID), "api-17")
+				require.NoError(t, err)
+				paid, err := ParseJSONResponse(resp)
+				require.NoError(t, err)
+				assert.Equal(t, "paid", paid["status"], "order paid on %s with %s should be paid", env.Name, method)
+			}
+		}
+	})
+
+	t.Run("Paying a canceled order is rejected", func(t *testing.T) {
+		for _, env := range Environments {
+			order, err := createOrder(user.UUID, OrderItem{ItemUUID: games[0].UUID, Quantity: 1})
+			if !assert.NoError(t, err) {
+				return
+			}
+			orderUUID := order["uuid"].(string)
+			resp, err := SendPatchRequest(fmt.Sprintf("%s/orders/%s/status", ReleaseURL, orderUUID), OrderStatusUpdateRequest{Status: "canceled"}, "api-18")
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			code, _, err := payOrder(env.URL, user.UUID, orderUUID, "mir_pay")
+			require.NoError(t, err)
+			assert.True(t, code >= 400 && code < 500, "%s should reject paying a canceled order, got %d", env.Name, code)
+		}
+	})
+
+	t.Run("Paying an order twice is rejected", func(t *testing.T) {
+		for _, env := range Environments {
+			order, err := createOrder(user.UUID, OrderItem{ItemUUID: games[0].UUID, Quantity: 1})
+			if !assert.NoError(t, err) {
+				return
+			}
+			orderUUID := order["uuid"].(string)
+			code, _, err := payOrder(env.URL, user.UUID, orderUUID, "mir_pay")
+			require.NoError(t, err)
+			assert.Equal(t, 200, code, "%s should accept the first payment", env.Name)
+
+			code, _, err = payOrder(env.URL, user.UUID, orderUUID, "mir_pay")
+			require.NoError(t, err)
+			assert.True(t, code >= 400 && code < 500, "%s should reject paying a paid order, got %d", env.Name, code)
+		}
+	})
+
+	// api-19 every field of the created payment should come back unchanged
+	t.Run("Get payment round trips every field", func(t *testing.T) {
+		for _, env := range Environments {
+			order, err := createOrder(user.UUID, OrderItem{ItemUUID: games[1].UUID, Quantity: 3})
+			if !assert.NoError(t, err) {
+				return
+			}
+			code, created, err := payOrder(env.URL, user.UUID, order["uuid"].(string), "mir_pay")
+			require.NoError(t, err)
+			if !assert.Equal(t, 200, code, env.Name) {
+				continue
+			}
+
+			for _, reader := range Environments {
+				resp, err := SendGetRequest(fmt.Sprintf("%s/payments/%s", reader.URL, created["uuid"]), "api-19")
+				require.NoError(t, err)
+				fetched, err := ParseJSONResponse(resp)
+				require.NoError(t, err)
+				for field, want := range created {
+					got, ok := fetched[field]
+					if !assert.True(t, ok, "payment created on %s and read from %s is missing %s", env.Name, reader.Name, field) {
+						continue
+					}
+					assert.Equal(t, want, got, "payment created on %s and read from %s differs in %s", env.Name, reader.Name, field)
+				}
+			}
+		}
 	})
 }
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/proptest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// offset/limit query value, Raw is sent verbatim for non-numeric cases
+type pageValue struct {
+	N   int
+	Raw string
+}
+
+func (v pageValue) String() string {
+	if v.Raw != "" {
+		return v.Raw
+	}
+	return strconv.Itoa(v.N)
+}
+
+// a single pagination request
+type pageParams struct {
+	Offset pageValue
+	Limit  pageValue
+}
+
+func (p pageParams) String() string {
+	return fmt.Sprintf("offset=%s&limit=%s", p.Offset, p.Limit)
+}
+
+// what an environment returned for a page, normalised against its own user list
+type pageOutcome struct {
+	Status int
+	Total  int
+	Start  int // index of the first returned user in the full list, -1 if not contiguous
+	Count  int
+}
+
+func (o pageOutcome) String() string {
+	if o.Status != http.StatusOK {
+		return fmt.Sprintf("%d", o.Status)
+	}
+	if o.Start < 0 {
+		return fmt.Sprintf("200 %d non-contiguous users", o.Count)
+	}
+	return fmt.Sprintf("200 users[%d:%d]", o.Start, o.Start+o.Count)
+}
+
+// hugeValues overflow common integer sizes or exceed any sane page size
+var hugeValues = []pageValue{{N: 1 << 31}, {N: 1 << 62}, {Raw: "99999999999999999999"}}
+
+var nonNumericValues = []pageValue{{Raw: "abc"}, {Raw: "1.5"}, {Raw: "0x10"}, {Raw: " 1"}, {Raw: "1e3"}, {Raw: "-"}}
+
+// pageValueGen mixes boundary values around total with random ones
+func pageValueGen(r *rand.Rand, total int) pageValue {
+	switch r.Intn(6) {
+	case 0:
+		return []pageValue{{N: 0}, {N: 1}, {N: -1}, {N: total - 1}, {N: total}, {N: total + 1}}[r.Intn(6)]
+	case 1:
+		return hugeValues[r.Intn(len(hugeValues))]
+	case 2:
+		return nonNumericValues[r.Intn(len(nonNumericValues))]
+	case 3:
+		return pageValue{N: -r.Intn(1000) - 1}
+	default:
+		return pageValue{N: r.Intn(2*total + 2)}
+	}
+}
+
+// shrinkPageValue moves non-numeric values to numbers and numbers towards zero
+func shrinkPageValue(v pageValue) []pageValue {
+	if v.Raw != "" {
+		return []pageValue{{N: 0}, {N: 1}}
+	}
+	var out []pageValue
+	for _, n := range proptest.ShrinkInt(v.N) {
+		out = append(out, pageValue{N: n})
+	}
+	return out
+}
+
+func pageParamsGen(total int) proptest.Gen[pageParams] {
+	return proptest.Gen[pageParams]{
+		Generate: func(r *rand.Rand) pageParams {
+			return pageParams{Offset: pageValueGen(r, total), Limit: pageValueGen(r, total)}
+		},
+		Shrink: func(p pageParams) []pageParams {
+			var out []pageParams
+			for _, o := range shrinkPageValue(p.Offset) {
+				out = append(out, pageParams{Offset: o, Limit: p.Limit})
+			}
+			for _, l := range shrinkPageValue(p.Limit) {
+				out = append(out, pageParams{Offset: p.Offset, Limit: l})
+			}
+			return out
+		},
+	}
+}
+
+// pageRegion names the input class of a value so results can be grouped
+func pageRegion(v pageValue, total int) string {
+	switch {
+	case v.Raw == hugeValues[2].Raw || v.N >= 1<<31:
+		return "huge"
+	case v.Raw != "":
+		return "non-numeric"
+	case v.N < 0:
+		return "negative"
+	case v.N == 0:
+		return "zero"
+	case v.N < total:
+		return "within total"
+	default:
+		return "beyond total"
+	}
+}
+
+// validPage reports whether the params are a plain, in-range offset/limit pair
+func validPage(p pageParams) bool {
+	return p.Offset.Raw == "" && p.Limit.Raw == "" &&
+		p.Offset.N >= 0 && p.Limit.N > 0 && p.Offset.N < 1<<31 && p.Limit.N < 1<<31
+}
+
+// usersPager fetches pages from one environment and checks them against its full list
+type usersPager struct {
+	env   Environment
+	total int // meta.total reported by the environment
+	uuids []string
+	index map[string]int
+}
+
+func newUsersPager(env Environment) (*usersPager, error) {
+	resp, err := SendGetRequest(fmt.Sprintf("%s/users", env.URL), "api-6")
+	if err != nil {
+		return nil, err
+	}
+	body, err := ParseJSONResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	meta, ok := body["meta"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: meta field is missing", env.Name)
+	}
+	totalValue, ok := meta["total"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s: meta.total is missing", env.Name)
+	}
+	total := int(totalValue)
+
+	resp, err = SendGetRequest(fmt.Sprintf("%s/users?offset=0&limit=%d", env.URL, total), "api-6")
+	if err != nil {
+		return nil, err
+	}
+	body, err = ParseJSONResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	pager := &usersPager{env: env, total: total, index: map[string]int{}}
+	users, _ := body["users"].([]interface{})
+	for i, user := range users {
+		fields, _ := user.(map[string]interface{})
+		uuid, ok := fields["uuid"].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: user %d has no uuid", env.Name, i)
+		}
+		pager.uuids = append(pager.uuids, uuid)
+		pager.index[uuid] = i
+	}
+	return pager, nil
+}
+
+func (p *usersPager) fetch(params pageParams) (pageOutcome, error) {
+	query := fmt.Sprintf("offset=%s&limit=%s", url.QueryEscape(params.Offset.String()), url.QueryEscape(params.Limit.String()))
+	resp, err := SendGetRequest(fmt.Sprintf("%s/users?%s", p.env.URL, query), "api-6")
+	if err != nil {
+		return pageOutcome{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return pageOutcome{Status: resp.StatusCode, Start: -1}, nil
+	}
+
+	body, err := ParseJSONResponse(resp)
+	if err != nil {
+		return pageOutcome{}, err
+	}
+
+	outcome := pageOutcome{Status: resp.StatusCode, Start: -1}
+	if meta, ok := body["meta"].(map[string]interface{}); ok {
+		total, _ := meta["total"].(float64)
+		outcome.Total = int(total)
+	}
+
+	users, _ := body["users"].([]interface{})
+	outcome.Count = len(users)
+	if len(users) == 0 {
+		outcome.Start = 0
+		return outcome, nil
+	}
+	for i, user := range users {
+		uuid, _ := user.(map[string]interface{})["uuid"].(string)
+		at, known := p.index[uuid]
+		if !known || (i > 0 && at != outcome.Start+i) {
+			outcome.Start = -1
+			return outcome, nil
+		}
+		if i == 0 {
+			outcome.Start = at
+		}
+	}
+	return outcome, nil
+}
+
+// invariant checks a single page against the contract the Release server documents
+func (p *usersPager) invariant(params pageParams, o pageOutcome) error {
+	total := len(p.uuids)
+	if o.Status >= 500 {
+		return fmt.Errorf("%s: %s returned %d", p.env.Name, params, o.Status)
+	}
+	if o.Status != http.StatusOK {
+		if validPage(params) {
+			return fmt.Errorf("%s: %s is valid but returned %d", p.env.Name, params, o.Status)
+		}
+		return nil
+	}
+	if o.Total != p.total {
+		return fmt.Errorf("%s: %s meta.total is %d, want %d", p.env.Name, params, o.Total, p.total)
+	}
+	if o.Start < 0 {
+		return fmt.Errorf("%s: %s returned %d users that are not a contiguous window", p.env.Name, params, o.Count)
+	}
+	if !validPage(params) {
+		return nil
+	}
+
+	wantStart, wantCount := params.Offset.N, 0
+	if wantStart < total {
+		wantCount = min(params.Limit.N, total-wantStart)
+	}
+	if o.Count > params.Limit.N {
+		return fmt.Errorf("%s: %s returned %d users, more than the limit", p.env.Name, params, o.Count)
+	}
+	if wantCount == 0 && o.Count != 0 {
+		return fmt.Errorf("%s: %s is past the end but returned %s", p.env.Name, params, o)
+	}
+	if wantCount > 0 && (o.Start != wantStart || o.Count != wantCount) {
+		return fmt.Errorf("%s: %s returned %s, want users[%d:%d]", p.env.Name, params, o, wantStart, wantStart+wantCount)
+	}
+	return nil
+}
+
+// per-region pass/fail counts for every environment, and under agreesKey
+// for whether the environments answered alike
+type regionTally map[string]map[string][2]int
+
+// agreesKey counts the inputs the environments agreed on as ok, and those they diverged on as failed
+const agreesKey = "agrees"
+
+func (rt regionTally) record(region, env string, ok bool) {
+	if rt[region] == nil {
+		rt[region] = map[string][2]int{}
+	}
+	counts := rt[region][env]
+	if ok {
+		counts[0]++
+	} else {
+		counts[1]++
+	}
+	rt[region][env] = counts
+}
+
+func (rt regionTally) String() string {
+	regions := make([]string, 0, len(rt))
+	for region := range rt {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-44s", "region (offset / limit)")
+	for _, env := range Environments {
+		fmt.Fprintf(&b, " %-12s", env.Name+" ok/fail")
+	}
+	b.WriteString(" diverges\n")
+	for _, region := range regions {
+		fmt.Fprintf(&b, "%-44s", region)
+		for _, env := range Environments {
+			c := rt[region][env.Name]
+			fmt.Fprintf(&b, " %-12s", fmt.Sprintf("%d/%d", c[0], c[1]))
+		}
+		agreed := rt[region][agreesKey]
+		fmt.Fprintf(&b, " %d/%d\n", agreed[1], agreed[0]+agreed[1])
+	}
+	return b.String()
+}
+
+// api-6 List all Users - property based offset/limit
+func TestPaginationProperties(t *testing.T) {
+	pagers := map[string]*usersPager{}
+	for _, env := range Environments {
+		pager, err := newUsersPager(env)
+		if !assert.NoError(t, err) {
+			return
+		}
+		pagers[env.Name] = pager
+	}
+
+	total := len(pagers[Environments[0].Name].uuids)
+	gen := pageParamsGen(total)
+	region := func(p pageParams) string {
+		return pageRegion(p.Offset, total) + " / " + pageRegion(p.Limit, total)
+	}
+
+	for _, env := range Environments {
+		pager := pagers[env.Name]
+		t.Run("Invariants on "+env.Name, func(t *testing.T) {
+			failure := proptest.Check(proptest.Config{Runs: 40}, gen, func(p pageParams) error {
+				o, err := pager.fetch(p)
+				if err != nil {
+					return err
+				}
+				return pager.invariant(p, o)
+			})
+			if failure != nil {
+				t.Errorf("pagination invariant violated on %s\n%s", env.Name, failure)
+			}
+		})
+	}
+
+	t.Run("Dev matches Release", func(t *testing.T) {
+		release, dev := pagers[Environments[0].Name], pagers[Environments[1].Name]
+		failure := proptest.Check(proptest.Config{Runs: 40}, gen, func(p pageParams) error {
+			releaseOutcome, err := release.fetch(p)
+			if err != nil {
+				return err
+			}
+			devOutcome, err := dev.fetch(p)
+			if err != nil {
+				return err
+			}
+			if !sameOutcome(releaseOutcome, devOutcome) {
+				return fmt.Errorf("%s: Release returned %s, Dev returned %s", p, releaseOutcome, devOutcome)
+			}
+			return nil
+		})
+		if failure != nil {
+			t.Errorf("Dev pagination diverges from Release\n%s", failure)
+		}
+	})
+
+	// the table comes from its own sample, the checks above stop at the first
+	// failure and shrink, so their inputs would neither cover nor count evenly
+	t.Run("Results by region", func(t *testing.T) {
+		tally := regionTally{}
+		for _, p := range proptest.Sample(proptest.Config{Runs: 60}, gen) {
+			outcomes := map[string]pageOutcome{}
+			for _, env := range Environments {
+				pager := pagers[env.Name]
+				o, err := pager.fetch(p)
+				if err != nil {
+					t.Fatalf("%s: %v", env.Name, err)
+				}
+				outcomes[env.Name] = o
+				tally.record(region(p), env.Name, pager.invariant(p, o) == nil)
+			}
+			tally.record(region(p), agreesKey, sameOutcome(outcomes[Environments[0].Name], outcomes[Environments[1].Name]))
+		}
+		t.Logf("pagination results by region\n%s", tally)
+	})
+}
+
+// sameOutcome reports whether two environments answered a page alike
+func sameOutcome(a, b pageOutcome) bool {
+	return a.Status == b.Status && a.Start == b.Start && a.Count == b.Count
+}
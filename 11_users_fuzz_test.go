@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// seed values for the user payload fuzz targets: email, password, name, nickname
+var userFuzzSeeds = [][4]string{
+	{"valid.fuzz@example.com", "password", "Valid User", "validfuzz"},
+	{"", "", "", ""},
+	{" ", " ", " ", " "},
+	{"\tlead@example.com", "pass word", " trailing ", "nick name"},
+	{"ünïcødé@exämple.com", "пароль123", "名前 テスト", "ニックネーム"},
+	{"emoji@example.com", "🔑🔑🔑🔑🔑🔑", "😀", "😀😀😀"},
+	{strings.Repeat("a", 300) + "@example.com", strings.Repeat("p", 1000), strings.Repeat("N", 1000), strings.Repeat("n", 300)},
+	{"' OR '1'='1@example.com", "' OR 1=1 --", "Robert'); DROP TABLE users;--", "admin'--"},
+	{`{"$gt": ""}@example.com`, `{"$ne": null}`, "<script>alert(1)</script>", "${jndi:ldap://x}"},
+	{"no-at-sign", "123", "x", "ab"},
+	{"a@b", "12345", "\x00", "null\x00byte"},
+}
+
+// statusClass groups response codes so environments can be compared
+func statusClass(code int) string {
+	switch {
+	case code >= 500:
+		return "server error"
+	case code == http.StatusConflict:
+		return "conflict"
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return "rejected"
+	case code >= 200 && code < 300:
+		return "accepted"
+	default:
+		return fmt.Sprintf("unexpected %d", code)
+	}
+}
+
+// compareClasses flags 5xx responses and environments that disagree with Release
+func compareClasses(t *testing.T, input string, classes []string) {
+	t.Helper()
+	for i, env := range Environments {
+		if classes[i] == "server error" {
+			t.Errorf("%s returned a 5xx for %s", env.Name, input)
+		}
+		if i > 0 && classes[i] != classes[0] {
+			t.Errorf("%s classified %s as %q, %s as %q", Environments[0].Name, input, classes[0], env.Name, classes[i])
+		}
+	}
+}
+
+// deleteCreatedUser removes a user created by a fuzz input so every environment sees the same state
+func deleteCreatedUser(t *testing.T, resp *http.Response) {
+	t.Helper()
+	body, err := ParseJSONResponse(resp)
+	if err != nil {
+		return
+	}
+	if uuid, ok := body["uuid"].(string); ok && uuid != "" {
+		if _, err := SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, uuid), "api-1"); err != nil {
+			t.Logf("cleanup of user %s failed: %v", uuid, err)
+		}
+	}
+}
+
+var scratchUserSeq atomic.Int64
+
+// createScratchUser creates a throwaway user on Release for update fuzzing
+func createScratchUser(t *testing.T) *User {
+	t.Helper()
+	id := fmt.Sprintf("fz%d%d", time.Now().UnixNano(), scratchUserSeq.Add(1))
+	data := UserCreateRequest{
+		Email:    id + "@example.com",
+		Password: "password",
+		Name:     "Scratch " + id,
+		Nickname: id,
+	}
+
+	resp, err := SendPostRequest(fmt.Sprintf("%s/users", ReleaseURL), data, "api-3")
+	if err != nil {
+		t.Fatalf("creating scratch user: %v", err)
+	}
+	body, err := ParseJSONResponse(resp)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("creating scratch user: status %d, %v", resp.StatusCode, err)
+	}
+	uuid, ok := body["uuid"].(string)
+	if !ok {
+		t.Fatalf("creating scratch user: no uuid in %v", body)
+	}
+	return &User{UUID: uuid, Email: data.Email, Nickname: data.Nickname, Name: data.Name}
+}
+
+// reuse bits of FuzzCreateUser, the fuzzed value is replaced by the one of an existing user
+const (
+	reuseEmail uint8 = 1 << iota
+	reuseNickname
+)
+
+// reuseNames describes the reuse bits for messages
+func reuseNames(reuse uint8) string {
+	var names []string
+	if reuse&reuseEmail != 0 {
+		names = append(names, "email")
+	}
+	if reuse&reuseNickname != 0 {
+		names = append(names, "nickname")
+	}
+	return strings.Join(names, " and ")
+}
+
+// api-3 / api-22 create a new user, reuse takes the email and/or nickname of an
+// existing user, which must be a 409 everywhere unless Release rejects the payload first
+func FuzzCreateUser(f *testing.F) {
+	for _, seed := range userFuzzSeeds {
+		f.Add(seed[0], seed[1], seed[2], seed[3], uint8(0))
+	}
+	valid := userFuzzSeeds[0]
+	for _, reuse := range []uint8{reuseEmail, reuseNickname, reuseEmail | reuseNickname} {
+		f.Add(valid[0], valid[1], valid[2], valid[3], reuse)
+	}
+
+	f.Fuzz(func(t *testing.T, email, password, name, nickname string, reuse uint8) {
+		reuse &= reuseEmail | reuseNickname
+		if reuse != 0 {
+			existing := createScratchUser(t)
+			defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, existing.UUID), "api-1")
+			if reuse&reuseEmail != 0 {
+				email = existing.Email
+			}
+			if reuse&reuseNickname != 0 {
+				nickname = existing.Nickname
+			}
+		}
+		data := UserCreateRequest{Email: email, Password: password, Name: name, Nickname: nickname}
+		input := fmt.Sprintf("create %+q", []string{email, password, name, nickname})
+
+		classes := make([]string, len(Environments))
+		for i, env := range Environments {
+			resp, err := SendPostRequest(fmt.Sprintf("%s/users", env.URL), data, "api-22")
+			if err != nil {
+				t.Fatalf("%s: %v", env.Name, err)
+			}
+			classes[i] = statusClass(resp.StatusCode)
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				deleteCreatedUser(t, resp)
+			} else {
+				resp.Body.Close()
+			}
+		}
+
+		compareClasses(t, input, classes)
+		if reuse != 0 && classes[0] != "rejected" {
+			for i, env := range Environments {
+				if classes[i] != "conflict" {
+					t.Errorf("%s classified %s with an existing %s as %q, want a 409 conflict", env.Name, input, reuseNames(reuse), classes[i])
+				}
+			}
+		}
+	})
+}
+
+// api-4 / api-24 update a user
+func FuzzUpdateUser(f *testing.F) {
+	for _, seed := range userFuzzSeeds {
+		f.Add(seed[0], seed[1], seed[2], seed[3])
+	}
+
+	f.Fuzz(func(t *testing.T, email, password, name, nickname string) {
+		data := UserUpdateRequest{Email: email, Password: password, Name: name, Nickname: nickname}
+		input := fmt.Sprintf("update %+q", []string{email, password, name, nickname})
+
+		classes := make([]string, len(Environments))
+		for i, env := range Environments {
+			user := createScratchUser(t)
+			resp, err := SendPatchRequest(fmt.Sprintf("%s/users/%s", env.URL, user.UUID), data, "api-4")
+			if err != nil {
+				t.Fatalf("%s: %v", env.Name, err)
+			}
+			classes[i] = statusClass(resp.StatusCode)
+			resp.Body.Close()
+
+			if _, err := SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1"); err != nil {
+				t.Logf("cleanup of user %s failed: %v", user.UUID, err)
+			}
+		}
+
+		compareClasses(t, input, classes)
+	})
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/probe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// upper bounds for the length and range searches
+const (
+	probeMaxLength   = 4096
+	probeMaxQuantity = 1 << 31
+)
+
+// emailFormats are representative shapes an email validator may accept or reject
+var emailFormats = []probe.Sample{
+	{Name: "plain", Value: "probe@example.com"},
+	{Name: "plus tag", Value: "probe+tag@example.com"},
+	{Name: "subdomain", Value: "probe@mail.example.com"},
+	{Name: "no tld", Value: "probe@localhost"},
+	{Name: "ip literal", Value: "probe@[127.0.0.1]"},
+	{Name: "uppercase", Value: "PROBE@EXAMPLE.COM"},
+	{Name: "quoted local", Value: `"pro be"@example.com`},
+	{Name: "display name", Value: "Probe <probe@example.com>"},
+	{Name: "trailing space", Value: "probe@example.com "},
+	{Name: "no at", Value: "probe.example.com"},
+	{Name: "double at", Value: "probe@@example.com"},
+	{Name: "no domain", Value: "probe@"},
+	{Name: "empty", Value: ""},
+}
+
+// itemUUIDFormats derives identifier shapes from a known item UUID
+func itemUUIDFormats(valid string) []probe.Sample {
+	return []probe.Sample{
+		{Name: "known", Value: valid},
+		{Name: "uppercase", Value: strings.ToUpper(valid)},
+		{Name: "no hyphens", Value: strings.ReplaceAll(valid, "-", "")},
+		{Name: "braced", Value: "{" + valid + "}"},
+		{Name: "unknown", Value: "3fa85f64-5717-4562-b3fc-2c963f66afa6"},
+		{Name: "nil", Value: "00000000-0000-0000-0000-000000000000"},
+		{Name: "not a uuid", Value: "not-a-uuid"},
+		{Name: "empty", Value: ""},
+	}
+}
+
+// probeVerdict turns a probe response into a verdict and releases its body
+func probeVerdict(resp *http.Response, err error) (probe.Verdict, error) {
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return probe.VerdictFor(resp.StatusCode), nil
+}
+
+// userFieldProbe updates one field of a scratch user, keeping the other fields valid
+func userFieldProbe(env Environment, user *User, field string) probe.StringProbe {
+	return func(v string) (probe.Verdict, error) {
+		data := UserUpdateRequest{Email: user.Email, Password: "password", Name: user.Name, Nickname: user.Nickname}
+		switch field {
+		case "email":
+			data.Email = v
+		case "password":
+			data.Password = v
+		case "name":
+			data.Name = v
+		case "nickname":
+			data.Nickname = v
+		}
+		return probeVerdict(SendPatchRequest(fmt.Sprintf("%s/users/%s", env.URL, user.UUID), data, "api-4"))
+	}
+}
+
+// cartAddProbe adds an item to the cart and clears it again on Release
+func cartAddProbe(env Environment, userUUID string) func(itemUUID string, quantity int) (probe.Verdict, error) {
+	return func(itemUUID string, quantity int) (probe.Verdict, error) {
+		verdict, err := probeVerdict(AddItemToCart(userUUID, itemUUID, quantity, env.URL, "api-12"))
+		if verdict == probe.Accepted {
+			probeVerdict(SendPostRequest(fmt.Sprintf("%s/users/%s/cart/clear", ReleaseURL, userUUID), struct{}{}, "api-15"))
+		}
+		return verdict, err
+	}
+}
+
+// wishlistAddProbe adds an item to the wishlist and removes it again on Release
+func wishlistAddProbe(env Environment, userUUID string) probe.StringProbe {
+	return func(itemUUID string) (probe.Verdict, error) {
+		verdict, err := probeVerdict(SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/add", env.URL, userUUID), WishlistBody{ItemUUID: itemUUID}, "api-5"))
+		if verdict == probe.Accepted {
+			probeVerdict(SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/remove", ReleaseURL, userUUID), WishlistBody{ItemUUID: itemUUID}, "api-8"))
+		}
+		return verdict, err
+	}
+}
+
+// orderCreateProbe creates a single-item order and cancels it again on Release
+func orderCreateProbe(env Environment, userUUID string) func(itemUUID string, quantity int) (probe.Verdict, error) {
+	return func(itemUUID string, quantity int) (probe.Verdict, error) {
+		data := OrderCreateRequest{Items: []OrderItem{{ItemUUID: itemUUID, Quantity: quantity}}}
+		resp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/orders", env.URL, userUUID), data, "api-16")
+		if err != nil {
+			return "", err
+		}
+		verdict := probe.VerdictFor(resp.StatusCode)
+		if verdict != probe.Accepted {
+			resp.Body.Close()
+			return verdict, nil
+		}
+		if body, err := ParseJSONResponse(resp); err == nil {
+			if uuid, ok := body["uuid"].(string); ok {
+				probeVerdict(SendPatchRequest(fmt.Sprintf("%s/orders/%s/status", ReleaseURL, uuid), OrderStatusUpdateRequest{Status: "canceled"}, "api-18"))
+			}
+		}
+		return verdict, nil
+	}
+}
+
+// stringFieldRules runs the length, charset and optional format probes for one field
+func stringFieldRules(field string, p probe.StringProbe, fill func(n int) string, withChar func(c string) string, formats []probe.Sample) (probe.FieldRules, error) {
+	rules := probe.FieldRules{Field: field}
+
+	length, err := probe.Lengths(p, fill, probeMaxLength)
+	if err != nil {
+		return rules, err
+	}
+	rules.Length = &length
+
+	if rules.Classes, err = probe.Samples(p, probe.CharClasses, withChar); err != nil {
+		return rules, err
+	}
+	if formats != nil {
+		if rules.Formats, err = probe.Samples(p, formats, func(s string) string { return s }); err != nil {
+			return rules, err
+		}
+	}
+	return rules, nil
+}
+
+// itemFieldRules probes the quantity range and item_uuid formats of an add-style endpoint
+func itemFieldRules(field, itemUUID string, add func(itemUUID string, quantity int) (probe.Verdict, error)) ([]probe.FieldRules, error) {
+	quantity, err := probe.Range(func(n int) (probe.Verdict, error) { return add(itemUUID, n) }, -probeMaxQuantity, probeMaxQuantity)
+	if err != nil {
+		return nil, err
+	}
+	formats, err := probe.Samples(func(v string) (probe.Verdict, error) { return add(v, 1) }, itemUUIDFormats(itemUUID), func(s string) string { return s })
+	if err != nil {
+		return nil, err
+	}
+	return []probe.FieldRules{
+		{Field: field + ".quantity", Range: &quantity},
+		{Field: field + ".item_uuid", Formats: formats},
+	}, nil
+}
+
+// discoverRules infers the rules of every writable field on one environment
+func discoverRules(t *testing.T, env Environment, itemUUID string) ([]probe.FieldRules, error) {
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+	var all []probe.FieldRules
+	stringFields := []struct {
+		name     string
+		fill     func(n int) string
+		withChar func(c string) string
+		formats  []probe.Sample
+	}{
+		{"user.email", func(n int) string {
+			if n < 6 {
+				return strings.Repeat("a", n)
+			}
+			return strings.Repeat("a", n-5) + "@b.co"
+		}, func(c string) string { return "pro" + c + "be@example.com" }, emailFormats},
+		{"user.password", func(n int) string { return strings.Repeat("p", n) }, func(c string) string { return "passw0rd" + c }, nil},
+		{"user.name", func(n int) string { return strings.Repeat("N", n) }, func(c string) string { return "Probe" + c + "Name" }, nil},
+		{"user.nickname", func(n int) string { return strings.Repeat("n", n) }, func(c string) string { return "probe" + c + "nick" }, nil},
+	}
+	for _, f := range stringFields {
+		rules, err := stringFieldRules(f.name, userFieldProbe(env, user, strings.TrimPrefix(f.name, "user.")), f.fill, f.withChar, f.formats)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, rules)
+	}
+
+	cart, err := itemFieldRules("cart", itemUUID, cartAddProbe(env, user.UUID))
+	if err != nil {
+		return nil, err
+	}
+	all = append(all, cart...)
+
+	wishlist, err := probe.Samples(wishlistAddProbe(env, user.UUID), itemUUIDFormats(itemUUID), func(s string) string { return s })
+	if err != nil {
+		return nil, err
+	}
+	all = append(all, probe.FieldRules{Field: "wishlist.item_uuid", Formats: wishlist})
+
+	order, err := itemFieldRules("order.items", itemUUID, orderCreateProbe(env, user.UUID))
+	if err != nil {
+		return nil, err
+	}
+	return append(all, order...), nil
+}
+
+// api-4, api-5, api-12, api-16 - infer field validation rules by probing
+func TestDiscoverValidationRules(t *testing.T) {
+	game, err := FetchExistingGame(0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	byEnv := make([][]probe.FieldRules, len(Environments))
+	for i, env := range Environments {
+		byEnv[i], err = discoverRules(t, env, game.UUID)
+		if !assert.NoError(t, err, env.Name) {
+			return
+		}
+	}
+
+	var report strings.Builder
+	for f, reference := range byEnv[0] {
+		fmt.Fprintf(&report, "%s\n", reference.Field)
+		for i, env := range Environments {
+			for _, line := range byEnv[i][f].Lines() {
+				fmt.Fprintf(&report, "  %-8s %s\n", env.Name, line)
+			}
+		}
+	}
+	t.Logf("inferred validation rules\n%s", report.String())
+
+	for f, reference := range byEnv[0] {
+		for i, env := range Environments[1:] {
+			for _, diff := range probe.Diff(reference, byEnv[i+1][f]) {
+				t.Errorf("%s: %s disagrees with %s on %s", reference.Field, env.Name, Environments[0].Name, diff)
+			}
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/proptest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// number of catalog games the generated cart sequences draw from
+const cartModelGames = 3
+
+// cart operation kinds
+const (
+	cartOpAdd    = "add"
+	cartOpChange = "change"
+	cartOpRemove = "remove"
+	cartOpClear  = "clear"
+)
+
+// one step of a generated cart sequence, Item indexes the game pool
+type cartOp struct {
+	Kind     string
+	Item     int
+	Quantity int
+}
+
+func (op cartOp) String() string {
+	switch op.Kind {
+	case cartOpAdd, cartOpChange:
+		return fmt.Sprintf("%s(game%d, %d)", op.Kind, op.Item, op.Quantity)
+	case cartOpRemove:
+		return fmt.Sprintf("remove(game%d)", op.Item)
+	default:
+		return op.Kind + "()"
+	}
+}
+
+// reference model of a cart, priced from the catalog
+type cartModel struct {
+	games      []*Game
+	quantities map[string]int
+}
+
+func newCartModel(games []*Game) *cartModel {
+	return &cartModel{games: games, quantities: map[string]int{}}
+}
+
+// apply updates the model and reports whether the server should accept the step
+func (m *cartModel) apply(op cartOp) bool {
+	item := m.games[op.Item].UUID
+	_, inCart := m.quantities[item]
+
+	switch op.Kind {
+	case cartOpAdd:
+		m.quantities[item] += op.Quantity
+	case cartOpChange:
+		if !inCart {
+			return false
+		}
+		m.quantities[item] = op.Quantity
+	case cartOpRemove:
+		if !inCart {
+			return false
+		}
+		delete(m.quantities, item)
+	case cartOpClear:
+		m.quantities = map[string]int{}
+	}
+	return true
+}
+
+// check compares a cart returned by the server with the model
+func (m *cartModel) check(cart CartResponse) error {
+	prices := map[string]int{}
+	for _, g := range m.games {
+		prices[g.UUID] = g.Price
+	}
+
+	got := map[string]CartItem{}
+	for _, item := range cart.Items {
+		got[item.ItemUUID] = item
+	}
+
+	wantTotal := 0
+	for uuid, quantity := range m.quantities {
+		wantTotal += prices[uuid] * quantity
+		item, ok := got[uuid]
+		if !ok {
+			return fmt.Errorf("item %s with quantity %d is missing", uuid, quantity)
+		}
+		if item.Quantity != quantity || item.TotalPrice != prices[uuid]*quantity {
+			return fmt.Errorf("item %s is %d for %d, want %d for %d", uuid, item.Quantity, item.TotalPrice, quantity, prices[uuid]*quantity)
+		}
+	}
+	if len(got) != len(m.quantities) {
+		return fmt.Errorf("cart has %d items, want %d", len(got), len(m.quantities))
+	}
+	if cart.TotalPrice != wantTotal {
+		return fmt.Errorf("cart total_price is %d, want %d", cart.TotalPrice, wantTotal)
+	}
+	return nil
+}
+
+// sendCartOp performs one step against an environment
+func sendCartOp(env Environment, userUUID string, games []*Game, op cartOp) (*http.Response, error) {
+	item := games[op.Item].UUID
+	cartURL := fmt.Sprintf("%s/users/%s/cart", env.URL, userUUID)
+
+	switch op.Kind {
+	case cartOpAdd:
+		return AddItemToCart(userUUID, item, op.Quantity, env.URL, "api-12")
+	case cartOpChange:
+		return SendPostRequest(cartURL+"/change", ChangeItemQuantityRequest{ItemUUID: item, Quantity: op.Quantity}, "api-13")
+	case cartOpRemove:
+		return SendPostRequest(cartURL+"/remove", RemoveItemRequest{ItemUUID: item}, "api-14")
+	default:
+		return SendPostRequest(cartURL+"/clear", struct{}{}, "api-15")
+	}
+}
+
+// runCartSequence replays ops for a fresh user on env and checks the cart after every step
+func runCartSequence(t *testing.T, env Environment, games []*Game, ops []cartOp) error {
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+	model := newCartModel(games)
+	for i, op := range ops {
+		resp, err := sendCartOp(env, user.UUID, games, op)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		wantOK := model.apply(op)
+		gotOK := resp.StatusCode >= 200 && resp.StatusCode < 300
+		if gotOK != wantOK {
+			return fmt.Errorf("step %d %s: status %d, model expects success=%t", i+1, op, resp.StatusCode, wantOK)
+		}
+
+		cartResp, err := GetUserCart(user.UUID, env.URL, "api-12")
+		if err != nil {
+			return err
+		}
+		var cart CartResponse
+		err = json.NewDecoder(cartResp.Body).Decode(&cart)
+		cartResp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("step %d %s: decoding cart: %v", i+1, op, err)
+		}
+		if err := model.check(cart); err != nil {
+			return fmt.Errorf("step %d %s: %v", i+1, op, err)
+		}
+	}
+	return nil
+}
+
+// cartOpsGen generates sequences of up to 10 steps over the game pool
+var cartOpsGen = proptest.Gen[[]cartOp]{
+	Generate: func(r *rand.Rand) []cartOp {
+		ops := make([]cartOp, 1+r.Intn(10))
+		for i := range ops {
+			op := cartOp{Item: r.Intn(cartModelGames), Quantity: 1 + r.Intn(5)}
+			switch n := r.Intn(20); {
+			case n < 8:
+				op.Kind = cartOpAdd
+			case n < 13:
+				op.Kind = cartOpChange
+			case n < 17:
+				op.Kind = cartOpRemove
+			default:
+				op.Kind = cartOpClear
+			}
+			ops[i] = op
+		}
+		return ops
+	},
+	Shrink: func(ops []cartOp) [][]cartOp {
+		return proptest.ShrinkSlice(ops, func(op cartOp) []cartOp {
+			var out []cartOp
+			if op.Item > 0 {
+				out = append(out, cartOp{Kind: op.Kind, Item: 0, Quantity: op.Quantity})
+			}
+			if op.Quantity > 1 {
+				out = append(out, cartOp{Kind: op.Kind, Item: op.Item, Quantity: 1})
+			}
+			return out
+		})
+	},
+}
+
+// api-12, api-13, api-14, api-15 - model based cart sequences
+func TestCartStateMachine(t *testing.T) {
+	games := make([]*Game, cartModelGames)
+	for i := range games {
+		game, err := FetchExistingGame(int32(i))
+		if !assert.NoError(t, err) {
+			return
+		}
+		games[i] = game
+	}
+
+	for _, env := range Environments {
+		t.Run("Cart model on "+env.Name, func(t *testing.T) {
+			failure := proptest.Check(proptest.Config{Runs: 25}, cartOpsGen, func(ops []cartOp) error {
+				return runCartSequence(t, env, games, ops)
+			})
+			if failure != nil {
+				t.Errorf("%s cart diverges from the model\n%s", env.Name, failure)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// order statuses known from the API documentation, more are discovered at runtime
+var knownOrderStatuses = []string{"open", "canceled", "paid"}
+
+// payStep marks reaching a status by paying the order instead of patching it
+const payStep = "<pay>"
+
+// transitionMatrix records the response code of every from->to status change
+type transitionMatrix map[string]map[string]int
+
+func (m transitionMatrix) set(from, to string, code int) {
+	if m[from] == nil {
+		m[from] = map[string]int{}
+	}
+	m[from][to] = code
+}
+
+// cell renders one entry, "-" when the source status could not be reached
+func (m transitionMatrix) cell(from, to string) string {
+	code, ok := m[from][to]
+	if !ok {
+		return "-"
+	}
+	if code >= 200 && code < 300 {
+		return fmt.Sprintf("%d ok", code)
+	}
+	return fmt.Sprint(code)
+}
+
+func (m transitionMatrix) String(statuses []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-12s", "from \\ to")
+	for _, to := range statuses {
+		fmt.Fprintf(&b, " %-10s", to)
+	}
+	b.WriteString("\n")
+	for _, from := range statuses {
+		fmt.Fprintf(&b, "%-12s", from)
+		for _, to := range statuses {
+			fmt.Fprintf(&b, " %-10s", m.cell(from, to))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// orderExplorer drives fresh orders through the status lifecycle of one environment
+type orderExplorer struct {
+	env      Environment
+	userUUID string
+	itemUUID string
+	seen     map[string]bool // statuses observed in any response
+}
+
+// newOrder creates a single-item open order on Release
+func (e *orderExplorer) newOrder() (string, error) {
+	data := OrderCreateRequest{Items: []OrderItem{{ItemUUID: e.itemUUID, Quantity: 1}}}
+	resp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/orders", ReleaseURL, e.userUUID), data, "api-16")
+	if err != nil {
+		return "", err
+	}
+	body, err := ParseJSONResponse(resp)
+	if err != nil {
+		return "", err
+	}
+	uuid, ok := body["uuid"].(string)
+	if !ok {
+		return "", fmt.Errorf("creating order: status %d without uuid", resp.StatusCode)
+	}
+	e.observe(body)
+	return uuid, nil
+}
+
+// observe records the status of an order payload
+func (e *orderExplorer) observe(body map[string]interface{}) {
+	if status, ok := body["status"].(string); ok && status != "" {
+		e.seen[status] = true
+	}
+}
+
+// step moves an order to status on the explored environment and returns the code
+func (e *orderExplorer) step(orderUUID, status string) (int, error) {
+	if status == payStep {
+		payment := PaymentCreateRequest{OrderUUID: orderUUID, PaymentMethod: "mir_pay"}
+		resp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/payments", e.env.URL, e.userUUID), payment, "api-20")
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	resp, err := SendPatchRequest(fmt.Sprintf("%s/orders/%s/status", e.env.URL, orderUUID), OrderStatusUpdateRequest{Status: status}, "api-18")
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if body, err := ParseJSONResponse(resp); err == nil {
+			e.observe(body)
+		}
+	} else {
+		resp.Body.Close()
+	}
+	return resp.StatusCode, nil
+}
+
+// orderStatus reads an order's current status from the user's order list on Release
+func (e *orderExplorer) orderStatus(orderUUID string) (string, error) {
+	resp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/orders?offset=0&limit=100", ReleaseURL, e.userUUID), "api-17")
+	if err != nil {
+		return "", err
+	}
+	body, err := ParseJSONResponse(resp)
+	if err != nil {
+		return "", err
+	}
+	orders, _ := body["orders"].([]interface{})
+	for _, o := range orders {
+		order, ok := o.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("order list entry is %T, want an object", o)
+		}
+		e.observe(order)
+		if order["uuid"] == orderUUID {
+			status, _ := order["status"].(string)
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("order %s is not listed", orderUUID)
+}
+
+// reach creates an order and replays path on it, returning false if a step is refused
+func (e *orderExplorer) reach(path []string) (string, bool, error) {
+	orderUUID, err := e.newOrder()
+	if err != nil {
+		return "", false, err
+	}
+	for _, status := range path {
+		code, err := e.step(orderUUID, status)
+		if err != nil {
+			return "", false, err
+		}
+		if code < 200 || code >= 300 {
+			return orderUUID, false, nil
+		}
+	}
+	return orderUUID, true, nil
+}
+
+// explore runs a breadth-first search over statuses, trying every transition
+// from each reachable status on a fresh order
+func (e *orderExplorer) explore(statuses []string) (transitionMatrix, error) {
+	matrix := transitionMatrix{}
+	paths := map[string][]string{"open": {}}
+	queue := []string{"open"}
+
+	for len(queue) > 0 || paths["paid"] == nil {
+		if len(queue) == 0 {
+			// paid is usually reached through a payment rather than a status change
+			orderUUID, ok, err := e.reach([]string{payStep})
+			if err != nil {
+				return nil, err
+			}
+			status := ""
+			if ok {
+				if status, err = e.orderStatus(orderUUID); err != nil {
+					return nil, err
+				}
+			}
+			if status != "paid" {
+				break
+			}
+			paths["paid"] = []string{payStep}
+			queue = append(queue, "paid")
+		}
+
+		from := queue[0]
+		queue = queue[1:]
+		for _, to := range statuses {
+			orderUUID, ok, err := e.reach(paths[from])
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+			code, err := e.step(orderUUID, to)
+			if err != nil {
+				return nil, err
+			}
+			matrix.set(from, to, code)
+			if code >= 200 && code < 300 && paths[to] == nil {
+				paths[to] = append(append([]string{}, paths[from]...), to)
+				queue = append(queue, to)
+			}
+		}
+	}
+	return matrix, nil
+}
+
+// discoverOrderStatuses adds every status found on existing orders to the known ones,
+// entries that are not an object with a string status are reported and skipped
+func discoverOrderStatuses(t *testing.T, env Environment) ([]string, error) {
+	seen := map[string]bool{}
+	for _, s := range knownOrderStatuses {
+		seen[s] = true
+	}
+
+	users, err := FetchAllUsers(env.URL, "api-6")
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		resp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/orders?offset=0&limit=100", env.URL, user["uuid"]), "api-17")
+		if err != nil {
+			return nil, err
+		}
+		body, err := ParseJSONResponse(resp)
+		if err != nil {
+			continue
+		}
+		orders, _ := body["orders"].([]interface{})
+		for i, o := range orders {
+			order, ok := o.(map[string]interface{})
+			if !ok {
+				t.Errorf("%s user %v orders[%d] is %T, want an object", env.Name, user["uuid"], i, o)
+				continue
+			}
+			status, ok := order["status"].(string)
+			if !ok {
+				t.Errorf("%s order %v status is %T, want a string", env.Name, order["uuid"], order["status"])
+				continue
+			}
+			if status != "" {
+				seen[status] = true
+			}
+		}
+	}
+	return sortedStatuses(seen), nil
+}
+
+// sortedStatuses lists open first and the rest alphabetically
+func sortedStatuses(seen map[string]bool) []string {
+	var out []string
+	for s := range seen {
+		if s != "open" {
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return append([]string{"open"}, out...)
+}
+
+// api-18 Update an order status - lifecycle exploration
+func TestOrderStatusTransitions(t *testing.T) {
+	game, err := FetchExistingGame(0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+	seen := map[string]bool{}
+	for _, env := range Environments {
+		statuses, err := discoverOrderStatuses(t, env)
+		if !assert.NoError(t, err, env.Name) {
+			return
+		}
+		for _, s := range statuses {
+			seen[s] = true
+		}
+	}
+
+	// explore until no run reveals a status that was not tried yet
+	var matrices []transitionMatrix
+	var statuses []string
+	for len(statuses) < len(seen) {
+		statuses = sortedStatuses(seen)
+		matrices = matrices[:0]
+		for _, env := range Environments {
+			explorer := &orderExplorer{env: env, userUUID: user.UUID, itemUUID: game.UUID, seen: seen}
+			matrix, err := explorer.explore(statuses)
+			if !assert.NoError(t, err, env.Name) {
+				return
+			}
+			matrices = append(matrices, matrix)
+		}
+	}
+
+	for i, env := range Environments {
+		t.Logf("%s order status transitions (code of PATCH /orders/{uuid}/status)\n%s", env.Name, matrices[i].String(statuses))
+	}
+
+	for i, env := range Environments[1:] {
+		for _, from := range statuses {
+			_, wantReached := matrices[0][from]
+			_, gotReached := matrices[i+1][from]
+			if wantReached != gotReached {
+				t.Errorf("%s: reachable on %s is %t, on %s is %t", from, Environments[0].Name, wantReached, env.Name, gotReached)
+				continue
+			}
+			for _, to := range statuses {
+				want, got := matrices[0].cell(from, to), matrices[i+1].cell(from, to)
+				if want != got {
+					t.Errorf("%s -> %s: %s returns %s, %s returns %s", from, to, Environments[0].Name, want, env.Name, got)
+				}
+			}
+		}
+	}
+}
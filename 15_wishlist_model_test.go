@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/proptest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wishlist capacity documented for api-5
+const wishlistLimit = 10
+
+// number of catalog games the wishlist sequences draw from, enough to overflow the limit
+const wishlistModelGames = wishlistLimit + 2
+
+// one step of a wishlist sequence, Item indexes the game pool
+type wishlistOp struct {
+	Remove bool
+	Item   int
+}
+
+func (op wishlistOp) String() string {
+	if op.Remove {
+		return fmt.Sprintf("remove(game%d)", op.Item)
+	}
+	return fmt.Sprintf("add(game%d)", op.Item)
+}
+
+// wishlistResponse is the payload of GET /users/{uuid}/wishlist
+type wishlistResponse struct {
+	Items    []Game `json:"items"`
+	UserUUID string `json:"user_uuid"`
+}
+
+// reference model of a wishlist, items kept in insertion order
+type wishlistModel struct {
+	games []*Game
+	items []string
+}
+
+func (m *wishlistModel) index(uuid string) int {
+	for i, item := range m.items {
+		if item == uuid {
+			return i
+		}
+	}
+	return -1
+}
+
+// matches reports whether a fetched wishlist lists the model items in the same order
+func (m *wishlistModel) matches(got []string) bool {
+	if len(got) != len(m.items) {
+		return false
+	}
+	for i := range got {
+		if got[i] != m.items[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// apply updates the model and returns the status code the server should answer with
+func (m *wishlistModel) apply(op wishlistOp) int {
+	uuid := m.games[op.Item].UUID
+	i := m.index(uuid)
+
+	if op.Remove {
+		if i < 0 {
+			return http.StatusNotFound
+		}
+		m.items = append(m.items[:i:i], m.items[i+1:]...)
+		return http.StatusOK
+	}
+	// adding an item twice keeps a single entry
+	if i >= 0 {
+		return http.StatusOK
+	}
+	if len(m.items) >= wishlistLimit {
+		return http.StatusUnprocessableEntity
+	}
+	m.items = append(m.items, uuid)
+	return http.StatusOK
+}
+
+// diverges names the kind of bug behind a mismatch between a step and the model
+func (m *wishlistModel) diverges(op wishlistOp, before []string, code, want int, got []string) error {
+	uuid := m.games[op.Item].UUID
+	has := func(items []string) bool {
+		for _, item := range items {
+			if item == uuid {
+				return true
+			}
+		}
+		return false
+	}
+	ok := code >= 200 && code < 300
+
+	switch {
+	case !op.Remove && code == http.StatusUnprocessableEntity && want != code:
+		return fmt.Errorf("spurious 422 with %d of %d items", len(before), wishlistLimit)
+	case !op.Remove && ok && !has(got):
+		return fmt.Errorf("add answered %d but the item is not persisted", code)
+	case op.Remove && ok && has(got):
+		return fmt.Errorf("remove answered %d but the item is still listed", code)
+	case code != want:
+		return fmt.Errorf("status %d, want %d", code, want)
+	}
+	return fmt.Errorf("wishlist is %v, want %v", got, m.items)
+}
+
+// sendWishlistOp performs one step against an environment
+func sendWishlistOp(env Environment, userUUID string, games []*Game, op wishlistOp) (*http.Response, error) {
+	action := "add"
+	if op.Remove {
+		action = "remove"
+	}
+	return SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/%s", env.URL, userUUID, action), WishlistBody{ItemUUID: games[op.Item].UUID}, "api-5")
+}
+
+// fetchWishlist reads the item uuids of a wishlist in the order the server lists them
+func fetchWishlist(env Environment, userUUID string) ([]string, error) {
+	resp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/wishlist", env.URL, userUUID), "api-5")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var wishlist wishlistResponse
+	if err := json.NewDecoder(resp.Body).Decode(&wishlist); err != nil {
+		return nil, fmt.Errorf("decoding wishlist: %v", err)
+	}
+	items := make([]string, 0, len(wishlist.Items))
+	for _, item := range wishlist.Items {
+		items = append(items, item.UUID)
+	}
+	return items, nil
+}
+
+// runWishlistSequence replays ops for a fresh user on env and re-reads the wishlist after every step
+func runWishlistSequence(t *testing.T, env Environment, games []*Game, ops []wishlistOp) error {
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+	model := &wishlistModel{games: games}
+	for i, op := range ops {
+		before := append([]string{}, model.items...)
+		resp, err := sendWishlistOp(env, user.UUID, games, op)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		want := model.apply(op)
+		got, err := fetchWishlist(env, user.UUID)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != want || !model.matches(got) {
+			return fmt.Errorf("step %d %s: %v", i+1, op, model.diverges(op, before, resp.StatusCode, want, got))
+		}
+	}
+	return nil
+}
+
+// fillWishlistScenario fills the wishlist, overflows it and exercises duplicates and removals
+func fillWishlistScenario() []wishlistOp {
+	var ops []wishlistOp
+	for i := 0; i < wishlistLimit; i++ {
+		ops = append(ops, wishlistOp{Item: i})
+	}
+	return append(ops,
+		wishlistOp{Item: 3},                               // duplicate at the limit
+		wishlistOp{Item: wishlistLimit},                   // 11th item
+		wishlistOp{Remove: true, Item: wishlistLimit + 1}, // never added
+		wishlistOp{Remove: true, Item: 4},
+		wishlistOp{Item: wishlistLimit},   // fits again after the removal
+		wishlistOp{Remove: true, Item: 4}, // already removed
+	)
+}
+
+// wishlistOpsGen generates sequences biased towards adds so the limit is reached
+var wishlistOpsGen = proptest.Gen[[]wishlistOp]{
+	Generate: func(r *rand.Rand) []wishlistOp {
+		ops := make([]wishlistOp, 1+r.Intn(20))
+		for i := range ops {
+			ops[i] = wishlistOp{Remove: r.Intn(4) == 0, Item: r.Intn(wishlistModelGames)}
+		}
+		return ops
+	},
+	Shrink: func(ops []wishlistOp) [][]wishlistOp {
+		return proptest.ShrinkSlice(ops, func(op wishlistOp) []wishlistOp {
+			if op.Item == 0 {
+				return nil
+			}
+			return []wishlistOp{{Remove: op.Remove, Item: 0}}
+		})
+	},
+}
+
+// api-5 Wishlist capacity, ordering, duplicates and removals against a model
+func TestWishlistModel(t *testing.T) {
+	games, err := FetchGames(wishlistModelGames)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, env := range Environments {
+		t.Run("Fill to the limit on "+env.Name, func(t *testing.T) {
+			if err := runWishlistSequence(t, env, games, fillWishlistScenario()); err != nil {
+				t.Errorf("%s wishlist diverges from the model: %v", env.Name, err)
+			}
+		})
+
+		t.Run("Random sequences on "+env.Name, func(t *testing.T) {
+			failure := proptest.Check(proptest.Config{Runs: 20}, wishlistOpsGen, func(ops []wishlistOp) error {
+				return runWishlistSequence(t, env, games, ops)
+			})
+			if failure != nil {
+				t.Errorf("%s wishlist diverges from the model\n%s", env.Name, failure)
+			}
+		})
+	}
+}
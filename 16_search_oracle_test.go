@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"unicode"
+
+	"QA-Bug-Hunter-jr/internal/proptest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// searchOracle computes the expected /games/search results from the full catalog
+type searchOracle struct {
+	games []*Game
+}
+
+// expect returns the sorted uuids of every game whose title contains the query, ignoring case
+func (o searchOracle) expect(query string) []string {
+	folded := strings.ToLower(query)
+	uuids := []string{}
+	for _, g := range o.games {
+		if strings.Contains(strings.ToLower(g.Title), folded) {
+			uuids = append(uuids, g.UUID)
+		}
+	}
+	sort.Strings(uuids)
+	return uuids
+}
+
+// searchResult is what one environment answered for a query
+type searchResult struct {
+	uuids []string
+	total int
+}
+
+// searchGames pages through /games/search and collects every returned uuid
+func searchGames(env Environment, query string) (searchResult, error) {
+	var result searchResult
+	uuids := []string{}
+	for {
+		resp, err := SendGetRequest(fmt.Sprintf("%s/games/search?query=%s&offset=%d&limit=100", env.URL, url.QueryEscape(query), len(uuids)), "api-2")
+		if err != nil {
+			return result, err
+		}
+		var body struct {
+			Games []*Game `json:"games"`
+			Meta  struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			return result, fmt.Errorf("searching %q: status %d: %v", query, resp.StatusCode, err)
+		}
+		result.total = body.Meta.Total
+		for _, g := range body.Games {
+			uuids = append(uuids, g.UUID)
+		}
+		if len(body.Games) == 0 || len(uuids) >= body.Meta.Total {
+			break
+		}
+	}
+	sort.Strings(uuids)
+	result.uuids = uuids
+	return result, nil
+}
+
+// check compares an environment's answer for query with the oracle
+func (o searchOracle) check(env Environment, query string) error {
+	got, err := searchGames(env, query)
+	if err != nil {
+		return err
+	}
+	want := o.expect(query)
+	if got.total != len(want) {
+		return fmt.Errorf("query %q: meta.total is %d, want %d", query, got.total, len(want))
+	}
+	if !assert.ObjectsAreEqual(want, got.uuids) {
+		return fmt.Errorf("query %q: returned %d games %v, want %d games %v", query, len(got.uuids), o.titles(got.uuids), len(want), o.titles(want))
+	}
+	return nil
+}
+
+// titles maps uuids back to titles for readable reports
+func (o searchOracle) titles(uuids []string) []string {
+	byUUID := map[string]string{}
+	for _, g := range o.games {
+		byUUID[g.UUID] = g.Title
+	}
+	out := make([]string, 0, len(uuids))
+	for _, uuid := range uuids {
+		if title, ok := byUUID[uuid]; ok {
+			out = append(out, title)
+		} else {
+			out = append(out, uuid)
+		}
+	}
+	return out
+}
+
+// flipCase randomly upper- or lower-cases every letter
+func flipCase(r *rand.Rand, s string) string {
+	return strings.Map(func(c rune) rune {
+		if r.Intn(2) == 0 {
+			return unicode.ToUpper(c)
+		}
+		return unicode.ToLower(c)
+	}, s)
+}
+
+// fragment cuts a random run of 2 to 8 runes out of a title
+func fragment(r *rand.Rand, title string) string {
+	runes := []rune(title)
+	n := 2 + r.Intn(7)
+	if n > len(runes) {
+		n = len(runes)
+	}
+	start := r.Intn(len(runes) - n + 1)
+	return string(runes[start : start+n])
+}
+
+// searchableGames splits off games whose title has no word, queries cannot be drawn from them
+func searchableGames(games []*Game) (searchable, blank []*Game) {
+	for _, g := range games {
+		if len(strings.Fields(g.Title)) == 0 {
+			blank = append(blank, g)
+		} else {
+			searchable = append(searchable, g)
+		}
+	}
+	return searchable, blank
+}
+
+// searchQueryGen draws queries from real titles, fragments, word pairs and strings that
+// match nothing, every game must have a word in its title, see searchableGames
+func searchQueryGen(games []*Game) proptest.Gen[string] {
+	return proptest.Gen[string]{
+		Generate: func(r *rand.Rand) string {
+			title := games[r.Intn(len(games))].Title
+			var q string
+			switch r.Intn(5) {
+			case 0:
+				q = title
+			case 1:
+				q = fragment(r, title)
+			case 2:
+				// two consecutive words of one title
+				words := strings.Fields(title)
+				i := r.Intn(len(words))
+				q = strings.Join(words[i:min(i+2, len(words))], " ")
+			case 3:
+				// words of two different titles, matching only if some title holds both in order
+				other := strings.Fields(games[r.Intn(len(games))].Title)
+				q = strings.Fields(title)[0] + " " + other[len(other)-1]
+			default:
+				q = fmt.Sprintf("zq%xjx", r.Int63())
+			}
+			q = strings.TrimSpace(flipCase(r, q))
+			if q == "" {
+				return title
+			}
+			return q
+		},
+		// drop a rune from either end, keeping queries non-blank
+		Shrink: func(q string) []string {
+			runes := []rune(q)
+			if len(runes) <= 1 {
+				return nil
+			}
+			var out []string
+			for _, s := range []string{string(runes[1:]), string(runes[:len(runes)-1])} {
+				if s = strings.TrimSpace(s); s != "" {
+					out = append(out, s)
+				}
+			}
+			return out
+		},
+	}
+}
+
+// api-2 Search Games - results compared with an oracle over the full catalog
+func TestSearchOracle(t *testing.T) {
+	games, err := FetchAllGames(ReleaseURL, "api-9")
+	if !assert.NoError(t, err) || !assert.NotEmpty(t, games) {
+		return
+	}
+	oracle := searchOracle{games: games}
+	searchable, blank := searchableGames(games)
+	for _, g := range blank {
+		t.Errorf("game %s has a blank title %q", g.UUID, g.Title)
+	}
+	if len(searchable) == 0 {
+		return
+	}
+
+	for _, env := range Environments {
+		t.Run("Real titles on "+env.Name, func(t *testing.T) {
+			for _, g := range searchable {
+				for _, q := range []string{g.Title, strings.ToUpper(g.Title), strings.ToLower(g.Title)} {
+					if err := oracle.check(env, q); err != nil {
+						t.Errorf("%s: %v", env.Name, err)
+						break
+					}
+				}
+			}
+		})
+
+		t.Run("Generated queries on "+env.Name, func(t *testing.T) {
+			failure := proptest.Check(proptest.Config{Runs: 50}, searchQueryGen(searchable), func(q string) error {
+				return oracle.check(env, q)
+			})
+			if failure != nil {
+				t.Errorf("%s search diverges from the oracle\n%s", env.Name, failure)
+			}
+		})
+	}
+}
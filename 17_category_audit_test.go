@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// unknownCategoryUUID is a well formed uuid that no category uses
+const unknownCategoryUUID = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+
+// fetchAllCategories pages through /categories of an environment
+func fetchAllCategories(env Environment) ([]Category, error) {
+	var categories []Category
+	for {
+		resp, err := SendGetRequest(fmt.Sprintf("%s/categories?offset=%d&limit=100", env.URL, len(categories)), "api-10")
+		if err != nil {
+			return nil, err
+		}
+		var body struct {
+			Categories []Category `json:"categories"`
+			Meta       struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, body.Categories...)
+		if len(body.Categories) == 0 || len(categories) >= body.Meta.Total {
+			return categories, nil
+		}
+	}
+}
+
+// categoryPage is everything /categories/{uuid}/games returned for one category
+type categoryPage struct {
+	status int
+	total  int
+	games  []*Game
+}
+
+// fetchCategoryGames pages through the games of one category, stopping at the first non-200
+func fetchCategoryGames(env Environment, categoryUUID string) (categoryPage, error) {
+	var page categoryPage
+	for {
+		resp, err := SendGetRequest(fmt.Sprintf("%s/categories/%s/games?offset=%d&limit=100", env.URL, categoryUUID, len(page.games)), "api-10")
+		if err != nil {
+			return page, err
+		}
+		page.status = resp.StatusCode
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			return page, nil
+		}
+		var body struct {
+			Games []*Game `json:"games"`
+			Meta  struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			return page, err
+		}
+		page.total = body.Meta.Total
+		page.games = append(page.games, body.Games...)
+		if len(body.Games) == 0 || len(page.games) >= body.Meta.Total {
+			return page, nil
+		}
+	}
+}
+
+// auditCatalog cross-checks categories and games of one environment and lists every inconsistency
+func auditCatalog(env Environment) ([]string, error) {
+	categories, err := fetchAllCategories(env)
+	if err != nil {
+		return nil, err
+	}
+	games, err := FetchAllGames(env.URL, "api-9")
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []string
+	known := map[string]string{}
+	for _, c := range categories {
+		known[c.UUID] = c.Title
+	}
+
+	// every category reference on /games points to an existing category
+	members := map[string][]*Game{}
+	for _, g := range games {
+		for _, uuid := range g.CategoryUUIDs {
+			if _, ok := known[uuid]; !ok {
+				issues = append(issues, fmt.Sprintf("game %q refers to unknown category %s", g.Title, uuid))
+			}
+			members[uuid] = append(members[uuid], g)
+		}
+	}
+
+	for _, c := range categories {
+		page, err := fetchCategoryGames(env, c.UUID)
+		if err != nil {
+			return nil, err
+		}
+		if page.status != 200 {
+			issues = append(issues, fmt.Sprintf("category %q: status %d", c.Title, page.status))
+			continue
+		}
+
+		listed := map[string]bool{}
+		for _, g := range page.games {
+			listed[g.UUID] = true
+			if !containsUUID(g.CategoryUUIDs, c.UUID) {
+				issues = append(issues, fmt.Sprintf("category %q lists %q, whose category_uuids are %v", c.Title, g.Title, g.CategoryUUIDs))
+			}
+		}
+		for _, g := range members[c.UUID] {
+			if !listed[g.UUID] {
+				issues = append(issues, fmt.Sprintf("category %q does not list %q, which belongs to it", c.Title, g.Title))
+			}
+		}
+		if page.total != len(page.games) {
+			issues = append(issues, fmt.Sprintf("category %q: meta.total is %d, %d games listed", c.Title, page.total, len(page.games)))
+		}
+	}
+
+	page, err := fetchCategoryGames(env, unknownCategoryUUID)
+	if err != nil {
+		return nil, err
+	}
+	if page.status != 404 {
+		issues = append(issues, fmt.Sprintf("unknown category %s: status %d, want 404", unknownCategoryUUID, page.status))
+	}
+	return issues, nil
+}
+
+func containsUUID(uuids []string, uuid string) bool {
+	for _, u := range uuids {
+		if u == uuid {
+			return true
+		}
+	}
+	return false
+}
+
+// api-10 Get games by category - every category audited against /games
+func TestCategoryCatalogAudit(t *testing.T) {
+	for _, env := range Environments {
+		t.Run("Audit "+env.Name, func(t *testing.T) {
+			issues, err := auditCatalog(env)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if len(issues) > 0 {
+				t.Errorf("%s catalog has %d inconsistencies:\n  %s", env.Name, len(issues), strings.Join(issues, "\n  "))
+			}
+		})
+	}
+}
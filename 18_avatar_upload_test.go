@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/avatarkit"
+
+	"github.com/stretchr/testify/assert"
+	_ "golang.org/x/image/webp"
+)
+
+// avatarVerdict summarises an upload response as "200 accepted" or "400 rejected"
+func avatarVerdict(code int) string {
+	if code >= 200 && code < 300 {
+		return fmt.Sprintf("%d accepted", code)
+	}
+	return fmt.Sprintf("%d rejected", code)
+}
+
+// mustReject reports whether no server should accept the payload, whatever its policy on formats and sizes
+func mustReject(c avatarkit.Case) bool {
+	return c.Image == nil
+}
+
+// uploadAvatar uploads one payload for userUUID on env and returns the status code
+func uploadAvatar(env Environment, userUUID string, c avatarkit.Case) (int, error) {
+	resp, err := SendPutRequestWithData(fmt.Sprintf("%s/users/%s/avatar", env.URL, userUUID), c.Filename, c.Data, "api-11")
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// api-11 Update user's avatar - real images and invalid payloads on every environment
+func TestAvatarUploadMatrix(t *testing.T) {
+	cases, err := avatarkit.Cases()
+	if !assert.NoError(t, err) {
+		return
+	}
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+	verdicts := map[string][]string{}
+	var table strings.Builder
+	fmt.Fprintf(&table, "%-18s %10s", "payload", "bytes")
+	for _, env := range Environments {
+		fmt.Fprintf(&table, " %-14s", env.Name)
+	}
+	table.WriteString("\n")
+
+	for _, c := range cases {
+		fmt.Fprintf(&table, "%-18s %10d", c.Name, len(c.Data))
+		for _, env := range Environments {
+			code, err := uploadAvatar(env, user.UUID, c)
+			if !assert.NoError(t, err, "%s on %s", c.Name, env.Name) {
+				return
+			}
+			verdict := avatarVerdict(code)
+			verdicts[c.Name] = append(verdicts[c.Name], verdict)
+			fmt.Fprintf(&table, " %-14s", verdict)
+
+			if mustReject(c) {
+				assert.False(t, code >= 200 && code < 300, "%s should reject %s, got %d", env.Name, c.Name, code)
+			}
+			if code >= 500 {
+				t.Errorf("%s fails with %d on %s", env.Name, code, c.Name)
+			}
+		}
+		table.WriteString("\n")
+	}
+	t.Logf("avatar uploads\n%s", table.String())
+
+	for _, c := range cases {
+		for i, env := range Environments[1:] {
+			want, got := verdicts[c.Name][0], verdicts[c.Name][i+1]
+			if strings.Fields(want)[1] != strings.Fields(got)[1] {
+				t.Errorf("%s: %s %s, %s %s", c.Name, Environments[0].Name, want, env.Name, got)
+			}
+		}
+	}
+}
+
+// downloadAvatar fetches an avatar_url without the API token, as a browser would
+func downloadAvatar(avatarURL string) ([]byte, error) {
+	resp, err := http.Get(avatarURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %d", avatarURL, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// verifyServedAvatar downloads avatarURL and checks that it is the uploaded image
+func verifyServedAvatar(img *avatarkit.Image, avatarURL string) error {
+	data, err := downloadAvatar(avatarURL)
+	if err != nil {
+		return err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("served avatar does not decode: %v", err)
+	}
+	if format != img.Format || cfg.Width != img.Width || cfg.Height != img.Height {
+		return fmt.Errorf("served avatar is %s %dx%d, uploaded %s %dx%d", format, cfg.Width, cfg.Height, img.Format, img.Width, img.Height)
+	}
+	if !bytes.Equal(data, img.Data) {
+		return fmt.Errorf("served avatar has different bytes, %d served, %d uploaded", len(data), len(img.Data))
+	}
+	return nil
+}
+
+// verifyAvatarPersisted checks that GET /users/{uuid} and login on env return avatarURL
+func verifyAvatarPersisted(env Environment, user *User, avatarURL string) error {
+	resp, err := SendGetRequest(fmt.Sprintf("%s/users/%s", env.URL, user.UUID), "api-11")
+	if err != nil {
+		return err
+	}
+	body, err := ParseJSONResponse(resp)
+	if err != nil {
+		return err
+	}
+	if body["avatar_url"] != avatarURL {
+		return fmt.Errorf("GET /users/{uuid} returns avatar_url %v, upload returned %s", body["avatar_url"], avatarURL)
+	}
+
+	resp, err = SendPostRequest(fmt.Sprintf("%s/users/login", env.URL), LoginRequest{Email: user.Email, Password: "password"}, "api-11")
+	if err != nil {
+		return err
+	}
+	body, err = ParseJSONResponse(resp)
+	if err != nil {
+		return err
+	}
+	if body["avatar_url"] != avatarURL {
+		return fmt.Errorf("login returns avatar_url %v, upload returned %s", body["avatar_url"], avatarURL)
+	}
+	return nil
+}
+
+// api-11 Update user's avatar - the returned avatar_url serves the uploaded image and is persisted
+func TestAvatarIsServed(t *testing.T) {
+	for _, env := range Environments {
+		t.Run("Avatars on "+env.Name, func(t *testing.T) {
+			user := createScratchUser(t)
+			defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+			for _, format := range avatarkit.Formats {
+				img, err := avatarkit.Generate(format, 64, 48)
+				if !assert.NoError(t, err) {
+					return
+				}
+				resp, err := SendPutRequestWithData(fmt.Sprintf("%s/users/%s/avatar", env.URL, user.UUID), img.Filename, img.Data, "api-11")
+				if !assert.NoError(t, err) {
+					return
+				}
+				body, err := ParseJSONResponse(resp)
+				if !assert.NoError(t, err) || !assert.Equal(t, http.StatusOK, resp.StatusCode, "%s upload on %s", format, env.Name) {
+					continue
+				}
+				avatarURL, _ := body["avatar_url"].(string)
+
+				if err := verifyServedAvatar(&img, avatarURL); err != nil {
+					t.Errorf("%s %s avatar: %v", env.Name, format, err)
+				}
+				if err := verifyAvatarPersisted(env, user, avatarURL); err != nil {
+					t.Errorf("%s %s avatar: %v", env.Name, format, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// authSecret and authEmail are the two halves of AuthHeader
+var authSecret, authEmail, _ = strings.Cut(strings.TrimPrefix(AuthHeader, "Bearer "), ":")
+
+// brokenAuthHeaders must never be accepted, name identifies the variant in reports
+var brokenAuthHeaders = []struct {
+	name   string
+	header string
+}{
+	{"missing header", ""},
+	{"scheme only", "Bearer"},
+	{"empty token", "Bearer "},
+	{"basic scheme", "Basic " + base64.StdEncoding.EncodeToString([]byte(authSecret+":"+authEmail))},
+	{"token scheme", "Token " + authSecret + ":" + authEmail},
+	{"bare token", authSecret + ":" + authEmail},
+	{"doubled scheme", "Bearer " + AuthHeader},
+	{"secret only", "Bearer " + authSecret},
+	{"email only", "Bearer :" + authEmail},
+	{"no email", "Bearer " + authSecret + ":"},
+	{"email is not an address", "Bearer " + authSecret + ":not-an-email"},
+	{"garbage", "Bearer !!!%%%"},
+}
+
+// foreignAuthHeaders are well-formed tokens of other accounts, they must not see our data
+var foreignAuthHeaders = []struct {
+	name   string
+	header string
+}{
+	{"other email", "Bearer " + authSecret + ":bughunter.intruder@example.com"},
+	{"other secret", "Bearer intruder2024:" + authEmail},
+}
+
+// authFixture is a user of our account with something in every resource
+type authFixture struct {
+	user    *User
+	game    *Game
+	order   string // open order
+	payment string // payment of a second, paid order
+}
+
+// authProbe is one request of the fixture's resources
+type authProbe struct {
+	name   string
+	method string
+	path   string
+	body   interface{}
+	taskID string
+}
+
+// probes lists reads first, then mutations, deleting the user last
+func (f *authFixture) probes() []authProbe {
+	item := WishlistBody{ItemUUID: f.game.UUID}
+	return []authProbe{
+		{"get user", "GET", "/users/" + f.user.UUID, nil, "api-23"},
+		{"get cart", "GET", "/users/" + f.user.UUID + "/cart", nil, "api-12"},
+		{"get wishlist", "GET", "/users/" + f.user.UUID + "/wishlist", nil, "api-5"},
+		{"list orders", "GET", "/users/" + f.user.UUID + "/orders", nil, "api-17"},
+		{"get order", "GET", "/orders/" + f.order, nil, "api-17"},
+		{"get payment", "GET", "/payments/" + f.payment, nil, "api-19"},
+		{"add to cart", "POST", "/users/" + f.user.UUID + "/cart/add", AddItemRequest{ItemUUID: f.game.UUID, Quantity: 5}, "api-13"},
+		{"clear cart", "POST", "/users/" + f.user.UUID + "/cart/clear", nil, "api-15"},
+		{"remove from wishlist", "POST", "/users/" + f.user.UUID + "/wishlist/remove", item, "api-8"},
+		{"create order", "POST", "/users/" + f.user.UUID + "/orders", OrderCreateRequest{Items: []OrderItem{{ItemUUID: f.game.UUID, Quantity: 1}}}, "api-16"},
+		{"pay order", "POST", "/users/" + f.user.UUID + "/payments", PaymentCreateRequest{OrderUUID: f.order, PaymentMethod: "mir_pay"}, "api-20"},
+		{"cancel order", "PATCH", "/orders/" + f.order + "/status", OrderStatusUpdateRequest{Status: "canceled"}, "api-18"},
+		{"update user", "PATCH", "/users/" + f.user.UUID, map[string]string{"name": "Intruder"}, "api-24"},
+		{"delete user", "DELETE", "/users/" + f.user.UUID, nil, "api-1"},
+	}
+}
+
+// authSnapshot is what the owner sees of the fixture, compared before and after the probes
+type authSnapshot struct {
+	name     string
+	cart     []CartItem
+	wishlist []string
+	orders   []string // "uuid status"
+}
+
+// newAuthFixture creates a user on Release with a cart line, a wishlist item, an open order and a paid one
+func newAuthFixture(t *testing.T) *authFixture {
+	t.Helper()
+	game, err := FetchExistingGame(0)
+	if err != nil {
+		t.Fatalf("fetching a game: %v", err)
+	}
+	f := &authFixture{user: createScratchUser(t), game: game}
+	item := OrderItem{ItemUUID: game.UUID, Quantity: 1}
+
+	paid, err := createOrder(f.user.UUID, item)
+	if err != nil {
+		t.Fatalf("creating order: %v", err)
+	}
+	code, payment, err := payOrder(ReleaseURL, f.user.UUID, paid["uuid"].(string), "mir_pay")
+	if err != nil || code != http.StatusOK {
+		t.Fatalf("paying order: status %d, %v", code, err)
+	}
+	f.payment, _ = payment["uuid"].(string)
+
+	open, err := createOrder(f.user.UUID, item)
+	if err != nil {
+		t.Fatalf("creating order: %v", err)
+	}
+	f.order = open["uuid"].(string)
+
+	if resp, err := AddItemToCart(f.user.UUID, game.UUID, 1, ReleaseURL, "api-13"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("adding to cart: %v", err)
+	}
+	if resp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/wishlist/add", ReleaseURL, f.user.UUID), WishlistBody{ItemUUID: game.UUID}, "api-5"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("adding to wishlist: %v", err)
+	}
+	return f
+}
+
+// snapshot reads the fixture with our own token on Release
+func (f *authFixture) snapshot() (*authSnapshot, error) {
+	snap := &authSnapshot{}
+
+	resp, err := SendGetRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, f.user.UUID), "api-23")
+	if err != nil {
+		return nil, err
+	}
+	user, err := ParseJSONResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	snap.name, _ = user["name"].(string)
+
+	resp, err = GetUserCart(f.user.UUID, ReleaseURL, "api-12")
+	if err != nil {
+		return nil, err
+	}
+	cart, err := ParseJSONResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := cart["items"].([]interface{})
+	for _, raw := range items {
+		line, _ := raw.(map[string]interface{})
+		quantity, _ := line["quantity"].(float64)
+		uuid, _ := line["item_uuid"].(string)
+		snap.cart = append(snap.cart, CartItem{ItemUUID: uuid, Quantity: int(quantity)})
+	}
+
+	if snap.wishlist, err = fetchWishlist(Environments[0], f.user.UUID); err != nil {
+		return nil, err
+	}
+
+	resp, err = SendGetRequest(fmt.Sprintf("%s/users/%s/orders", ReleaseURL, f.user.UUID), "api-17")
+	if err != nil {
+		return nil, err
+	}
+	orders, err := ParseJSONResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	list, _ := orders["orders"].([]interface{})
+	for _, raw := range list {
+		order, _ := raw.(map[string]interface{})
+		snap.orders = append(snap.orders, fmt.Sprintf("%v %v", order["uuid"], order["status"]))
+	}
+	return snap, nil
+}
+
+// sendProbe sends p to env with authHeader and returns the status code and body
+func sendProbe(env Environment, p authProbe, authHeader string) (int, string, error) {
+	resp, err := SendRequestWithAuth(p.method, env.URL+p.path, authHeader, p.body, p.taskID)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body), err
+}
+
+// authClass groups responses to a request that should not be authorized
+func authClass(code int) string {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return "denied"
+	case code == http.StatusNotFound:
+		return "not found"
+	case code >= 200 && code < 300:
+		return "allowed"
+	case code >= 500:
+		return "server error"
+	default:
+		return fmt.Sprintf("rejected %d", code)
+	}
+}
+
+// runAuthProbes sends every probe with authHeader to every environment and
+// flags allowed requests, 5xx responses and environments that disagree with Release
+func runAuthProbes(t *testing.T, f *authFixture, variant, authHeader string) {
+	t.Helper()
+	for _, p := range f.probes() {
+		classes := make([]string, len(Environments))
+		for i, env := range Environments {
+			code, body, err := sendProbe(env, p, authHeader)
+			if !assert.NoError(t, err, "%s on %s", p.name, env.Name) {
+				return
+			}
+			classes[i] = authClass(code)
+			switch classes[i] {
+			case "allowed":
+				t.Errorf("%s: %s allows %s %s with %s (%d)", env.Name, p.name, p.method, p.path, variant, code)
+				if strings.Contains(body, f.user.Email) || strings.Contains(body, f.user.UUID) {
+					t.Errorf("%s: %s with %s leaks the user's data", env.Name, p.name, variant)
+				}
+			case "server error":
+				t.Errorf("%s: %s with %s fails with %d", env.Name, p.name, variant, code)
+			}
+		}
+		for i, env := range Environments[1:] {
+			if classes[i+1] != classes[0] {
+				t.Errorf("%s with %s: %s %s, %s %s", p.name, variant, Environments[0].Name, classes[0], env.Name, classes[i+1])
+			}
+		}
+	}
+}
+
+// assertUntouched compares the owner's view of the fixture with the snapshot taken before the probes
+func assertUntouched(t *testing.T, f *authFixture, before *authSnapshot) {
+	t.Helper()
+	after, err := f.snapshot()
+	if !assert.NoError(t, err, "re-reading the fixture") {
+		return
+	}
+	assert.Equal(t, before.name, after.name, "the user's name was changed through another token")
+	assert.Equal(t, before.cart, after.cart, "the cart was changed through another token")
+	assert.Equal(t, before.wishlist, after.wishlist, "the wishlist was changed through another token")
+	assert.Equal(t, before.orders, after.orders, "the orders were changed through another token")
+}
+
+// authorization - broken tokens are denied on every endpoint
+func TestAuthRejectsBrokenTokens(t *testing.T) {
+	f := newAuthFixture(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, f.user.UUID), "api-1")
+	before, err := f.snapshot()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, variant := range brokenAuthHeaders {
+		t.Run(variant.name, func(t *testing.T) {
+			runAuthProbes(t, f, variant.name, variant.header)
+		})
+	}
+	assertUntouched(t, f, before)
+}
+
+// authorization - another account's token can neither read nor change our data
+func TestAuthIsolatesAccounts(t *testing.T) {
+	f := newAuthFixture(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, f.user.UUID), "api-1")
+	before, err := f.snapshot()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, variant := range foreignAuthHeaders {
+		t.Run(variant.name, func(t *testing.T) {
+			runAuthProbes(t, f, variant.name, variant.header)
+		})
+	}
+	assertUntouched(t, f, before)
+}
+
+// crossUserFindings swaps the fixture owner's resources into the paths of
+// another user of the same account and returns what env let through
+func crossUserFindings(env Environment, f *authFixture, other *User) ([]string, error) {
+	var findings []string
+	before, err := f.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/orders", env.URL, other.UUID), "api-17")
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(body), f.order) || strings.Contains(string(body), f.user.UUID) {
+		findings = append(findings, "another user's order list shows the owner's orders")
+	}
+
+	resp, err = GetUserCart(other.UUID, env.URL, "api-12")
+	if err != nil {
+		return nil, err
+	}
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(body), f.game.UUID) {
+		findings = append(findings, "another user's cart shows the owner's items")
+	}
+
+	resp, err = SendGetRequest(fmt.Sprintf("%s/users/%s/payments/%s", env.URL, other.UUID, f.payment), "api-19")
+	if err != nil {
+		return nil, err
+	}
+	body, err = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && strings.Contains(string(body), f.payment) {
+		findings = append(findings, fmt.Sprintf("another user's path shows the owner's payment (%d)", resp.StatusCode))
+	}
+
+	code, _, err := payOrder(env.URL, other.UUID, f.order, "mir_pay")
+	if err != nil {
+		return nil, err
+	}
+	if code >= 200 && code < 300 {
+		findings = append(findings, fmt.Sprintf("another user paid the owner's order (%d)", code))
+	}
+
+	for _, p := range []authProbe{
+		{"remove from cart", "POST", "/users/" + other.UUID + "/cart/remove", RemoveItemRequest{ItemUUID: f.game.UUID, Quantity: 1}, "api-14"},
+		{"remove from wishlist", "POST", "/users/" + other.UUID + "/wishlist/remove", WishlistBody{ItemUUID: f.game.UUID}, "api-8"},
+	} {
+		if _, _, err := sendProbe(env, p, AuthHeader); err != nil {
+			return nil, err
+		}
+	}
+
+	after, err := f.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	if fmt.Sprint(before.cart) != fmt.Sprint(after.cart) {
+		findings = append(findings, fmt.Sprintf("another user's requests changed the owner's cart from %v to %v", before.cart, after.cart))
+	}
+	if fmt.Sprint(before.wishlist) != fmt.Sprint(after.wishlist) {
+		findings = append(findings, fmt.Sprintf("another user's requests changed the owner's wishlist from %v to %v", before.wishlist, after.wishlist))
+	}
+	if fmt.Sprint(before.orders) != fmt.Sprint(after.orders) {
+		findings = append(findings, fmt.Sprintf("another user's requests changed the owner's orders from %v to %v", before.orders, after.orders))
+	}
+	return findings, nil
+}
+
+// authorization - one user's uuid in the path gives no access to another user's resources
+func TestCrossUserAccess(t *testing.T) {
+	allowed := make([]int, len(Environments))
+	for i, env := range Environments {
+		t.Run(env.Name, func(t *testing.T) {
+			f := newAuthFixture(t)
+			defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, f.user.UUID), "api-1")
+			other := createScratchUser(t)
+			defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, other.UUID), "api-1")
+
+			findings, err := crossUserFindings(env, f, other)
+			if !assert.NoError(t, err) {
+				return
+			}
+			allowed[i] = len(findings)
+			for _, finding := range findings {
+				t.Errorf("%s: %s", env.Name, finding)
+			}
+		})
+	}
+	for i, env := range Environments[1:] {
+		if (allowed[i+1] == 0) != (allowed[0] == 0) {
+			t.Errorf("cross-user access: %s has %d findings, %s has %d", Environments[0].Name, allowed[0], env.Name, allowed[i+1])
+		}
+	}
+}
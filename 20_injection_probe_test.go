@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/inject"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// injectionTargets are the parameterized endpoints, placeholders are filled from the fixture.
+// offset and limit are lenient as the API may fall back to its default page for a bad number.
+var injectionTargets = []inject.Target{
+	{Method: "GET", Path: "/users/{user}"},
+	{Method: "GET", Path: "/users/{user}/cart"},
+	{Method: "POST", Path: "/users/{user}/cart/add", Body: AddItemRequest{Quantity: 1}},
+	{Method: "GET", Path: "/users/{user}/wishlist"},
+	{Method: "GET", Path: "/users/{user}/orders"},
+	{Method: "GET", Path: "/games/{game}"},
+	{Method: "GET", Path: "/categories/{category}/games"},
+	{Method: "GET", Path: "/orders/{order}"},
+	{Method: "GET", Path: "/payments/{payment}"},
+	{Method: "GET", Path: "/games/search", Query: []string{"query", "offset", "limit"}, Lenient: []string{"query", "offset", "limit"}},
+	{Method: "GET", Path: "/users", Query: []string{"offset", "limit"}, Lenient: []string{"offset", "limit"}},
+}
+
+// noRedirects keeps redirects visible to the probe, see inject.Sender
+var noRedirects = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
+
+// injectionSender sends a probe with the suite's token
+func injectionSender(method, url string, body interface{}) (inject.Response, error) {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return inject.Response{}, err
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return inject.Response{}, err
+	}
+	req.Header.Set("Authorization", AuthHeader)
+	req.Header.Set("X-Task-Id", "api-probe")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := noRedirects.Do(req)
+	if err != nil {
+		return inject.Response{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return inject.Response{Status: resp.StatusCode, Body: data}, err
+}
+
+// hostile path and query values - 4xx everywhere, no 5xx, no leaked internals, no data for a bogus identifier
+func TestInjectionProbe(t *testing.T) {
+	f := newAuthFixture(t)
+	defer SendDeleteRequest(ReleaseURL+"/users/"+f.user.UUID, "api-1")
+	category, err := FetchCategory()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the cart body needs a real game, the path carries the probed user
+	targets := append([]inject.Target(nil), injectionTargets...)
+	for i := range targets {
+		if targets[i].Body != nil {
+			targets[i].Body = AddItemRequest{ItemUUID: f.game.UUID, Quantity: 1}
+		}
+	}
+	values := map[string]string{
+		"user":     f.user.UUID,
+		"game":     f.game.UUID,
+		"category": category.UUID,
+		"order":    f.order,
+		"payment":  f.payment,
+		"query":    "a",
+		"offset":   "0",
+		"limit":    "10",
+	}
+
+	results := make([][]inject.Result, len(Environments))
+	for i, env := range Environments {
+		results[i], err = inject.Run(env.URL, targets, values, inject.Payloads(), injectionSender)
+		if !assert.NoError(t, err, env.Name) {
+			return
+		}
+		t.Logf("%s, %d probes\n%s", env.Name, len(results[i]), strings.Join(inject.Summary(results[i]), "\n"))
+		for _, r := range inject.Findings(results[i]) {
+			t.Errorf("%s: %s", env.Name, r)
+		}
+	}
+	for i, env := range Environments[1:] {
+		for _, diff := range inject.Diff(results[0], results[i+1]) {
+			t.Errorf("%s vs %s: %s", Environments[0].Name, env.Name, diff)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// raceWidth is how many requests each race fires at once
+const raceWidth = 8
+
+// concurrently calls fn(0..n-1) from n goroutines released at the same time
+// and returns the status codes by index
+func concurrently(n int, fn func(i int) (*http.Response, error)) ([]int, error) {
+	codes := make([]int, n)
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			resp, err := fn(i)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			resp.Body.Close()
+			codes[i] = resp.StatusCode
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return codes, nil
+}
+
+// accepted counts the 2xx codes
+func accepted(codes []int) int {
+	n := 0
+	for _, code := range codes {
+		if code >= 200 && code < 300 {
+			n++
+		}
+	}
+	return n
+}
+
+// fetchCart reads a user's cart on env
+func fetchCart(env Environment, userUUID string) (CartResponse, error) {
+	var cart CartResponse
+	resp, err := GetUserCart(userUUID, env.URL, "api-12")
+	if err != nil {
+		return cart, err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&cart); err != nil {
+		return cart, fmt.Errorf("decoding cart: %v", err)
+	}
+	return cart, nil
+}
+
+// cartRace prepares a cart sequentially, runs one race on it and checks the result against the model
+type cartRace struct {
+	name    string
+	prefill []cartOp
+	race    func(i int) cartOp
+}
+
+var cartRaces = []cartRace{
+	{
+		name: "adds of distinct items",
+		race: func(i int) cartOp { return cartOp{Kind: cartOpAdd, Item: i, Quantity: 1} },
+	},
+	{
+		name: "adds of one item",
+		race: func(i int) cartOp { return cartOp{Kind: cartOpAdd, Item: 0, Quantity: 1} },
+	},
+	{
+		name:    "changes of distinct items",
+		prefill: raceFill(),
+		race:    func(i int) cartOp { return cartOp{Kind: cartOpChange, Item: i, Quantity: i + 2} },
+	},
+	{
+		name:    "removes of every other item, each sent twice",
+		prefill: raceFill(),
+		race:    func(i int) cartOp { return cartOp{Kind: cartOpRemove, Item: i - i%2} },
+	},
+}
+
+// raceFill adds one of each of the first raceWidth games
+func raceFill() []cartOp {
+	ops := make([]cartOp, raceWidth)
+	for i := range ops {
+		ops[i] = cartOp{Kind: cartOpAdd, Item: i, Quantity: 1}
+	}
+	return ops
+}
+
+// run plays the race on env for a fresh user. The model applies every
+// accepted racing op; since they commute, any order gives the same cart.
+func (r cartRace) run(t *testing.T, env Environment, games []*Game) error {
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+	model := newCartModel(games)
+	for _, op := range r.prefill {
+		resp, err := sendCartOp(env, user.UUID, games, op)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		model.apply(op)
+	}
+
+	ops := make([]cartOp, raceWidth)
+	for i := range ops {
+		ops[i] = r.race(i)
+	}
+	codes, err := concurrently(raceWidth, func(i int) (*http.Response, error) {
+		return sendCartOp(env, user.UUID, games, ops[i])
+	})
+	if err != nil {
+		return err
+	}
+	for i, op := range ops {
+		if codes[i] >= 200 && codes[i] < 300 {
+			model.apply(op)
+		}
+	}
+
+	cart, err := fetchCart(env, user.UUID)
+	if err != nil {
+		return err
+	}
+	if err := model.check(cart); err != nil {
+		return fmt.Errorf("%d of %d requests accepted, %v", accepted(codes), raceWidth, err)
+	}
+	return nil
+}
+
+// api-12, api-13, api-14 - concurrent mutations of one cart lose no update
+func TestConcurrentCart(t *testing.T) {
+	games, err := FetchGames(raceWidth)
+	if !assert.NoError(t, err) || !assert.Len(t, games, raceWidth) {
+		return
+	}
+	for _, env := range Environments {
+		for _, race := range cartRaces {
+			t.Run(env.Name+" "+race.name, func(t *testing.T) {
+				if err := race.run(t, env, games); err != nil {
+					t.Errorf("%s, %s: %v", env.Name, race.name, err)
+				}
+			})
+		}
+	}
+}
+
+// raceWishlistPrefill leaves room for 3 items, fewer than the racing adds
+const raceWishlistPrefill = wishlistLimit - 3
+
+// api-5 - concurrent adds near the limit neither overshoot it nor lose items
+func TestConcurrentWishlist(t *testing.T) {
+	games, err := FetchGames(raceWishlistPrefill + raceWidth)
+	if !assert.NoError(t, err) || !assert.Len(t, games, raceWishlistPrefill+raceWidth) {
+		return
+	}
+	for _, env := range Environments {
+		t.Run(env.Name, func(t *testing.T) {
+			user := createScratchUser(t)
+			defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+			for i := 0; i < raceWishlistPrefill; i++ {
+				resp, err := sendWishlistOp(env, user.UUID, games, wishlistOp{Item: i})
+				if !assert.NoError(t, err) {
+					return
+				}
+				resp.Body.Close()
+			}
+			before, err := fetchWishlist(env, user.UUID)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			codes, err := concurrently(raceWidth, func(i int) (*http.Response, error) {
+				return sendWishlistOp(env, user.UUID, games, wishlistOp{Item: raceWishlistPrefill + i})
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			after, err := fetchWishlist(env, user.UUID)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if len(after) > wishlistLimit {
+				t.Errorf("%s: wishlist holds %d items after concurrent adds, the limit is %d", env.Name, len(after), wishlistLimit)
+			}
+			if want := len(before) + accepted(codes); len(after) != want {
+				t.Errorf("%s: %d items before, %d adds accepted, %d items after", env.Name, len(before), accepted(codes), len(after))
+			}
+			for i, code := range codes {
+				if code >= 500 {
+					t.Errorf("%s: concurrent add %d failed with %d", env.Name, i, code)
+				}
+			}
+		})
+	}
+}
+
+// api-16, api-17 - concurrent order creations each create exactly one order
+func TestConcurrentOrders(t *testing.T) {
+	game, err := FetchExistingGame(0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, env := range Environments {
+		t.Run(env.Name, func(t *testing.T) {
+			user := createScratchUser(t)
+			defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+			created := make([]string, raceWidth)
+			codes, err := concurrently(raceWidth, func(i int) (*http.Response, error) {
+				resp, err := SendPostRequest(fmt.Sprintf("%s/users/%s/orders", env.URL, user.UUID), OrderCreateRequest{Items: []OrderItem{{ItemUUID: game.UUID, Quantity: 1}}}, "api-16")
+				if err != nil {
+					return nil, err
+				}
+				var order struct {
+					UUID string `json:"uuid"`
+				}
+				json.NewDecoder(resp.Body).Decode(&order)
+				created[i] = order.UUID
+				return resp, nil
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			resp, err := SendGetRequest(fmt.Sprintf("%s/users/%s/orders?limit=100", env.URL, user.UUID), "api-17")
+			if !assert.NoError(t, err) {
+				return
+			}
+			var list struct {
+				Orders []struct {
+					UUID string `json:"uuid"`
+				} `json:"orders"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&list)
+			resp.Body.Close()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			listed := map[string]int{}
+			for _, o := range list.Orders {
+				listed[o.UUID]++
+			}
+			var duplicated, missing []string
+			for uuid, n := range listed {
+				if n > 1 {
+					duplicated = append(duplicated, uuid)
+				}
+			}
+			for i, uuid := range created {
+				if codes[i] >= 200 && codes[i] < 300 && listed[uuid] == 0 {
+					missing = append(missing, uuid)
+				}
+			}
+			sort.Strings(duplicated)
+
+			if len(list.Orders) != accepted(codes) {
+				t.Errorf("%s: %d concurrent orders accepted, %d listed", env.Name, accepted(codes), len(list.Orders))
+			}
+			if len(duplicated) > 0 {
+				t.Errorf("%s: orders listed more than once: %v", env.Name, duplicated)
+			}
+			if len(missing) > 0 {
+				t.Errorf("%s: accepted orders missing from the list: %v", env.Name, missing)
+			}
+		})
+	}
+}
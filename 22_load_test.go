@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/load"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// loadEndpoint is one endpoint driven in load mode, key names its BUGHUNTER_LOAD_<KEY> override
+type loadEndpoint struct {
+	key  string
+	name string
+	send func(envURL string) (*http.Response, error)
+}
+
+// loadEndpoints reuses the request helpers, reads only apart from login
+func loadEndpoints(user *User, game *Game, category *Category) []loadEndpoint {
+	return []loadEndpoint{
+		{"LIST_USERS", "list users", func(u string) (*http.Response, error) { return SendGetRequest(u+"/users", "api-21") }},
+		{"GET_USER", "get user", func(u string) (*http.Response, error) { return SendGetRequest(u+"/users/"+user.UUID, "api-23") }},
+		{"LOGIN", "login", func(u string) (*http.Response, error) {
+			return SendPostRequest(u+"/users/login", LoginRequest{Email: user.Email, Password: "password"}, "api-7")
+		}},
+		{"LIST_GAMES", "list games", func(u string) (*http.Response, error) { return SendGetRequest(u+"/games", "api-9") }},
+		{"SEARCH", "search games", func(u string) (*http.Response, error) { return SendGetRequest(u+"/games/search?query=the", "api-2") }},
+		{"GET_GAME", "get game", func(u string) (*http.Response, error) { return SendGetRequest(u+"/games/"+game.UUID, "api-9") }},
+		{"CATEGORIES", "categories", func(u string) (*http.Response, error) { return SendGetRequest(u+"/categories", "api-10") }},
+		{"CATEGORY_GAMES", "category games", func(u string) (*http.Response, error) {
+			return SendGetRequest(u+"/categories/"+category.UUID+"/games", "api-10")
+		}},
+		{"GET_CART", "get cart", func(u string) (*http.Response, error) { return GetUserCart(user.UUID, u, "api-12") }},
+	}
+}
+
+// loadConfig reads BUGHUNTER_LOAD and the endpoint's BUGHUNTER_LOAD_<KEY> override
+func loadConfig(key string) (load.Config, error) {
+	spec := os.Getenv("BUGHUNTER_LOAD")
+	if spec == "on" {
+		spec = ""
+	}
+	c, err := load.ParseConfig(spec, load.DefaultConfig)
+	if err != nil {
+		return c, err
+	}
+	return load.ParseConfig(os.Getenv("BUGHUNTER_LOAD_"+key), c)
+}
+
+// load mode - latency percentiles, throughput and error rate per endpoint, Release and Dev side by side
+func TestLoad(t *testing.T) {
+	if os.Getenv("BUGHUNTER_LOAD") == "" {
+		t.Skip("load mode is off, set BUGHUNTER_LOAD=on or a spec such as rate=20,concurrency=4,duration=10s")
+	}
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+	game, err := FetchExistingGame(0)
+	if !assert.NoError(t, err) {
+		return
+	}
+	category, err := FetchCategory()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	envs := make([]string, len(Environments))
+	for i, env := range Environments {
+		envs[i] = env.Name
+	}
+	var rows []load.Row
+	for _, endpoint := range loadEndpoints(user, game, category) {
+		c, err := loadConfig(endpoint.key)
+		if !assert.NoError(t, err, endpoint.key) {
+			return
+		}
+		row := load.Row{Endpoint: endpoint.name}
+		for _, env := range Environments {
+			stats := load.Run(context.Background(), c, func() (int, error) {
+				resp, err := endpoint.send(env.URL)
+				if err != nil {
+					return 0, err
+				}
+				resp.Body.Close()
+				return resp.StatusCode, nil
+			})
+			t.Logf("%s on %s: %d requests with %s", endpoint.name, env.Name, stats.Count(), c)
+			row.Stats = append(row.Stats, stats)
+		}
+		rows = append(rows, row)
+	}
+	t.Logf("latency per endpoint\n%s", load.Table(envs, rows))
+
+	for _, row := range rows {
+		for i, stats := range row.Stats {
+			if stats.ErrorRate() > 0 {
+				t.Errorf("%s on %s: %.1f%% of requests failed", row.Endpoint, envs[i], 100*stats.ErrorRate())
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/faultproxy"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// helperCall exercises one helper against base and returns the status it saw, 0 for none
+type helperCall struct {
+	name string
+	call func(base string, user *User, game *Game) (int, error)
+}
+
+// sendAndParse runs a Send* helper followed by ParseJSONResponse, as the tests do
+func sendAndParse(send func() (*http.Response, error)) (int, error) {
+	resp, err := send()
+	if err != nil {
+		return 0, err
+	}
+	_, err = ParseJSONResponse(resp)
+	return resp.StatusCode, err
+}
+
+var helperCalls = []helperCall{
+	{"SendGetRequest", func(base string, user *User, _ *Game) (int, error) {
+		return sendAndParse(func() (*http.Response, error) { return SendGetRequest(base+"/users/"+user.UUID, "api-23") })
+	}},
+	{"SendPostRequest", func(base string, user *User, _ *Game) (int, error) {
+		return sendAndParse(func() (*http.Response, error) {
+			return SendPostRequest(base+"/users/login", LoginRequest{Email: user.Email, Password: "password"}, "api-7")
+		})
+	}},
+	{"SendPatchRequest", func(base string, user *User, _ *Game) (int, error) {
+		return sendAndParse(func() (*http.Response, error) {
+			return SendPatchRequest(base+"/users/"+user.UUID, map[string]string{"name": user.Name}, "api-24")
+		})
+	}},
+	{"AddItemToCart", func(base string, user *User, game *Game) (int, error) {
+		return sendAndParse(func() (*http.Response, error) { return AddItemToCart(user.UUID, game.UUID, 1, base, "api-13") })
+	}},
+	{"GetUserCart", func(base string, user *User, _ *Game) (int, error) {
+		return sendAndParse(func() (*http.Response, error) { return GetUserCart(user.UUID, base, "api-12") })
+	}},
+	{"FetchAllUsers", func(base string, _ *User, _ *Game) (int, error) {
+		_, err := FetchAllUsers(base, "api-6")
+		return 0, err
+	}},
+	{"FetchAllGames", func(base string, _ *User, _ *Game) (int, error) {
+		_, err := FetchAllGames(base, "api-9")
+		return 0, err
+	}},
+	{"FetchExistingUser", func(string, *User, *Game) (int, error) {
+		_, err := FetchExistingUser(0)
+		return 0, err
+	}},
+	{"FetchExistingGame", func(string, *User, *Game) (int, error) {
+		_, err := FetchExistingGame(0)
+		return 0, err
+	}},
+	{"FetchGames", func(string, *User, *Game) (int, error) {
+		_, err := FetchGames(3)
+		return 0, err
+	}},
+	{"FetchCategory", func(string, *User, *Game) (int, error) {
+		_, err := FetchCategory()
+		return 0, err
+	}},
+}
+
+// callHelper runs one helper call and turns a panic into an error
+func callHelper(c helperCall, base string, user *User, game *Game) (status int, err error, panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, panicked = fmt.Errorf("panic: %v", r), true
+		}
+	}()
+	status, err = c.call(base, user, game)
+	return status, err, false
+}
+
+// fault proxy - every helper reports a misbehaving server as an error or a 5xx, never by panicking
+func TestHelpersFailCleanly(t *testing.T) {
+	user := createScratchUser(t)
+	defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+	game, err := FetchExistingGame(0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	schedules := []string{"reset/1", "truncate/1", "json/1", "ctype/1", "5xx/1", "latency=50ms/1"}
+	for _, schedule := range schedules {
+		t.Run(schedule, func(t *testing.T) {
+			rules, err := faultproxy.ParseSchedule(schedule)
+			if !assert.NoError(t, err) {
+				return
+			}
+			envs, stop, err := proxyEnvironments(rules)
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer stop()
+
+			// the Fetch* helpers read Release through the global
+			defer func(url string) { ReleaseURL = url }(ReleaseURL)
+			ReleaseURL = envs[0].URL
+
+			slowOnly := strings.HasPrefix(schedule, "latency")
+			for _, c := range helperCalls {
+				status, err, panicked := callHelper(c, envs[0].URL, user, game)
+				switch {
+				case panicked:
+					t.Errorf("%s panics behind %s: %v", c.name, schedule, err)
+				case slowOnly && (err != nil || status >= 500):
+					t.Errorf("%s fails behind %s: status %d, %v", c.name, schedule, status, err)
+				case !slowOnly && err == nil && status < 500:
+					t.Errorf("%s hides the fault behind %s: status %d and no error", c.name, schedule, status)
+				default:
+					t.Logf("%s behind %s: status %d, %v", c.name, schedule, status, err)
+				}
+			}
+		})
+	}
+}
+
+// fault proxy - a burst of 5xx on a schedule and the helpers recover once it is over
+func TestHelpersRecoverAfterBurst(t *testing.T) {
+	rules := []faultproxy.Rule{{Fault: faultproxy.ServerError, Every: 4, Burst: 2}}
+	envs, stop, err := proxyEnvironments(rules)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer stop()
+
+	var failed, passed int
+	for i := 0; i < 8; i++ {
+		_, err := FetchAllUsers(envs[0].URL, "api-6")
+		if err != nil {
+			failed++
+		} else {
+			passed++
+		}
+	}
+	assert.Equal(t, 4, failed, "requests 3, 4, 7 and 8 fall in the burst")
+	assert.Equal(t, 4, passed)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/scenario"
+	"QA-Bug-Hunter-jr/internal/vars"
+
+	"github.com/stretchr/testify/require"
+)
+
+// scenarioVars are the fixture values scenario files can use as {{name}}
+func scenarioVars(user *User, games []*Game, category *Category) *vars.Set {
+	set := vars.New()
+	set.Set("user.uuid", user.UUID)
+	set.Set("user.email", user.Email)
+	set.Set("user.name", user.Name)
+	set.Set("user.nickname", user.Nickname)
+	set.Set("user.password", "password")
+	set.Set("category.uuid", category.UUID)
+	for i, g := range games {
+		prefix := "game"
+		if i > 0 {
+			prefix += strconv.Itoa(i + 1)
+		}
+		set.Set(prefix+".uuid", g.UUID)
+		set.Set(prefix+".title", g.Title)
+		set.Set(prefix+".price", g.Price)
+	}
+	return set
+}
+
+// scenarios - every file in scenarios/ runs as a subtest with one subtest per step,
+// BUGHUNTER_SCENARIOS=<dir> reads another directory
+func TestScenarios(t *testing.T) {
+	scenarios, err := scenario.LoadDir(envOr("BUGHUNTER_SCENARIOS", "scenarios"))
+	require.NoError(t, err)
+	games, err := FetchGames(2)
+	require.NoError(t, err)
+	category, err := FetchCategory()
+	require.NoError(t, err)
+
+	for _, sc := range scenarios {
+		t.Run(sc.Name, func(t *testing.T) {
+			// each scenario gets a user of its own, so carts and wishlists start empty
+			user := createScratchUser(t)
+			defer SendDeleteRequest(fmt.Sprintf("%s/users/%s", ReleaseURL, user.UUID), "api-1")
+
+			runner := &scenario.Runner{Envs: Environments, Auth: AuthHeader, Vars: scenarioVars(user, games, category)}
+			if err := runner.Check(sc); err != nil {
+				t.Fatalf("%s: %v", sc.File, err)
+			}
+			for i, step := range sc.Steps {
+				var stepErr error
+				t.Run(step.Title(i), func(t *testing.T) {
+					var failures []string
+					failures, stepErr = runner.Step(sc, step)
+					if stepErr != nil {
+						t.Fatalf("%s: %v", sc.File, stepErr)
+					}
+					for _, f := range failures {
+						t.Error(f)
+					}
+				})
+				// later steps build on this one
+				if stepErr != nil {
+					return
+				}
+			}
+		})
+	}
+}
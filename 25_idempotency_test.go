@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/repeat"
+
+	"github.com/stretchr/testify/require"
+)
+
+// repeatCase is a mutating call sent twice, prepare creates what it acts on for one user
+type repeatCase struct {
+	task    string
+	prepare func(t *testing.T, user *User, game *Game) repeat.Call
+}
+
+var repeatCases = []repeatCase{
+	{"api-1", func(t *testing.T, user *User, game *Game) repeat.Call {
+		path := "/users/" + user.UUID
+		return repeat.Call{Name: "user delete", Method: "DELETE", Path: path, Read: path, Want: repeat.Gone}
+	}},
+	{"api-8", func(t *testing.T, user *User, game *Game) repeat.Call {
+		path := "/users/" + user.UUID + "/wishlist"
+		body := WishlistBody{ItemUUID: game.UUID}
+		resp, err := SendPostRequest(ReleaseURL+path+"/add", body, "api-8")
+		require.NoError(t, err)
+		resp.Body.Close()
+		return repeat.Call{Name: "wishlist remove", Method: "POST", Path: path + "/remove", Body: body, Read: path, Want: repeat.Gone}
+	}},
+	{"api-14", func(t *testing.T, user *User, game *Game) repeat.Call {
+		resp, err := AddItemToCart(user.UUID, game.UUID, 1, ReleaseURL, "api-14")
+		require.NoError(t, err)
+		resp.Body.Close()
+		path := "/users/" + user.UUID + "/cart"
+		return repeat.Call{Name: "cart remove", Method: "POST", Path: path + "/remove", Body: RemoveItemRequest{ItemUUID: game.UUID}, Read: path, Want: repeat.Gone}
+	}},
+	{"api-15", func(t *testing.T, user *User, game *Game) repeat.Call {
+		resp, err := AddItemToCart(user.UUID, game.UUID, 1, ReleaseURL, "api-15")
+		require.NoError(t, err)
+		resp.Body.Close()
+		path := "/users/" + user.UUID + "/cart"
+		return repeat.Call{Name: "cart clear", Method: "POST", Path: path + "/clear", Read: path, Want: repeat.Same}
+	}},
+	{"api-18", func(t *testing.T, user *User, game *Game) repeat.Call {
+		order, err := createOrder(user.UUID, OrderItem{ItemUUID: game.UUID, Quantity: 1})
+		require.NoError(t, err)
+		path := "/orders/" + jsonField[string](t, order, "uuid")
+		return repeat.Call{Name: "order cancel", Method: "PATCH", Path: path + "/status",
+			Body: OrderStatusUpdateRequest{Status: "canceled"}, Read: path, Want: repeat.Refused}
+	}},
+}
+
+// api-8, api-1, api-14, api-15, api-18 - every mutating call is sent twice on each
+// environment: a remove must answer 404 the second time, a clear must answer the
+// same and a status change must be refused, and the second call may not change the state
+func TestRepeatedCalls(t *testing.T) {
+	game, err := FetchExistingGame(0)
+	require.NoError(t, err)
+
+	for _, c := range repeatCases {
+		c := c
+		t.Run(c.task, func(t *testing.T) {
+			var name string
+			var outcomes []repeat.Outcome
+			for _, env := range Environments {
+				call := c.prepare(t, createScratchUser(t), game)
+				name = call.Name
+				o, err := repeat.Run(env, AuthHeader, c.task, call)
+				require.NoError(t, err)
+				for _, p := range o.Problems {
+					t.Errorf("%s %s on %s: %s", c.task, call.Name, env.Name, p)
+				}
+				outcomes = append(outcomes, o)
+			}
+			for _, d := range repeat.Compare(outcomes) {
+				t.Errorf("%s %s, %s", c.task, name, d)
+			}
+			if !t.Failed() {
+				t.Logf("%s %s: semantics held on every environment", c.task, name)
+			}
+		})
+	}
+}
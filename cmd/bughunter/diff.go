@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/jsondiff"
+)
+
+// answer is what an environment replied to one request
+type answer struct {
+	status int
+	body   interface{} // decoded JSON, or the raw text when the body is not JSON
+}
+
+// send sends one request to env through the suite's helper
+func send(env api.Environment, method, path, auth string, body json.RawMessage, taskID string) (answer, error) {
+	var payload interface{}
+	if len(body) > 0 {
+		payload = body
+	}
+	resp, err := api.SendRequestWithAuth(method, env.URL+path, auth, payload, taskID)
+	if err != nil {
+		return answer{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return answer{}, fmt.Errorf("%s %s: %v", method, path, err)
+	}
+	return decodeAnswer(resp.StatusCode, data), nil
+}
+
+// decodeAnswer decodes a JSON body, keeping other bodies as text
+func decodeAnswer(status int, data []byte) answer {
+	if len(data) == 0 {
+		return answer{status: status}
+	}
+	if doc, err := jsondiff.Decode(data); err == nil {
+		return answer{status: status, body: doc}
+	}
+	return answer{status: status, body: string(data)}
+}
+
+// compareAnswers lists the differences between two answers, status first
+func compareAnswers(a, b answer, nameA, nameB string, opts jsondiff.Options) []string {
+	var out []string
+	if a.status != b.status {
+		out = append(out, fmt.Sprintf("status: %s %d, %s %d", nameA, a.status, nameB, b.status))
+	}
+	for _, d := range jsondiff.Compare(a.body, b.body, opts) {
+		out = append(out, d.Format(nameA, nameB))
+	}
+	return out
+}
+
+// ignoreKeys turns the -ignore flag into jsondiff options
+func ignoreKeys(list string) jsondiff.Options {
+	opts := jsondiff.Options{Ignore: map[string]bool{}}
+	for _, k := range splitList(list) {
+		opts.Ignore[k] = true
+	}
+	return opts
+}
+
+func cmdDiff(args []string) int {
+	fs := newFlagSet("diff", "PATH")
+	var envs envFlags
+	envs.register(fs)
+	method := fs.String("method", "GET", "HTTP method")
+	body := fs.String("body", "", "JSON request body")
+	taskID := fs.String("task", "api-diff", "X-Task-Id header")
+	auth := fs.String("auth", api.AuthHeader, "Authorization header, empty sends none")
+	ignore := fs.String("ignore", "", "comma separated keys to leave out of the comparison, e.g. uuid")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || !strings.HasPrefix(fs.Arg(0), "/") {
+		fmt.Fprintln(os.Stderr, "bughunter diff: want one path below the base URL, e.g. /users?offset=0&limit=5")
+		return 2
+	}
+	if *body != "" && !json.Valid([]byte(*body)) {
+		fmt.Fprintln(os.Stderr, "bughunter diff: -body is not valid JSON")
+		return 2
+	}
+
+	path := fs.Arg(0)
+	var answers []answer
+	for _, env := range envs.environments() {
+		a, err := send(env, strings.ToUpper(*method), path, *auth, json.RawMessage(*body), *taskID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bughunter diff: %s: %v\n", env.Name, err)
+			return 2
+		}
+		fmt.Printf("%-8s %s %s%s -> %d\n", env.Name, strings.ToUpper(*method), env.URL, path, a.status)
+		answers = append(answers, a)
+	}
+
+	diffs := compareAnswers(answers[0], answers[1], "Release", "Dev", ignoreKeys(*ignore))
+	if len(diffs) == 0 {
+		fmt.Println("no difference")
+		return 0
+	}
+	fmt.Printf("%d differences\n", len(diffs))
+	for _, d := range diffs {
+		fmt.Println("  " + d)
+	}
+	return 1
+}
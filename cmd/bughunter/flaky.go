@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// historyWindow is how many runs of a test the history keeps
+const historyWindow = 20
+
+// flakeRecord is the rerun history of one test
+type flakeRecord struct {
+	Tasks    []string  `json:"tasks,omitempty"`
+	Recent   []string  `json:"recent"` // outcomes of the last runs, oldest first
+	LastSeen time.Time `json:"last_seen"`
+	// when the test last failed and then passed a rerun, nil if never
+	LastUnstable *time.Time `json:"last_unstable,omitempty"`
+}
+
+// unstable counts the recent runs where the test was flaky or recovered
+func (rec *flakeRecord) unstable() int {
+	n := 0
+	for _, o := range rec.Recent {
+		if o == "flaky" || o == "recovered" {
+			n++
+		}
+	}
+	return n
+}
+
+// flakeHistory is the rerun history by test, kept in a JSON file between runs
+type flakeHistory map[string]*flakeRecord
+
+// readFlakeHistory reads a history file, a missing file is an empty history
+func readFlakeHistory(path string) (flakeHistory, error) {
+	h := flakeHistory{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return h, nil
+}
+
+// record adds the outcome of every test of a run
+func (h flakeHistory) record(res *results, now time.Time) {
+	for _, r := range res.order {
+		if r.Suite {
+			continue
+		}
+		rec := h[r.Test]
+		if rec == nil {
+			rec = &flakeRecord{}
+			h[r.Test] = rec
+		}
+		rec.Tasks = r.Tasks
+		rec.LastSeen = now
+		o := r.outcome()
+		if o == "flaky" || o == "recovered" {
+			rec.LastUnstable = &now
+		}
+		rec.Recent = append(rec.Recent, o)
+		if len(rec.Recent) > historyWindow {
+			rec.Recent = rec.Recent[len(rec.Recent)-historyWindow:]
+		}
+	}
+}
+
+func (h flakeHistory) write(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,122 @@
+// Command bughunter runs the Release vs Dev checks outside of go test.
+//
+//	bughunter run [flags] [task IDs or test names]   run the suite, or part of it
+//	bughunter report [flags] [results file]          render the results of a run
+//	bughunter trend [flags] [task IDs or test names] show when bugs first and last failed across runs
+//	bughunter diff [flags] PATH                      send one request to both environments and diff the answers
+//	bughunter replay [flags] TRAFFIC_FILE            replay recorded traffic and diff the answers
+//	bughunter serve [flags]                          serve the fake Release and Dev APIs
+//
+// It sends its requests through the same helpers and models as the suite.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"QA-Bug-Hunter-jr/internal/api"
+)
+
+// command is one subcommand, it returns the exit code
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{"run", "run the suite or selected task IDs against chosen environments", cmdRun},
+	{"report", "render the results written by run", cmdReport},
+	{"trend", "show when each task first and last failed and whether it flapped", cmdTrend},
+	{"diff", "send a request to Release and Dev and print the difference", cmdDiff},
+	{"replay", "replay recorded traffic and compare the answers", cmdReplay},
+	{"serve", "serve the fake Release and Dev APIs", cmdServe},
+}
+
+func main() {
+	os.Exit(dispatch(os.Args[1:]))
+}
+
+func dispatch(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		usage()
+		return 2
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "bughunter: unknown command %q\n", args[0])
+	usage()
+	return 2
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: bughunter <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr)
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run bughunter <command> -h for the flags of a command.")
+}
+
+// newFlagSet returns a flag set for a subcommand with a usage line
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: bughunter %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// envFlags are the base URLs of both environments, they default to the
+// BUGHUNTER_RELEASE_URL and BUGHUNTER_DEV_URL variables like the suite
+type envFlags struct {
+	release, dev string
+}
+
+func (e *envFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&e.release, "release", envOr("BUGHUNTER_RELEASE_URL", api.DefaultReleaseURL), "base URL of Release")
+	fs.StringVar(&e.dev, "dev", envOr("BUGHUNTER_DEV_URL", api.DefaultDevURL), "base URL of Dev")
+}
+
+// environments returns Release and Dev, Release first as the reference
+func (e *envFlags) environments() []api.Environment {
+	return []api.Environment{
+		{Name: "Release", URL: strings.TrimSuffix(e.release, "/")},
+		{Name: "Dev", URL: strings.TrimSuffix(e.dev, "/")},
+	}
+}
+
+// lookup finds an environment by name, ignoring case
+func (e *envFlags) lookup(name string) (api.Environment, bool) {
+	for _, env := range e.environments() {
+		if strings.EqualFold(env.Name, name) {
+			return env, true
+		}
+	}
+	return api.Environment{}, false
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// splitList splits a comma separated flag value, dropping empty entries
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/fakeapi"
+	"QA-Bug-Hunter-jr/internal/history"
+	"QA-Bug-Hunter-jr/internal/jsondiff"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const suiteSource = `package main
+
+import "testing"
+
+// api-7 Get a user by email and pass
+func TestUserLogin(t *testing.T) {}
+
+// api-7: login after an update
+func TestUpdateUserAndLogin(t *testing.T) {}
+
+// fault proxy - no task
+func TestHelpersFailCleanly(t *testing.T) {}
+
+// api-12, api-14 - model based cart sequences
+func TestCartStateMachine(t *testing.T) {}
+
+// api-3 / api-22 create a user, api-99 is only mentioned
+func TestCreateUser(t *testing.T) {}
+
+func TestMain(m *testing.M) {}
+
+func helper(t *testing.T) {}
+`
+
+func writeSuite(t *testing.T) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "02_users_test.go"), []byte(suiteSource), 0o644))
+	return dir
+}
+
+func TestScanSuite(t *testing.T) {
+	s, err := scanSuite(writeSuite(t))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TestCartStateMachine", "TestCreateUser", "TestHelpersFailCleanly", "TestUpdateUserAndLogin", "TestUserLogin"}, s.tests)
+	assert.Equal(t, map[string][]string{
+		"TestUserLogin":          {"api-7"},
+		"TestUpdateUserAndLogin": {"api-7"},
+		"TestCartStateMachine":   {"api-12", "api-14"},
+		"TestCreateUser":         {"api-3", "api-22"},
+	}, s.tasks)
+
+	pattern, err := s.pattern([]string{"api-7", "TestHelpersFailCleanly"})
+	require.NoError(t, err)
+	assert.Equal(t, "^(TestUpdateUserAndLogin|TestUserLogin|TestHelpersFailCleanly)$", pattern)
+
+	pattern, err = s.pattern([]string{"api-14", "api-22"})
+	require.NoError(t, err)
+	assert.Equal(t, "^(TestCartStateMachine|TestCreateUser)$", pattern, "every task of a doc comment selects the test")
+
+	pattern, err = s.pattern(nil)
+	require.NoError(t, err)
+	assert.Empty(t, pattern)
+
+	_, err = s.pattern([]string{"api-99"})
+	assert.EqualError(t, err, `"api-99" is neither a task ID nor a test of the suite`)
+
+	_, err = scanSuite(t.TempDir())
+	assert.Error(t, err)
+}
+
+const events = `{"Action":"start"}
+{"Action":"run","Test":"TestUserLogin"}
+{"Action":"output","Test":"TestUserLogin","Output":"=== RUN   TestUserLogin\n"}
+{"Action":"output","Test":"TestUserLogin/Release","Output":"    02_users_test.go:63: \n"}
+{"Action":"output","Test":"TestUserLogin/Release","Output":"        \tError:      \tShould not be: 200\n"}
+{"Action":"output","Test":"TestUserLogin/Release","Output":"        \tMessages:   \tcodes should differ\n"}
+{"Action":"fail","Test":"TestUserLogin/Release","Elapsed":0.01}
+{"Action":"fail","Test":"TestUserLogin","Elapsed":0.02}
+{"Action":"output","Test":"TestHelpersFailCleanly","Output":"    23_fault_proxy_test.go:154: FetchCategory behind reset/1\n"}
+{"Action":"pass","Test":"TestHelpersFailCleanly","Elapsed":0.5}
+not an event
+{"Action":"fail","Elapsed":0.6}
+`
+
+func TestReport(t *testing.T) {
+	s, err := scanSuite(writeSuite(t))
+	require.NoError(t, err)
+	res, err := readResults(strings.NewReader(events), s)
+	require.NoError(t, err)
+	require.Len(t, res.order, 2)
+	assert.Equal(t, &result{Test: "TestUserLogin", Tasks: []string{"api-7"}, Result: "fail", Elapsed: 0.02,
+		Errors: []string{"Should not be: 200", "  codes should differ"}}, res.order[0])
+	assert.Empty(t, res.order[1].Errors, "the log of a passing test is not kept")
+	assert.Equal(t, 1, res.failed())
+
+	var text bytes.Buffer
+	res.render(&text, "text")
+	assert.Equal(t, `TASK   TEST                    RESULT  TIME
+api-7  TestUserLogin           FAIL    0.02s
+-      TestHelpersFailCleanly  PASS    0.50s
+1 passed, 1 failed, 0 skipped
+
+TestUserLogin api-7
+    Should not be: 200
+      codes should differ
+`, text.String())
+
+	var md bytes.Buffer
+	res.render(&md, "markdown")
+	assert.Contains(t, md.String(), "| api-7 | TestUserLogin | FAIL | 0.02s |\n")
+	assert.Contains(t, md.String(), "- codes should differ\n")
+}
+
+func TestFailureLinesCap(t *testing.T) {
+	var output []string
+	for i := 0; i < maxErrors+3; i++ {
+		output = append(output, "    16_search_oracle_test.go:188: Dev: meta.total is 20, want 1\n")
+	}
+	lines := failureLines(output)
+	assert.Len(t, lines, maxErrors+1)
+	assert.Equal(t, "... 3 more", lines[maxErrors])
+}
+
+func TestCompareAnswers(t *testing.T) {
+	a := decodeAnswer(200, []byte(`{"uuid":"a","total":1}`))
+	b := decodeAnswer(404, []byte(`not found`))
+	assert.Equal(t, []string{
+		"status: Release 200, Dev 404",
+		`$: Release {"total":1,"uuid":"a"}, Dev "not found"`,
+	}, compareAnswers(a, b, "Release", "Dev", jsondiff.Options{}))
+
+	c := decodeAnswer(200, []byte(`{"uuid":"b","total":1}`))
+	assert.Empty(t, compareAnswers(a, c, "Release", "Dev", ignoreKeys("uuid, ")))
+	assert.Equal(t, answer{status: 204}, decodeAnswer(204, nil))
+}
+
+func TestDispatch(t *testing.T) {
+	assert.Equal(t, 2, dispatch(nil))
+	assert.Equal(t, 2, dispatch([]string{"nope"}))
+	assert.Equal(t, 2, dispatch([]string{"diff", "users"}))
+	assert.Equal(t, 2, dispatch([]string{"run", "-target", "staging"}))
+}
+
+// rerunEvents is a run where TestUserLogin fails for good, TestUpdateUserAndLogin
+// fails once and passes both reruns and TestHelpersFailCleanly passes one of two
+const rerunEvents = `{"Action":"output","Test":"TestUserLogin","Output":"    02_users_test.go:63: Dev: status 500\n"}
+{"Action":"fail","Test":"TestUserLogin","Elapsed":0.02}
+{"Action":"fail","Test":"TestUpdateUserAndLogin","Elapsed":0.03}
+{"Action":"fail","Test":"TestHelpersFailCleanly","Elapsed":0.5}
+{"Action":"fail","Test":"TestUserLogin","Elapsed":0.02}
+{"Action":"pass","Test":"TestUpdateUserAndLogin","Elapsed":0.03}
+{"Action":"pass","Test":"TestHelpersFailCleanly","Elapsed":0.5}
+{"Action":"fail","Test":"TestUserLogin","Elapsed":0.02}
+{"Action":"pass","Test":"TestUpdateUserAndLogin","Elapsed":0.03}
+{"Action":"fail","Test":"TestHelpersFailCleanly","Elapsed":0.5}
+`
+
+func TestReruns(t *testing.T) {
+	s, err := scanSuite(writeSuite(t))
+	require.NoError(t, err)
+	res, err := readResults(strings.NewReader(rerunEvents), s)
+	require.NoError(t, err)
+	require.Len(t, res.order, 3, "reruns are folded into the first result")
+
+	var outcomes []string
+	for _, r := range res.order {
+		outcomes = append(outcomes, r.outcome())
+	}
+	assert.Equal(t, []string{"fail", "recovered", "flaky"}, outcomes)
+	assert.Equal(t, 1, res.failed())
+	assert.Equal(t, []string{"TestUserLogin", "TestUpdateUserAndLogin", "TestHelpersFailCleanly"}, res.rerunnable())
+
+	res.flakes = flakeHistory{"TestHelpersFailCleanly": {Recent: []string{"pass", "flaky", "pass", "recovered"}}}
+	var text bytes.Buffer
+	res.render(&text, "text")
+	assert.Equal(t, `TASK   TEST                    RESULT     TIME
+api-7  TestUserLogin           FAIL       0.02s
+api-7  TestUpdateUserAndLogin  RECOVERED  0.03s
+-      TestHelpersFailCleanly  FLAKY      0.50s
+0 passed, 1 failed, 0 skipped, 1 flaky, 1 recovered on rerun
+
+flaky, not counted as failures:
+    TestUpdateUserAndLogin api-7 recovered, failed 0 of 2 reruns
+    TestHelpersFailCleanly - flaky, failed 1 of 2 reruns, unstable in 2 of the last 4 runs
+
+TestUserLogin api-7
+    Dev: status 500
+`, text.String())
+
+	var md bytes.Buffer
+	res.render(&md, "markdown")
+	assert.Contains(t, md.String(), "### Flaky, not counted as failures\n\n- TestUpdateUserAndLogin api-7: recovered, failed 0 of 2 reruns\n")
+}
+
+// findingEvents has a run whose tests pass but whose suite-level checks fail,
+// then a rerun of the test the persistence gap was attached to
+const findingEvents = `{"Action":"pass","Test":"TestUserLogin","Elapsed":0.02}
+{"Action":"pass","Test":"TestHelpersFailCleanly","Elapsed":0.5}
+{"Action":"output","Output":"--- FAIL: persistence (1 distinct gaps)\n"}
+{"Action":"output","Output":"    api-7 Dev PATCH /users/u1: user update: name was set to \"A\", stored \"B\" (x1)\n"}
+{"Action":"output","Output":"--- FAIL: latency baseline (1 endpoints slower than x2)\n"}
+{"Action":"output","Output":"    Dev GET /users/{uuid}/orders: median 10.4ms, baseline 0.1ms (x193.0, 3 samples)\n"}
+{"Action":"output","Output":"FAIL\n"}
+{"Action":"fail","Elapsed":0.6}
+{"Action":"pass","Test":"TestUserLogin","Elapsed":0.02}
+{"Action":"output","Output":"--- FAIL: persistence (1 distinct gaps)\n"}
+{"Action":"output","Output":"    api-7 Dev PATCH /users/u2: user update: name was set to \"A\", stored \"B\" (x1)\n"}
+{"Action":"fail","Elapsed":0.1}
+`
+
+func TestSuiteFindings(t *testing.T) {
+	s, err := scanSuite(writeSuite(t))
+	require.NoError(t, err)
+	res, err := readResults(strings.NewReader(findingEvents), s)
+	require.NoError(t, err)
+	require.Len(t, res.order, 3)
+
+	login := res.byTest["TestUserLogin"]
+	assert.Equal(t, "fail", login.outcome(), "the gap fails the test of its task, on the rerun too")
+	assert.Equal(t, []string{"fail"}, login.Reruns)
+	assert.Equal(t, []string{
+		`persistence: api-7 Dev PATCH /users/u1: user update: name was set to "A", stored "B" (x1)`,
+		`persistence: api-7 Dev PATCH /users/u2: user update: name was set to "A", stored "B" (x1)`,
+	}, login.Errors)
+	assert.Equal(t, "pass", res.byTest["TestHelpersFailCleanly"].outcome())
+
+	latency := res.byTest["latency baseline"]
+	require.NotNil(t, latency, "a finding without a task gets a result of its own")
+	assert.True(t, latency.Suite)
+	assert.Equal(t, []string{"latency baseline: Dev GET /users/{uuid}/orders: median 10.4ms, baseline 0.1ms (x193.0, 3 samples)"}, latency.Errors)
+
+	assert.Equal(t, 2, res.failed())
+	assert.Equal(t, []string{"TestUserLogin"}, res.rerunnable(), "suite-level results are not rerun")
+
+	var text bytes.Buffer
+	res.render(&text, "text")
+	assert.Contains(t, text.String(), "-      latency baseline        FAIL    0.00s\n")
+	assert.Contains(t, text.String(), "\nlatency baseline -\n    latency baseline: Dev GET /users/{uuid}/orders")
+}
+
+func TestRunRecordKeepsSuiteFindings(t *testing.T) {
+	s, err := scanSuite(writeSuite(t))
+	require.NoError(t, err)
+	res, err := readResults(strings.NewReader(findingEvents), s)
+	require.NoError(t, err)
+	assert.False(t, brokenRun(false, res), "a run failing on suite-level checks is stored")
+	assert.True(t, brokenRun(false, newResults(s)), "a run without any failed result is broken")
+	assert.False(t, brokenRun(true, newResults(s)))
+
+	start := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	require.NoError(t, history.Append(path, runRecord(start, "fake", nil, res)))
+	runs, err := history.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+
+	trends := map[string]*history.Trend{}
+	for _, tr := range history.Trends(runs) {
+		trends[tr.Task] = tr
+	}
+	require.Contains(t, trends, "api-7")
+	assert.Equal(t, "fail", trends["api-7"].Current)
+	assert.Contains(t, trends["api-7"].Last.Diffs[0], "persistence: api-7 Dev PATCH /users/u1")
+	require.Contains(t, trends, "latency baseline")
+	assert.Equal(t, 1, trends["latency baseline"].Failed)
+}
+
+func TestFindingOfATestWithSeveralTasks(t *testing.T) {
+	s, err := scanSuite(writeSuite(t))
+	require.NoError(t, err)
+	res, err := readResults(strings.NewReader(`{"Action":"pass","Test":"TestCartStateMachine","Elapsed":0.1}
+{"Action":"output","Output":"--- FAIL: persistence (1 distinct gaps)\n"}
+{"Action":"output","Output":"    api-14 Dev POST /users/u1/cart/remove: cart remove: 1 items are still stored (x1)\n"}
+{"Action":"fail","Elapsed":0.2}
+`), s)
+	require.NoError(t, err)
+	require.Len(t, res.order, 1, "the gap goes to the test covering api-14")
+	cart := res.order[0]
+	assert.Equal(t, "fail", cart.outcome())
+	assert.Equal(t, "api-12,api-14", cart.task())
+
+	trends := map[string]*history.Trend{}
+	for _, tr := range history.Trends([]history.Run{runRecord(time.Now(), "fake", nil, res)}) {
+		trends[tr.Task] = tr
+	}
+	require.Contains(t, trends, "api-14")
+	assert.Equal(t, "fail", trends["api-14"].Current)
+	assert.Equal(t, []string{"TestCartStateMachine"}, trends["api-14"].Tests)
+	require.Contains(t, trends, "api-12")
+}
+
+func TestAddErrorCap(t *testing.T) {
+	res := &result{}
+	for i := 0; i < maxErrors+3; i++ {
+		res.addError(fmt.Sprintf("gap %d", i))
+	}
+	res.addError("gap 0")
+	assert.Len(t, res.Errors, maxErrors+1)
+	assert.Equal(t, "... 3 more", res.Errors[maxErrors])
+}
+
+func TestFlakeHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flaky.json")
+	h, err := readFlakeHistory(path)
+	require.NoError(t, err)
+	assert.Empty(t, h, "a missing file is an empty history")
+
+	s, err := scanSuite(writeSuite(t))
+	require.NoError(t, err)
+	res, err := readResults(strings.NewReader(rerunEvents), s)
+	require.NoError(t, err)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < historyWindow+1; i++ {
+		h.record(res, now)
+	}
+	require.NoError(t, h.write(path))
+
+	read, err := readFlakeHistory(path)
+	require.NoError(t, err)
+	flaky := read["TestHelpersFailCleanly"]
+	assert.Len(t, flaky.Recent, historyWindow, "only the last runs are kept")
+	assert.Equal(t, historyWindow, flaky.unstable())
+	require.NotNil(t, flaky.LastUnstable)
+	assert.True(t, flaky.LastUnstable.Equal(now))
+	assert.Equal(t, []string{"api-7"}, read["TestUserLogin"].Tasks)
+	assert.Equal(t, 0, read["TestUserLogin"].unstable())
+	assert.Nil(t, read["TestUserLogin"].LastUnstable)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = readFlakeHistory(path)
+	assert.Error(t, err)
+}
+
+func TestFingerprint(t *testing.T) {
+	release := fakeapi.New(fakeapi.Options{})
+	rel := httptest.NewServer(release)
+	defer rel.Close()
+	env := api.Environment{Name: "Release", URL: rel.URL + fakeapi.BasePath}
+
+	fp := fingerprint(env)
+	assert.Empty(t, fp.Err)
+	assert.Len(t, fp.Hash, 12)
+	assert.Equal(t, fp, fingerprint(env), "the same build gives the same fingerprint")
+
+	// new data keeps the fingerprint
+	_, err := api.SendPostRequest(env.URL+"/users", api.UserCreateRequest{Email: "new@example.com", Password: "password", Name: "New", Nickname: "newbie"}, "api-3")
+	require.NoError(t, err)
+	assert.Equal(t, fp.Hash, fingerprint(env).Hash)
+
+	rel.Close()
+	assert.NotEmpty(t, fingerprint(env).Err)
+
+	assert.Equal(t, "{games:[{price:number,title:string}],meta:{total:number}}",
+		shape(map[string]interface{}{"meta": map[string]interface{}{"total": 2.0}, "games": []interface{}{
+			map[string]interface{}{"title": "Portal 2", "price": 999.0}}}))
+}
+
+func TestRenderTrends(t *testing.T) {
+	at := func(d int) time.Time { return time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC) }
+	fps := []history.Fingerprint{{Name: "Release", Hash: "aaaaaaaaaaaa"}, {Name: "Dev", Hash: "bbbbbbbbbbbb"}}
+	var runs []history.Run
+	for d, outcome := range []string{"pass", "fail", "pass", "fail"} {
+		runs = append(runs, history.Run{ID: history.NewID(at(d + 1)), Time: at(d + 1), Envs: fps, Tests: []history.Test{
+			{Name: "TestRemoveFromCart", Tasks: []string{"api-14"}, Outcome: outcome, Diffs: []string{"Dev: cart is empty after removing one item"}},
+			{Name: "TestUserLogin", Tasks: []string{"api-7"}, Outcome: "pass"},
+		}})
+	}
+
+	var text bytes.Buffer
+	renderTrends(&text, history.Trends(runs), "text")
+	assert.Equal(t, `TASK    NOW   FIRST FAILED      LAST FAILED       FAILED RUNS  FLIPS
+api-7   PASS  -                 -                 0/4          0
+api-14  FAIL  2026-10-02 09:00  2026-10-04 09:00  2/4          3 flapped
+
+api-14 TestRemoveFromCart
+    first failed in run 20261002T090000Z on Release aaaaaaaaaaaa, Dev bbbbbbbbbbbb
+    last failed in run 20261004T090000Z on Release aaaaaaaaaaaa, Dev bbbbbbbbbbbb
+      Dev: cart is empty after removing one item
+`, text.String())
+
+	var md bytes.Buffer
+	renderTrends(&md, history.Trends(runs), "markdown")
+	assert.Contains(t, md.String(), "| api-14 | FAIL | 2026-10-02 09:00 | 2026-10-04 09:00 | 2/4 | 3 flapped |\n")
+	assert.Contains(t, md.String(), "- Dev: cart is empty after removing one item\n")
+}
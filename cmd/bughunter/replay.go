@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/traffic"
+)
+
+func cmdReplay(args []string) int {
+	fs := newFlagSet("replay", "TRAFFIC_FILE")
+	var envs envFlags
+	envs.register(fs)
+	only := fs.String("env", "", "replay every exchange on this environment instead of the recorded one")
+	auth := fs.String("auth", api.AuthHeader, "Authorization header, empty sends none")
+	ignore := fs.String("ignore", "", "comma separated keys to leave out of the comparison, e.g. uuid")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter replay: %v\n", err)
+		return 2
+	}
+	exchanges, err := traffic.Read(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter replay: %s: %v\n", fs.Arg(0), err)
+		return 2
+	}
+
+	opts := ignoreKeys(*ignore)
+	changed := 0
+	for i, ex := range exchanges {
+		name := ex.Env
+		if *only != "" {
+			name = *only
+		}
+		env, ok := envs.lookup(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "bughunter replay: exchange %d: unknown environment %q\n", i+1, name)
+			return 2
+		}
+
+		got, err := send(env, ex.Method, ex.Path, *auth, ex.Body, ex.TaskID)
+		if err != nil {
+			fmt.Printf("%-8s %s %s %s: %v\n", orDash(ex.TaskID), env.Name, ex.Method, ex.Path, err)
+			changed++
+			continue
+		}
+		recorded := decodeAnswer(ex.Status, ex.Response)
+		diffs := compareAnswers(recorded, got, "recorded", "now", opts)
+		if len(diffs) == 0 {
+			continue
+		}
+		changed++
+		fmt.Printf("%-8s %s %s %s\n", orDash(ex.TaskID), env.Name, ex.Method, ex.Path)
+		for _, d := range diffs {
+			fmt.Println("    " + d)
+		}
+	}
+
+	fmt.Printf("%d exchanges replayed, %d answered differently\n", len(exchanges), changed)
+	if changed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// event is one line of go test -json output
+type event struct {
+	Time    time.Time
+	Action  string
+	Test    string
+	Elapsed float64
+	Output  string
+}
+
+// result is the outcome of one top-level test
+type result struct {
+	Test    string
+	Tasks   []string // task IDs of the test's doc comment
+	Result  string   // pass, fail or skip
+	Elapsed float64
+	Errors  []string // assertion messages of a failed test
+	Reruns  []string // results of the reruns of a failed test, in order
+	Suite   bool     // findings of a check of the whole suite that no test of their task ran into
+}
+
+// task lists the task IDs for display, "-" when there are none
+func (res *result) task() string {
+	return orDash(strings.Join(res.Tasks, ","))
+}
+
+// covers reports whether the result carries the task ID
+func (res *result) covers(task string) bool {
+	for _, t := range res.Tasks {
+		if t == task {
+			return true
+		}
+	}
+	return false
+}
+
+// outcome classifies a test after its reruns: a failure that fails every
+// rerun is a real failure, one that passes every rerun recovered, and one
+// that does both is flaky
+func (res *result) outcome() string {
+	if res.Result != "fail" || len(res.Reruns) == 0 {
+		return res.Result
+	}
+	failed := 0
+	for _, r := range res.Reruns {
+		if r == "fail" {
+			failed++
+		}
+	}
+	switch failed {
+	case len(res.Reruns):
+		return "fail"
+	case 0:
+		return "recovered"
+	}
+	return "flaky"
+}
+
+// results collects the outcomes of a run in the order the tests finished
+type results struct {
+	suite  *suite
+	order  []*result
+	byTest map[string]*result
+	output map[string][]string // lines printed by each running test
+	flakes flakeHistory        // earlier outcomes of each test, nil when not kept
+
+	current []*result // results finished by the go test process being read
+	section string    // title of the suite-level findings being read
+}
+
+func newResults(s *suite) *results {
+	return &results{suite: s, byTest: map[string]*result{}, output: map[string][]string{}}
+}
+
+// add folds an event in and returns the result it completed, if any. A
+// test that finishes again is a rerun, its result is added to the first.
+func (r *results) add(ev event) *result {
+	if ev.Test == "" {
+		r.addSuite(ev)
+		return nil
+	}
+	top, _, _ := strings.Cut(ev.Test, "/")
+	switch ev.Action {
+	case "output":
+		r.output[top] = append(r.output[top], ev.Output)
+	case "pass", "fail", "skip":
+		if ev.Test != top {
+			return nil
+		}
+		if first := r.byTest[top]; first != nil {
+			first.Reruns = append(first.Reruns, ev.Action)
+			delete(r.output, top)
+			r.current = append(r.current, first)
+			return first
+		}
+		res := &result{Test: top, Result: ev.Action, Elapsed: ev.Elapsed}
+		if r.suite != nil {
+			res.Tasks = r.suite.tasks[top]
+		}
+		if ev.Action == "fail" {
+			res.Errors = failureLines(r.output[top])
+		}
+		delete(r.output, top)
+		r.order = append(r.order, res)
+		r.byTest[top] = res
+		r.current = append(r.current, res)
+		return res
+	}
+	return nil
+}
+
+// findingHeader matches the line TestMain prints above the findings of a
+// check of the whole suite, e.g. "--- FAIL: persistence (2 distinct gaps)"
+var findingHeader = regexp.MustCompile(`^--- FAIL: (.+?) \(.+\)$`)
+
+// addSuite folds in an event of the package rather than of a test: the
+// findings TestMain prints after the last test, and the end of the process
+func (r *results) addSuite(ev event) {
+	switch ev.Action {
+	case "output":
+		line := strings.TrimRight(ev.Output, "\n")
+		if m := findingHeader.FindStringSubmatch(line); m != nil {
+			r.section = m[1]
+		} else if r.section != "" && strings.HasPrefix(line, "    ") {
+			r.addFinding(r.section, strings.TrimSpace(line))
+		} else {
+			r.section = ""
+		}
+	case "pass", "fail", "skip":
+		r.current, r.section = nil, ""
+	}
+}
+
+// addFinding fails the tests of this process that share the task ID the
+// finding starts with, they sent the request. A finding without a task, or
+// whose task no test ran, goes to a result named after the check.
+func (r *results) addFinding(title, msg string) {
+	var task string
+	if m := taskPattern.FindStringSubmatch(msg); m != nil {
+		task = m[1]
+	}
+	msg = title + ": " + msg
+
+	hit := false
+	for _, res := range r.current {
+		if task == "" || !res.covers(task) {
+			continue
+		}
+		hit = true
+		if n := len(res.Reruns); n > 0 {
+			res.Reruns[n-1] = "fail"
+		} else {
+			res.Result = "fail"
+		}
+		res.addError(msg)
+	}
+	if hit {
+		return
+	}
+	res := r.byTest[title]
+	if res == nil {
+		res = &result{Test: title, Result: "fail", Suite: true}
+		if task != "" {
+			res.Tasks = []string{task}
+		}
+		r.order = append(r.order, res)
+		r.byTest[title] = res
+	}
+	res.addError(msg)
+}
+
+// addError keeps msg once, up to maxErrors messages
+func (res *result) addError(msg string) {
+	for _, e := range res.Errors {
+		if e == msg {
+			return
+		}
+	}
+	if len(res.Errors) < maxErrors {
+		res.Errors = append(res.Errors, msg)
+		return
+	}
+	more := 0
+	if len(res.Errors) > maxErrors {
+		fmt.Sscanf(res.Errors[maxErrors], "... %d more", &more)
+	}
+	res.Errors = append(res.Errors[:maxErrors], fmt.Sprintf("... %d more", more+1))
+}
+
+// logLine matches a line logged with t.Errorf or t.Logf, e.g. "16_search_oracle_test.go:188: Dev: ..."
+var logLine = regexp.MustCompile(`^\S+_test\.go:\d+: (.+)$`)
+
+// maxErrors caps the messages kept for one failed test
+const maxErrors = 10
+
+// failureLines picks the testify and t.Errorf messages out of a test's output
+func failureLines(output []string) []string {
+	var out []string
+	for _, line := range output {
+		line = strings.TrimSpace(line)
+		if msg, ok := strings.CutPrefix(line, "Error:"); ok {
+			out = append(out, strings.TrimSpace(msg))
+		} else if msg, ok := strings.CutPrefix(line, "Messages:"); ok {
+			out = append(out, "  "+strings.TrimSpace(msg))
+		} else if m := logLine.FindStringSubmatch(line); m != nil {
+			out = append(out, m[1])
+		}
+	}
+	if len(out) > maxErrors {
+		out = append(out[:maxErrors], fmt.Sprintf("... %d more", len(out)-maxErrors))
+	}
+	return out
+}
+
+func (r *results) count(outcome string) int {
+	n := 0
+	for _, res := range r.order {
+		if res.outcome() == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+// failed counts the failures that held up on every rerun
+func (r *results) failed() int {
+	return r.count("fail")
+}
+
+// rerunnable returns the tests that failed on their first run, suite-level
+// findings are not tests and cannot be run on their own
+func (r *results) rerunnable() []string {
+	var out []string
+	for _, res := range r.order {
+		if res.Result == "fail" && !res.Suite {
+			out = append(out, res.Test)
+		}
+	}
+	return out
+}
+
+// unstable returns the flaky and recovered tests, listed apart from the failures
+func (r *results) unstable() []*result {
+	var out []*result
+	for _, res := range r.order {
+		if o := res.outcome(); o == "flaky" || o == "recovered" {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// rerunNote describes the reruns of a test and, with a history, how often it was unstable
+func (r *results) rerunNote(res *result) string {
+	failed := 0
+	for _, rr := range res.Reruns {
+		if rr == "fail" {
+			failed++
+		}
+	}
+	note := fmt.Sprintf("failed %d of %d reruns", failed, len(res.Reruns))
+	if rec := r.flakes[res.Test]; rec != nil {
+		note += fmt.Sprintf(", unstable in %d of the last %d runs", rec.unstable(), len(rec.Recent))
+	}
+	return note
+}
+
+// render writes a table of the results, format is text or markdown
+func (r *results) render(w io.Writer, format string) {
+	summary := fmt.Sprintf("%d passed, %d failed, %d skipped", r.count("pass"), r.failed(), r.count("skip"))
+	unstable := r.unstable()
+	if len(unstable) > 0 {
+		summary += fmt.Sprintf(", %d flaky, %d recovered on rerun", r.count("flaky"), r.count("recovered"))
+	}
+	if format == "markdown" {
+		fmt.Fprintln(w, "| Task | Test | Result | Time |")
+		fmt.Fprintln(w, "|------|------|--------|------|")
+		for _, res := range r.order {
+			fmt.Fprintf(w, "| %s | %s | %s | %.2fs |\n", res.task(), res.Test, strings.ToUpper(res.outcome()), res.Elapsed)
+		}
+		fmt.Fprintf(w, "\n**%s**\n", summary)
+		if len(unstable) > 0 {
+			fmt.Fprintf(w, "\n### Flaky, not counted as failures\n\n")
+			for _, res := range unstable {
+				fmt.Fprintf(w, "- %s %s: %s, %s\n", res.Test, res.task(), res.outcome(), r.rerunNote(res))
+			}
+		}
+		for _, res := range r.order {
+			if len(res.Errors) > 0 && res.outcome() == "fail" {
+				fmt.Fprintf(w, "\n### %s %s\n\n", res.Test, strings.Join(res.Tasks, ", "))
+				for _, msg := range res.Errors {
+					fmt.Fprintf(w, "- %s\n", strings.TrimSpace(msg))
+				}
+			}
+		}
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TASK\tTEST\tRESULT\tTIME")
+	for _, res := range r.order {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.2fs\n", res.task(), res.Test, strings.ToUpper(res.outcome()), res.Elapsed)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, summary)
+	if len(unstable) > 0 {
+		fmt.Fprintf(w, "\nflaky, not counted as failures:\n")
+		for _, res := range unstable {
+			fmt.Fprintf(w, "    %s %s %s, %s\n", res.Test, res.task(), res.outcome(), r.rerunNote(res))
+		}
+	}
+	for _, res := range r.order {
+		if len(res.Errors) > 0 && res.outcome() == "fail" {
+			fmt.Fprintf(w, "\n%s %s\n", res.Test, res.task())
+			for _, msg := range res.Errors {
+				fmt.Fprintf(w, "    %s\n", msg)
+			}
+		}
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// readResults reads the events written by run
+func readResults(rd io.Reader, s *suite) (*results, error) {
+	res := newResults(s)
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		var ev event
+		if json.Unmarshal(sc.Bytes(), &ev) != nil {
+			continue // build output and other non-event lines
+		}
+		res.add(ev)
+	}
+	return res, sc.Err()
+}
+
+func cmdReport(args []string) int {
+	fs := newFlagSet("report", "[results file]")
+	dir := fs.String("dir", ".", "directory of the suite, for the task IDs")
+	format := fs.String("format", "text", "text or markdown")
+	historyPath := fs.String("flaky-history", "", "rerun history written by run, shows how often flaky tests were unstable")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != "markdown" {
+		fmt.Fprintf(os.Stderr, "bughunter report: -format must be text or markdown, got %q\n", *format)
+		return 2
+	}
+	path := "bughunter-results.jsonl"
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+
+	// task IDs are optional, a report can be rendered away from the suite
+	s, err := scanSuite(*dir)
+	if err != nil {
+		s = nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter report: %v\n", err)
+		return 2
+	}
+	defer f.Close()
+	res, err := readResults(f, s)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter report: %s: %v\n", path, err)
+		return 2
+	}
+	if len(res.order) == 0 {
+		fmt.Fprintf(os.Stderr, "bughunter report: %s holds no test results\n", path)
+		return 2
+	}
+	if *historyPath != "" {
+		if res.flakes, err = readFlakeHistory(*historyPath); err != nil {
+			fmt.Fprintf(os.Stderr, "bughunter report: %v\n", err)
+			return 2
+		}
+	}
+	res.render(os.Stdout, *format)
+	if res.failed() > 0 {
+		return 1
+	}
+	return 0
+}
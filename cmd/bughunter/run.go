@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"QA-Bug-Hunter-jr/internal/history"
+)
+
+// taskPattern matches the task ID a finding starts with, e.g. "api-15 Dev POST ..."
+var taskPattern = regexp.MustCompile(`^(api-\d+)\b`)
+
+// taskListPattern matches the task IDs a test's doc comment starts with, e.g.
+// "// api-7 Get a user", "// api-12, api-13 - ..." or "// api-3 / api-22 ..."
+var taskListPattern = regexp.MustCompile(`^api-\d+(?:\s*[,/]\s*api-\d+)*\b`)
+
+var taskID = regexp.MustCompile(`api-\d+`)
+
+// docTasks returns every task ID a doc comment starts with
+func docTasks(doc string) []string {
+	return taskID.FindAllString(taskListPattern.FindString(doc), -1)
+}
+
+// suite is what the test files of the suite declare
+type suite struct {
+	tests []string            // test functions, sorted
+	tasks map[string][]string // task IDs by test, absent for tests without one
+}
+
+// covers reports whether test carries the task ID
+func (s *suite) covers(test, task string) bool {
+	for _, t := range s.tasks[test] {
+		if t == task {
+			return true
+		}
+	}
+	return false
+}
+
+// scanSuite reads the test functions of the suite in dir and the task IDs of their doc comments
+func scanSuite(dir string) (*suite, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil {
+		return nil, err
+	}
+	s := &suite{tasks: map[string][]string{}}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.Name != "main" {
+			continue
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, "Test") || fn.Name.Name == "TestMain" {
+				continue
+			}
+			s.tests = append(s.tests, fn.Name.Name)
+			if fn.Doc != nil {
+				if tasks := docTasks(fn.Doc.Text()); tasks != nil {
+					s.tasks[fn.Name.Name] = tasks
+				}
+			}
+		}
+	}
+	if len(s.tests) == 0 {
+		return nil, fmt.Errorf("no tests found in %s", dir)
+	}
+	sort.Strings(s.tests)
+	return s, nil
+}
+
+// pattern turns task IDs and test names into a go test -run pattern, "" runs everything
+func (s *suite) pattern(selected []string) (string, error) {
+	var names []string
+	for _, sel := range selected {
+		var matched []string
+		for _, test := range s.tests {
+			if test == sel || s.covers(test, sel) {
+				matched = append(matched, test)
+			}
+		}
+		if matched == nil {
+			return "", fmt.Errorf("%q is neither a task ID nor a test of the suite", sel)
+		}
+		names = append(names, matched...)
+	}
+	if names == nil {
+		return "", nil
+	}
+	return "^(" + strings.Join(names, "|") + ")$", nil
+}
+
+func cmdRun(args []string) int {
+	fs := newFlagSet("run", "[task IDs or test names]")
+	var envs envFlags
+	envs.register(fs)
+	dir := fs.String("dir", ".", "directory of the suite")
+	target := fs.String("target", "live", "live runs against -release and -dev, fake against in-process fakes")
+	out := fs.String("out", "bughunter-results.jsonl", "file the go test -json events are written to, for report")
+	record := fs.String("record", "", "file the requests and responses are logged to, for replay")
+	faults := fs.String("faults", "", "fault proxy schedule, e.g. reset/7,5xx/10x3")
+	verify := fs.Bool("verify", false, "read every successful mutation back and fail when the change was not stored")
+	reruns := fs.Int("reruns", 2, "times a failed test is run again in a fresh go test process, 0 disables")
+	historyPath := fs.String("flaky-history", "bughunter-flaky.json", "file the rerun outcomes of every test are kept in, empty disables")
+	runHistory := fs.String("history", "bughunter-history.jsonl", "file every run is appended to, for trend, empty disables")
+	verbose := fs.Bool("v", false, "print the output of every test")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *target != "live" && *target != "fake" {
+		fmt.Fprintf(os.Stderr, "bughunter run: -target must be live or fake, got %q\n", *target)
+		return 2
+	}
+	if *reruns < 0 {
+		fmt.Fprintf(os.Stderr, "bughunter run: -reruns must not be negative, got %d\n", *reruns)
+		return 2
+	}
+
+	s, err := scanSuite(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+		return 2
+	}
+	pattern, err := s.pattern(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+		return 2
+	}
+	var flakes flakeHistory
+	if *historyPath != "" {
+		if flakes, err = readFlakeHistory(*historyPath); err != nil {
+			fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+			return 2
+		}
+	}
+
+	env := append(os.Environ(),
+		"BUGHUNTER_RELEASE_URL="+envs.release,
+		"BUGHUNTER_DEV_URL="+envs.dev,
+	)
+	if *target == "fake" {
+		env = append(env, "BUGHUNTER_TARGET=fake")
+	}
+	if *faults != "" {
+		env = append(env, "BUGHUNTER_FAULTS="+*faults)
+	}
+	if *verify {
+		env = append(env, "BUGHUNTER_VERIFY=1")
+	}
+	firstEnv := env
+	if *record != "" {
+		path, err := filepath.Abs(*record)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+			return 2
+		}
+		// reruns would overwrite the traffic of the first run
+		firstEnv = append(env[:len(env):len(env)], "BUGHUNTER_RECORD="+path)
+	}
+
+	// the fakes of -target fake live inside the test process, there is no build to fingerprint
+	start := time.Now()
+	var prints []history.Fingerprint
+	for _, e := range envs.environments() {
+		if *target == "fake" {
+			prints = append(prints, history.Fingerprint{Name: e.Name, URL: "fake"})
+		} else if *runHistory != "" {
+			prints = append(prints, fingerprint(e))
+		}
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+		return 2
+	}
+	defer f.Close()
+	results := newResults(s)
+	passed, err := goTest(*dir, firstEnv, pattern, f, results, *verbose)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+		return 2
+	}
+	broken := brokenRun(passed, results)
+
+	// every rerun is a new process, so TestMain sets the fixtures up afresh
+	failed := results.rerunnable()
+	for i := 1; i <= *reruns && len(failed) > 0 && !broken; i++ {
+		fmt.Printf("\nrerun %d of %d: %s\n", i, *reruns, strings.Join(failed, " "))
+		if _, err := goTest(*dir, env, "^("+strings.Join(failed, "|")+")$", f, results, *verbose); err != nil {
+			fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+			return 2
+		}
+	}
+
+	if flakes != nil {
+		flakes.record(results, time.Now())
+		if err := flakes.write(*historyPath); err != nil {
+			fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+			return 2
+		}
+		results.flakes = flakes
+	}
+	if *runHistory != "" && !broken {
+		if err := history.Append(*runHistory, runRecord(start, *target, prints, results)); err != nil {
+			fmt.Fprintf(os.Stderr, "bughunter run: %v\n", err)
+			return 2
+		}
+	}
+
+	fmt.Println()
+	results.render(os.Stdout, "text")
+	fmt.Printf("\nevents written to %s\n", *out)
+	if broken || results.failed() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// brokenRun reports whether go test failed for another reason than the
+// tests or the suite-level checks, e.g. a build error. A broken run is
+// neither rerun nor stored in the history; a run that fails only on
+// persistence gaps or price invariants has failed results and is stored.
+func brokenRun(passed bool, res *results) bool {
+	return !passed && res.failed() == 0
+}
+
+// goTest runs go test -json in dir, appends the events to w, folds them
+// into res and reports whether go test passed
+func goTest(dir string, env []string, pattern string, w io.Writer, res *results, verbose bool) (bool, error) {
+	args := []string{"test", "-json", "-count=1"}
+	if pattern != "" {
+		args = append(args, "-run", pattern)
+	}
+	cmd := exec.Command("go", append(args, ".")...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	cmd.Env = env
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+	follow(io.TeeReader(stdout, w), res, verbose)
+	return cmd.Wait() == nil, nil
+}
+
+// follow reads go test -json events into res and prints a line per finished
+// test; lines that are not events are passed through
+func follow(r io.Reader, res *results, verbose bool) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for sc.Scan() {
+		var ev event
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil || ev.Action == "" {
+			fmt.Println(sc.Text())
+			continue
+		}
+		if verbose && ev.Action == "output" {
+			fmt.Print(ev.Output)
+		}
+		r := res.add(ev)
+		if !verbose && ev.Test == "" && ev.Action == "output" && res.section != "" {
+			fmt.Print(ev.Output) // suite-level findings, they fail tests that already passed
+		}
+		if r == nil || verbose {
+			continue
+		}
+		if n := len(r.Reruns); n > 0 {
+			fmt.Printf("%-4s %-8s %s (rerun %d)\n", strings.ToUpper(r.Reruns[n-1]), r.task(), r.Test, n)
+		} else {
+			fmt.Printf("%-4s %-8s %s\n", strings.ToUpper(r.Result), r.task(), r.Test)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"QA-Bug-Hunter-jr/internal/fakeapi"
+)
+
+func cmdServe(args []string) int {
+	fs := newFlagSet("serve", "")
+	releaseAddr := fs.String("release", "localhost:8081", "address of the fake Release API")
+	devAddr := fs.String("dev", "localhost:8082", "address of the fake Dev API")
+	devLatency := fs.Duration("dev-latency", 0, "delay every response of the fake Dev API")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// both fakes share one data set, like the real deployments
+	release := fakeapi.New(fakeapi.Options{})
+	handlers := []struct {
+		name, addr string
+		handler    http.Handler
+	}{
+		{"Release", *releaseAddr, release},
+		{"Dev", *devAddr, release.Share(fakeapi.Options{Dev: true, Latency: *devLatency})},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	errs := make(chan error, len(handlers))
+	var servers []*http.Server
+	for _, h := range handlers {
+		ln, err := net.Listen("tcp", h.addr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bughunter serve: %v\n", err)
+			return 2
+		}
+		srv := &http.Server{Handler: h.handler}
+		servers = append(servers, srv)
+		go func() { errs <- srv.Serve(ln) }()
+		fmt.Printf("%-8s http://%s%s\n", h.name, ln.Addr(), fakeapi.BasePath)
+	}
+	fmt.Println("press Ctrl+C to stop")
+
+	code := 0
+	select {
+	case <-ctx.Done():
+	case err := <-errs:
+		fmt.Fprintf(os.Stderr, "bughunter serve: %v\n", err)
+		code = 1
+	}
+	for _, srv := range servers {
+		if err := srv.Shutdown(context.Background()); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "bughunter serve: %v\n", err)
+		}
+	}
+	return code
+}
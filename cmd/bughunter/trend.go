@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/history"
+)
+
+// fingerprintPaths are read-only endpoints whose answers describe a build,
+// with the task ID of each
+var fingerprintPaths = []struct{ path, task string }{
+	{"/games?offset=0&limit=1", "api-9"},
+	{"/categories", "api-10"},
+	{"/users?offset=0&limit=1", "api-6"},
+}
+
+// fingerprint identifies the build env runs: the headers that name a
+// version and the shape, keys and value types but not values, of a few
+// answers. New data keeps the fingerprint, a changed schema does not.
+func fingerprint(env api.Environment) history.Fingerprint {
+	fp := history.Fingerprint{Name: env.Name, URL: env.URL}
+	h := sha256.New()
+	for _, p := range fingerprintPaths {
+		resp, err := api.SendGetRequest(env.URL+p.path, p.task)
+		if err != nil {
+			fp.Err = err.Error()
+			return fp
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			fp.Err = err.Error()
+			return fp
+		}
+		for name, values := range versionHeaders(resp.Header) {
+			if fp.Headers == nil {
+				fp.Headers = map[string]string{}
+			}
+			fp.Headers[name] = values
+		}
+		var doc interface{}
+		if json.Unmarshal(data, &doc) != nil {
+			doc = "not json"
+		}
+		fmt.Fprintf(h, "%s %d %s\n", p.path, resp.StatusCode, shape(doc))
+	}
+	names := make([]string, 0, len(fp.Headers))
+	for name := range fp.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s: %s\n", name, fp.Headers[name])
+	}
+	fp.Hash = hex.EncodeToString(h.Sum(nil))[:12]
+	return fp
+}
+
+// versionHeaders picks the headers that name a server, version or build
+func versionHeaders(header http.Header) map[string]string {
+	out := map[string]string{}
+	for name, values := range header {
+		lower := strings.ToLower(name)
+		if lower == "server" || strings.Contains(lower, "version") || strings.Contains(lower, "build") || strings.Contains(lower, "revision") {
+			out[name] = strings.Join(values, ", ")
+		}
+	}
+	return out
+}
+
+// shape describes the structure of a decoded JSON value, e.g.
+// {games:[{price:number,title:string}],meta:{total:number}}
+func shape(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ":" + shape(v[k])
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	case []interface{}:
+		if len(v) == 0 {
+			return "[]"
+		}
+		return "[" + shape(v[0]) + "]"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+// runRecord turns the results of a run into a history entry
+func runRecord(start time.Time, target string, envs []history.Fingerprint, res *results) history.Run {
+	run := history.Run{ID: history.NewID(start), Time: start, Target: target, Envs: envs}
+	for _, r := range res.order {
+		run.Tests = append(run.Tests, history.Test{
+			Name:    r.Test,
+			Tasks:   r.Tasks,
+			Outcome: r.outcome(),
+			Elapsed: r.Elapsed,
+			Diffs:   r.Errors,
+		})
+	}
+	return run
+}
+
+// renderTrends writes a table of trends, format is text or markdown
+func renderTrends(w io.Writer, trends []*history.Trend, format string) {
+	when := func(s *history.Sighting) string {
+		if s == nil {
+			return "-"
+		}
+		return s.Time.Format("2006-01-02 15:04")
+	}
+	row := func(tr *history.Trend) []string {
+		flips := fmt.Sprint(tr.Flips)
+		if tr.Flapped() {
+			flips += " flapped"
+		}
+		return []string{tr.Task, strings.ToUpper(tr.Current), when(tr.First), when(tr.Last),
+			fmt.Sprintf("%d/%d", tr.Failed, tr.Runs), flips}
+	}
+	header := []string{"TASK", "NOW", "FIRST FAILED", "LAST FAILED", "FAILED RUNS", "FLIPS"}
+
+	if format == "markdown" {
+		fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat("------|", len(header)))
+		for _, tr := range trends {
+			fmt.Fprintf(w, "| %s |\n", strings.Join(row(tr), " | "))
+		}
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, tr := range trends {
+			fmt.Fprintln(tw, strings.Join(row(tr), "\t"))
+		}
+		tw.Flush()
+	}
+
+	// where each bug came from, with the builds it appeared on and its latest messages
+	for _, tr := range trends {
+		if tr.First == nil {
+			continue
+		}
+		if format == "markdown" {
+			fmt.Fprintf(w, "\n### %s %s\n\n", tr.Task, strings.Join(tr.Tests, ", "))
+		} else {
+			fmt.Fprintf(w, "\n%s %s\n", tr.Task, strings.Join(tr.Tests, ", "))
+		}
+		lines := []string{
+			fmt.Sprintf("first failed in run %s on %s", tr.First.Run, builds(tr.First.Envs)),
+			fmt.Sprintf("last failed in run %s on %s", tr.Last.Run, builds(tr.Last.Envs)),
+		}
+		for _, d := range tr.Last.Diffs {
+			lines = append(lines, "  "+d)
+		}
+		for _, line := range lines {
+			if format == "markdown" {
+				fmt.Fprintf(w, "- %s\n", strings.TrimSpace(line))
+			} else {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+	}
+}
+
+// builds names the fingerprint of every environment, e.g. "Release 1a2b3c4d5e6f, Dev 9f8e7d6c5b4a"
+func builds(envs []history.Fingerprint) string {
+	if len(envs) == 0 {
+		return "unknown builds"
+	}
+	parts := make([]string, len(envs))
+	for i, fp := range envs {
+		switch {
+		case fp.Hash != "":
+			parts[i] = fp.Name + " " + fp.Hash
+		case fp.Err != "":
+			parts[i] = fp.Name + " (no fingerprint)"
+		default:
+			parts[i] = fp.Name + " " + fp.URL
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func cmdTrend(args []string) int {
+	fs := newFlagSet("trend", "[task IDs or test names]")
+	path := fs.String("history", "bughunter-history.jsonl", "history file written by run")
+	format := fs.String("format", "text", "text or markdown")
+	failing := fs.Bool("failing", false, "only tasks that failed at least once")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != "markdown" {
+		fmt.Fprintf(os.Stderr, "bughunter trend: -format must be text or markdown, got %q\n", *format)
+		return 2
+	}
+	runs, err := history.ReadFile(*path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bughunter trend: %v\n", err)
+		return 2
+	}
+	if len(runs) == 0 {
+		fmt.Fprintf(os.Stderr, "bughunter trend: %s holds no runs\n", *path)
+		return 2
+	}
+
+	selected := map[string]bool{}
+	for _, arg := range fs.Args() {
+		selected[arg] = true
+	}
+	var trends []*history.Trend
+	for _, tr := range history.Trends(runs) {
+		if len(selected) > 0 && !selected[tr.Task] && !anySelected(selected, tr.Tests) {
+			continue
+		}
+		if *failing && tr.First == nil {
+			continue
+		}
+		trends = append(trends, tr)
+	}
+	if len(trends) == 0 {
+		fmt.Fprintf(os.Stderr, "bughunter trend: nothing in %s matches\n", *path)
+		return 2
+	}
+	fmt.Printf("%d runs from %s to %s\n\n", len(runs),
+		runs[0].Time.Format("2006-01-02 15:04"), runs[len(runs)-1].Time.Format("2006-01-02 15:04"))
+	renderTrends(os.Stdout, trends, *format)
+	return 0
+}
+
+func anySelected(selected map[string]bool, tests []string) bool {
+	for _, t := range tests {
+		if selected[t] {
+			return true
+		}
+	}
+	return false
+}
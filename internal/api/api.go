@@ -0,0 +1,446 @@
+// Package api holds the request models and HTTP helpers of the game store
+// API. The test suite and the bughunter command both send their requests
+// through it.
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// AuthHeader is sent with every request
+const AuthHeader = "Bearer qahack2024:jagadeshc0891@gmail.com"
+
+// base URLs of the deployed environments
+const (
+	DefaultReleaseURL = "https://release-gs.qa-playground.com/api/v1"
+	DefaultDevURL     = "https://dev-gs.qa-playground.com/api/v1"
+)
+
+// target environment under test
+type Environment struct {
+	Name string
+	URL  string
+}
+
+// login request body
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// creating a user
+type UserCreateRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Nickname string `json:"nickname"`
+}
+
+// update a user
+type UserUpdateRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name"`
+	Nickname string `json:"nickname"`
+}
+
+// user
+type User struct {
+	UUID      string `json:"uuid"`
+	Email     string `json:"email"`
+	Nickname  string `json:"nickname"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// game
+type Game struct {
+	CategoryUUIDs []string `json:"category_uuids"`
+	Price         int      `json:"price"`
+	Title         string   `json:"title"`
+	UUID          string   `json:"uuid"`
+}
+
+// category partial
+type Category struct {
+	UUID  string `json:"uuid"`
+	Title string `json:"title"`
+}
+
+// add to wishlist
+type WishlistBody struct {
+	ItemUUID string `json:"item_uuid"`
+}
+
+// add to cart
+type AddItemRequest struct {
+	ItemUUID string `json:"item_uuid"`
+	Quantity int    `json:"quantity"`
+}
+
+// change quantity in cart
+type ChangeItemQuantityRequest struct {
+	ItemUUID string `json:"item_uuid"`
+	Quantity int    `json:"quantity"`
+}
+
+// delete item in cart
+type RemoveItemRequest struct {
+	ItemUUID string `json:"item_uuid"`
+	Quantity int    `json:"quantity"`
+}
+
+// item in the user's cart
+type CartItem struct {
+	ItemUUID   string `json:"item_uuid"`
+	Quantity   int    `json:"quantity"`
+	TotalPrice int    `json:"total_price"`
+}
+
+// structure of the cart response
+type CartResponse struct {
+	Items      []CartItem `json:"items"`
+	TotalPrice int        `json:"total_price"`
+	UserUUID   string     `json:"user_uuid"`
+}
+
+// item of an order
+type OrderItem struct {
+	ItemUUID string `json:"item_uuid"`
+	Quantity int    `json:"quantity"`
+}
+
+// creating an order
+type OrderCreateRequest struct {
+	Items []OrderItem `json:"items"`
+}
+
+// update order status
+type OrderStatusUpdateRequest struct {
+	Status string `json:"status"`
+}
+
+// paying an order
+type PaymentCreateRequest struct {
+	OrderUUID     string `json:"order_uuid"`
+	PaymentMethod string `json:"payment_method"`
+}
+
+// Helper - POST requests
+func SendPostRequest(url string, body interface{}, taskID string) (*http.Response, error) {
+	client := &http.Client{}
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", AuthHeader)
+	req.Header.Set("X-Task-Id", taskID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Helper - GET requests
+func SendGetRequest(url string, taskID string) (*http.Response, error) {
+	client := &http.Client{}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", AuthHeader)
+	req.Header.Set("X-Task-Id", taskID)
+
+	// No Cache headers
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("Pragma", "no-cache")
+	req.Header.Set("Expires", "0")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Helper - DELETE requests
+func SendDeleteRequest(url string, taskID string) (*http.Response, error) {
+	client := &http.Client{}
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", AuthHeader)
+	req.Header.Set("X-Task-Id", taskID)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Helper - PATCH requests
+func SendPatchRequest(url string, body interface{}, taskID string) (*http.Response, error) {
+	client := &http.Client{}
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", AuthHeader)
+	req.Header.Set("X-Task-Id", taskID)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// Helper - any method with a custom Authorization header, omitted when empty
+func SendRequestWithAuth(method, url, authHeader string, body interface{}, taskID string) (*http.Response, error) {
+	client := &http.Client{}
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+
+	if authHeader != "" {
+		req.Header.Set("Authorization", authHeader)
+	}
+	req.Header.Set("X-Task-Id", taskID)
+	req.Header.Set("Content-Type", "application/json")
+
+	return client.Do(req)
+}
+
+// Helper - decode a JSON response body into v, closing it
+func DecodeJSONResponse(resp *http.Response, v interface{}) error {
+	if resp == nil {
+		return fmt.Errorf("no response")
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return fmt.Errorf("status %d: expected a JSON body, got Content-Type %q", resp.StatusCode, ct)
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("status %d: decoding JSON body: %w", resp.StatusCode, err)
+	}
+	return nil
+}
+
+// Helper - parse a JSON object response
+func ParseJSONResponse(resp *http.Response) (map[string]interface{}, error) {
+	var responseBody map[string]interface{}
+	if err := DecodeJSONResponse(resp, &responseBody); err != nil {
+		return nil, err
+	}
+	return responseBody, nil
+}
+
+// FetchJSON sends a GET request and decodes a 200 response into v
+func FetchJSON(url, taskID string, v interface{}) error {
+	resp, err := SendGetRequest(url, taskID)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return fmt.Errorf("GET %s returned %d", url, resp.StatusCode)
+	}
+	return DecodeJSONResponse(resp, v)
+}
+
+// Helper - PUT requests with file
+func SendPutRequestWithFile(url string, filePath string, taskID string) (*http.Response, error) {
+	// a missing file is an error, empty uploads go through SendPutRequestWithData
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+	return SendPutRequestWithData(url, filepath.Base(filePath), data, taskID)
+}
+
+// Helper - PUT requests with in-memory file content, sent as the "avatar_file" form field
+func SendPutRequestWithData(url string, filename string, data []byte, taskID string) (*http.Response, error) {
+	// Create a buffer to write the multipart form data
+	var b bytes.Buffer
+	writer := multipart.NewWriter(&b)
+
+	// the part's content type follows the file name, like a browser upload
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="avatar_file"; filename="%s"`, filename))
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %v", err)
+	}
+	if _, err = part.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to copy file content: %v", err)
+	}
+
+	// Close the writer to finalize the multipart form
+	err = writer.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close multipart writer: %v", err)
+	}
+
+	// Create the PUT request with the appropriate headers
+	req, err := http.NewRequest("PUT", url, &b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PUT request: %v", err)
+	}
+
+	// Add necessary headers
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", AuthHeader)
+	req.Header.Set("X-Task-Id", taskID)
+
+	// Send the request using the http client
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send PUT request: %v", err)
+	}
+
+	return resp, nil
+}
+
+// FetchAllUsers returns the first page of users of an environment
+func FetchAllUsers(url, taskID string) ([]map[string]interface{}, error) {
+	// Fetch and parse the users page
+	var body struct {
+		Users []map[string]interface{} `json:"users"`
+	}
+	if err := FetchJSON(fmt.Sprintf("%s/users", url), taskID, &body); err != nil {
+		return nil, err
+	}
+
+	// Ensure "users" field exists in the response
+	if body.Users == nil {
+		return nil, fmt.Errorf("expected 'users' key in the response")
+	}
+	return body.Users, nil
+}
+
+// FetchAllGames pages through the whole catalog of an environment
+func FetchAllGames(url, taskID string) ([]*Game, error) {
+	var games []*Game
+	for {
+		var body struct {
+			Games []*Game `json:"games"`
+			Meta  struct {
+				Total int `json:"total"`
+			} `json:"meta"`
+		}
+		if err := FetchJSON(fmt.Sprintf("%s/games?offset=%d&limit=100", url, len(games)), taskID, &body); err != nil {
+			return nil, err
+		}
+		games = append(games, body.Games...)
+		if len(body.Games) == 0 || len(games) >= body.Meta.Total {
+			return games, nil
+		}
+	}
+}
+
+func AddItemToCart(userUUID string, itemUUID string, quantity int, environmentURL string, taskID string) (*http.Response, error) {
+	// Prepare the request body using the AddItemRequest struct
+	requestBody := AddItemRequest{
+		ItemUUID: itemUUID,
+		Quantity: quantity,
+	}
+
+	// Convert request body to JSON
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request data: %v", err)
+	}
+
+	// Create a POST request to add the item to the cart
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/users/%s/cart/add", environmentURL, userUUID), bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create POST request: %v", err)
+	}
+
+	// Set headers
+	req.Header.Set("Authorization", AuthHeader)
+	req.Header.Set("X-Task-Id", taskID)
+	req.Header.Set("Content-Type", "application/json")
+
+	// Send the request
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send POST request: %v", err)
+	}
+
+	return resp, nil
+}
+
+func GetUserCart(userUUID string, environmentURL string, taskID string) (*http.Response, error) {
+	// Send GET request to fetch the cart
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/users/%s/cart", environmentURL, userUUID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GET request: %v", err)
+	}
+
+	// Set headers
+	req.Header.Set("Authorization", AuthHeader)
+	req.Header.Set("X-Task-Id", taskID)
+
+	// Send the request
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send GET request: %v", err)
+	}
+
+	return resp, nil
+}
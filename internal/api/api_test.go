@@ -0,0 +1,54 @@
+package api
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func response(status int, contentType, body string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+	if contentType != "" {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	return resp
+}
+
+func TestDecodeJSONResponse(t *testing.T) {
+	body, err := ParseJSONResponse(response(200, "application/json; charset=utf-8", `{"uuid":"a"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "a", body["uuid"])
+
+	_, err = ParseJSONResponse(response(200, "application/problem+json", `{}`))
+	assert.NoError(t, err)
+	_, err = ParseJSONResponse(response(200, "", `{}`))
+	assert.NoError(t, err, "a missing content type is tolerated")
+
+	_, err = ParseJSONResponse(nil)
+	assert.EqualError(t, err, "no response")
+	_, err = ParseJSONResponse(response(200, "text/html", `{}`))
+	assert.EqualError(t, err, `status 200: expected a JSON body, got Content-Type "text/html"`)
+	_, err = ParseJSONResponse(response(500, "application/json", `{"code":`))
+	assert.ErrorContains(t, err, "status 500: decoding JSON body")
+
+	var user User
+	err = DecodeJSONResponse(response(200, "application/json", `{"uuid":7}`), &user)
+	assert.Error(t, err, "a number is not a UUID")
+}
+
+func TestSendPutRequestWithFileMissing(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer srv.Close()
+
+	_, err := SendPutRequestWithFile(srv.URL, filepath.Join(t.TempDir(), "missing.png"), "api-test")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	assert.False(t, called, "nothing is uploaded for a missing file")
+}
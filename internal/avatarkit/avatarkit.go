@@ -0,0 +1,165 @@
+// Package avatarkit builds avatar upload payloads in memory: real PNG, JPEG,
+// GIF and WebP images of chosen sizes, and invalid inputs such as empty
+// files, mislabelled files and oversized images.
+package avatarkit
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+)
+
+// Formats lists the image formats Generate can encode.
+var Formats = []string{"png", "jpeg", "gif", "webp"}
+
+// extensions maps a format to the file extension used for uploads
+var extensions = map[string]string{"png": ".png", "jpeg": ".jpg", "gif": ".gif", "webp": ".webp"}
+
+// Image is an encoded image ready to be uploaded.
+type Image struct {
+	Format   string
+	Filename string
+	Width    int
+	Height   int
+	Data     []byte
+}
+
+// Pattern returns a deterministic opaque gradient of the given size.
+func Pattern(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x * 255 / max(width-1, 1)),
+				G: uint8(y * 255 / max(height-1, 1)),
+				B: uint8((x + y) * 7),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// Noise returns an image of random pixels, which compresses poorly.
+func Noise(width, height int, seed int64) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rand.New(rand.NewSource(seed)).Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+// Encode encodes img in one of Formats.
+func Encode(format string, img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	case "webp":
+		return EncodeWebP(img), nil
+	default:
+		return nil, fmt.Errorf("unknown image format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Generate encodes a gradient of the given size.
+func Generate(format string, width, height int) (Image, error) {
+	data, err := Encode(format, Pattern(width, height))
+	if err != nil {
+		return Image{}, err
+	}
+	return Image{
+		Format:   format,
+		Filename: fmt.Sprintf("avatar-%dx%d%s", width, height, extensions[format]),
+		Width:    width,
+		Height:   height,
+		Data:     data,
+	}, nil
+}
+
+// Case is one upload payload. Valid cases carry a real image whose name
+// matches its format; Image is nil for payloads that are not an image.
+type Case struct {
+	Name     string
+	Filename string
+	Data     []byte
+	Valid    bool
+	Image    *Image
+}
+
+// Sizes are the dimensions every format is generated at.
+var Sizes = [][2]int{{1, 1}, {64, 64}, {256, 128}, {512, 512}}
+
+// OversizedBytes is the minimum size of the oversized payload.
+const OversizedBytes = 6 << 20
+
+// Cases returns a valid case for every format and size, followed by the
+// invalid inputs.
+func Cases() ([]Case, error) {
+	var cases []Case
+	for _, format := range Formats {
+		for _, size := range Sizes {
+			img, err := Generate(format, size[0], size[1])
+			if err != nil {
+				return nil, err
+			}
+			cases = append(cases, Case{
+				Name:     fmt.Sprintf("%s %dx%d", format, size[0], size[1]),
+				Filename: img.Filename,
+				Data:     img.Data,
+				Valid:    true,
+				Image:    &img,
+			})
+		}
+	}
+
+	small, err := Generate("png", 64, 64)
+	if err != nil {
+		return nil, err
+	}
+	photo, err := Generate("jpeg", 64, 64)
+	if err != nil {
+		return nil, err
+	}
+	oversized, err := oversizedPNG()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(cases,
+		Case{Name: "zero bytes", Filename: "avatar.png", Data: []byte{}},
+		Case{Name: "png named .txt", Filename: "avatar.txt", Data: small.Data, Image: &small},
+		Case{Name: "jpeg named .png", Filename: "avatar.png", Data: photo.Data, Image: &photo},
+		Case{Name: "text named .jpg", Filename: "avatar.jpg", Data: []byte("this is not an image\n")},
+		Case{Name: "truncated png", Filename: "avatar.png", Data: small.Data[:len(small.Data)/2]},
+		Case{Name: "oversized png", Filename: oversized.Filename, Data: oversized.Data, Image: &oversized},
+	), nil
+}
+
+// oversizedPNG returns a noise PNG larger than OversizedBytes
+func oversizedPNG() (Image, error) {
+	side := 1500 // opaque noise keeps about 3 bytes per pixel, 6.75 MB
+	data, err := Encode("png", Noise(side, side, 1))
+	if err != nil {
+		return Image{}, err
+	}
+	if len(data) < OversizedBytes {
+		return Image{}, fmt.Errorf("oversized png is only %d bytes", len(data))
+	}
+	return Image{Format: "png", Filename: "avatar-oversized.png", Width: side, Height: side, Data: data}, nil
+}
@@ -0,0 +1,74 @@
+package avatarkit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/webp"
+)
+
+func TestGenerateDecodes(t *testing.T) {
+	for _, format := range Formats {
+		img, err := Generate(format, 37, 21)
+		if !assert.NoError(t, err, format) {
+			continue
+		}
+		var cfg image.Config
+		var got string
+		if format == "webp" {
+			cfg, err = webp.DecodeConfig(bytes.NewReader(img.Data))
+			got = "webp"
+		} else {
+			cfg, got, err = image.DecodeConfig(bytes.NewReader(img.Data))
+		}
+		if assert.NoError(t, err, format) {
+			assert.Equal(t, format, got)
+			assert.Equal(t, 37, cfg.Width, format)
+			assert.Equal(t, 21, cfg.Height, format)
+		}
+	}
+}
+
+func TestEncodeWebPIsLossless(t *testing.T) {
+	for _, size := range [][2]int{{1, 1}, {3, 5}, {64, 48}} {
+		want := Noise(size[0], size[1], 7)
+		// exercise alpha as well
+		want.Pix[3] = 0
+		got, err := webp.Decode(bytes.NewReader(EncodeWebP(want)))
+		if !assert.NoError(t, err, "%v", size) {
+			continue
+		}
+		assert.Equal(t, want.Bounds(), got.Bounds())
+		for y := 0; y < size[1]; y++ {
+			for x := 0; x < size[0]; x++ {
+				if c := color.NRGBAModel.Convert(got.At(x, y)); c != want.At(x, y) {
+					t.Fatalf("%v: pixel (%d, %d) is %v, want %v", size, x, y, c, want.At(x, y))
+				}
+			}
+		}
+	}
+}
+
+func TestCases(t *testing.T) {
+	cases, err := Cases()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, cases, len(Formats)*len(Sizes)+6)
+
+	names := map[string]bool{}
+	for _, c := range cases {
+		assert.False(t, names[c.Name], "duplicate case %s", c.Name)
+		names[c.Name] = true
+		if c.Valid {
+			assert.NotNil(t, c.Image, c.Name)
+			assert.NotEmpty(t, c.Data, c.Name)
+		}
+	}
+	last := cases[len(cases)-1]
+	assert.GreaterOrEqual(t, len(last.Data), OversizedBytes)
+	assert.Empty(t, cases[len(Formats)*len(Sizes)].Data, "zero bytes")
+}
@@ -0,0 +1,125 @@
+package avatarkit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+)
+
+// EncodeWebP encodes img as a lossless WebP (VP8L) image. The encoder is
+// deliberately minimal: no transforms, no color cache and fixed 8-bit prefix
+// codes, so every channel value costs 8 bits. That is enough to produce a
+// valid file of a given size, not a small one.
+func EncodeWebP(img image.Image) []byte {
+	b := img.Bounds()
+	w := &bitWriter{}
+
+	// VP8L header: signature, 14-bit width-1 and height-1, alpha hint, version 0
+	w.write(0x2f, 8)
+	w.write(uint32(b.Dx()-1), 14)
+	w.write(uint32(b.Dy()-1), 14)
+	w.write(1, 1)
+	w.write(0, 3)
+
+	w.write(0, 1) // no transforms
+	w.write(0, 1) // no color cache
+	w.write(0, 1) // no meta prefix codes
+
+	writeFullCode(w, 256+24) // green and length prefixes, only the 256 literals are used
+	writeFullCode(w, 256)    // red
+	writeFullCode(w, 256)    // blue
+	writeFullCode(w, 256)    // alpha
+	// distance: a single symbol costing no bits, backward references are never used
+	w.write(1, 1) // simple
+	w.write(0, 1) // one symbol
+	w.write(0, 1) // stored in 1 bit
+	w.write(0, 1) // symbol 0
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			for _, v := range []uint8{c.G, c.R, c.B, c.A} {
+				w.writeCode(v)
+			}
+		}
+	}
+
+	data := w.bytes()
+	chunk := new(bytes.Buffer)
+	chunk.WriteString("VP8L")
+	binary.Write(chunk, binary.LittleEndian, uint32(len(data)))
+	chunk.Write(data)
+	if len(data)%2 == 1 {
+		chunk.WriteByte(0)
+	}
+
+	out := new(bytes.Buffer)
+	out.WriteString("RIFF")
+	binary.Write(out, binary.LittleEndian, uint32(4+chunk.Len()))
+	out.WriteString("WEBP")
+	out.Write(chunk.Bytes())
+	return out.Bytes()
+}
+
+// writeFullCode writes a normal prefix code giving the first 256 symbols of an
+// alphabet of size n a length of 8 and the rest a length of 0.
+func writeFullCode(w *bitWriter, n int) {
+	w.write(0, 1) // normal, not simple
+
+	// code length code: only the lengths 0 and 8 occur, each gets a 1-bit code.
+	// Lengths are listed in the order 17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, ...
+	// so 12 entries reach the one for 8.
+	w.write(12-4, 4)
+	for i := 0; i < 12; i++ {
+		switch i {
+		case 2, 11: // lengths 0 and 8
+			w.write(1, 3)
+		default:
+			w.write(0, 3)
+		}
+	}
+
+	w.write(0, 1) // code lengths for the whole alphabet follow
+	for i := 0; i < n; i++ {
+		if i < 256 {
+			w.write(1, 1) // length 8, the second canonical code
+		} else {
+			w.write(0, 1) // length 0
+		}
+	}
+}
+
+// bitWriter packs values least significant bit first, as VP8L reads them
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+func (w *bitWriter) write(v uint32, n uint) {
+	w.acc |= uint64(v) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// writeCode writes the canonical 8-bit code of symbol v, whose first bit is its most significant
+func (w *bitWriter) writeCode(v uint8) {
+	var r uint8
+	for i := 0; i < 8; i++ {
+		r = r<<1 | v>>i&1
+	}
+	w.write(uint32(r), 8)
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
@@ -0,0 +1,215 @@
+// Package chain sends requests built from {{name}} templates and captures
+// values out of the answers for later requests, so a flow reads top to
+// bottom without decoding every response by hand:
+//
+//	set := vars.New()
+//	set.Set("user.uuid", user.UUID)
+//	release := chain.New(releaseEnv, auth, set)
+//	_, err := release.Post("/users/{{user.uuid}}/orders", order).Task("api-16").
+//		Expect(200).Capture("order.uuid", "$.uuid", vars.UUID).Send()
+//	status, err := release.Get("/orders/{{order.uuid}}").Task("api-17").Send()
+//
+// Clients of several environments may share one set; what a client captures
+// is stored for its environment, see package vars.
+package chain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/jsonpath"
+	"QA-Bug-Hunter-jr/internal/vars"
+)
+
+// Client sends requests to one environment.
+type Client struct {
+	Env  api.Environment
+	Auth string // Authorization header, none when empty
+	Vars *vars.Set
+}
+
+// New returns a client of env that fills and captures values in set.
+func New(env api.Environment, auth string, set *vars.Set) *Client {
+	return &Client{Env: env, Auth: auth, Vars: set}
+}
+
+// Request is a request being built, nothing is sent before Send.
+type Request struct {
+	client   *Client
+	method   string
+	path     string
+	body     interface{}
+	task     string
+	status   []int
+	captures []capture
+}
+
+type capture struct {
+	name, path string
+	typ        vars.Type
+}
+
+// Do starts a request with any method, body may be nil.
+func (c *Client) Do(method, path string, body interface{}) *Request {
+	return &Request{client: c, method: method, path: path, body: body}
+}
+
+func (c *Client) Get(path string) *Request {
+	return c.Do("GET", path, nil)
+}
+
+func (c *Client) Post(path string, body interface{}) *Request {
+	return c.Do("POST", path, body)
+}
+
+func (c *Client) Put(path string, body interface{}) *Request {
+	return c.Do("PUT", path, body)
+}
+
+func (c *Client) Patch(path string, body interface{}) *Request {
+	return c.Do("PATCH", path, body)
+}
+
+func (c *Client) Delete(path string) *Request {
+	return c.Do("DELETE", path, nil)
+}
+
+// Task sets the X-Task-Id header.
+func (r *Request) Task(id string) *Request {
+	r.task = id
+	return r
+}
+
+// Expect makes Send fail unless the status is one of codes, before anything
+// is captured.
+func (r *Request) Expect(codes ...int) *Request {
+	r.status = append(r.status, codes...)
+	return r
+}
+
+// Capture stores the value at a JSONPath of the answer as name, it must
+// have type typ.
+func (r *Request) Capture(name, path string, typ vars.Type) *Request {
+	r.captures = append(r.captures, capture{name: name, path: path, typ: typ})
+	return r
+}
+
+// Response is the answer to a request.
+type Response struct {
+	Env    string
+	Method string
+	Path   string // with the placeholders filled
+	Status int
+	Body   []byte
+	Doc    interface{} // Body decoded, nil when it is not JSON
+}
+
+// Get reads a JSONPath of the answer.
+func (r *Response) Get(path string) (interface{}, error) {
+	if r.Doc == nil {
+		return nil, fmt.Errorf("%s %s: status %d, body is not JSON: %q", r.Method, r.Path, r.Status, truncate(string(r.Body)))
+	}
+	return jsonpath.Get(r.Doc, path)
+}
+
+// Object returns the answer when it is a JSON object, nil otherwise.
+func (r *Response) Object() map[string]interface{} {
+	obj, _ := r.Doc.(map[string]interface{})
+	return obj
+}
+
+// Send fills the placeholders, sends the request and runs the captures. A
+// failed capture is returned as a *vars.CaptureError together with the
+// response.
+func (r *Request) Send() (*Response, error) {
+	c := r.client
+	path, err := ExpandPath(c.Vars, c.Env.Name, r.path)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s on %s: %v", r.method, r.path, c.Env.Name, err)
+	}
+	body, err := r.expandBody()
+	if err != nil {
+		return nil, fmt.Errorf("%s %s on %s: body: %v", r.method, path, c.Env.Name, err)
+	}
+
+	resp, err := api.SendRequestWithAuth(r.method, c.Env.URL+path, c.Auth, body, r.task)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s on %s: %v", r.method, path, c.Env.Name, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s on %s: %v", r.method, path, c.Env.Name, err)
+	}
+	out := &Response{Env: c.Env.Name, Method: r.method, Path: path, Status: resp.StatusCode, Body: data}
+	if json.Unmarshal(data, &out.Doc) != nil {
+		out.Doc = nil
+	}
+
+	if len(r.status) > 0 && !expected(r.status, out.Status) {
+		return out, fmt.Errorf("%s %s on %s: status %d, want %s: %s", r.method, path, c.Env.Name, out.Status, codes(r.status), truncate(string(data)))
+	}
+	for _, cp := range r.captures {
+		if _, err := c.Vars.Capture(c.Env.Name, cp.name, cp.path, cp.typ, out.Doc); err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// expandBody turns the body into decoded JSON and fills its placeholders,
+// so structs may carry "{{order.uuid}}" in string fields
+func (r *Request) expandBody() (interface{}, error) {
+	if r.body == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(r.body)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return r.client.Vars.ExpandValue(r.client.Env.Name, doc)
+}
+
+// ExpandPath fills the placeholders of a request path as seen from env,
+// escaping the values for the path or the query string they land in.
+func ExpandPath(set *vars.Set, env, path string) (string, error) {
+	p, query, hasQuery := strings.Cut(path, "?")
+	out, err := set.ExpandWith(env, p, url.PathEscape)
+	if err != nil || !hasQuery {
+		return out, err
+	}
+	query, err = set.ExpandWith(env, query, url.QueryEscape)
+	return out + "?" + query, err
+}
+
+func expected(codes []int, status int) bool {
+	for _, c := range codes {
+		if c == status {
+			return true
+		}
+	}
+	return false
+}
+
+func codes(cs []int) string {
+	parts := make([]string, len(cs))
+	for i, c := range cs {
+		parts[i] = fmt.Sprint(c)
+	}
+	return strings.Join(parts, " or ")
+}
+
+func truncate(s string) string {
+	if len(s) > 80 {
+		return s[:77] + "..."
+	}
+	return s
+}
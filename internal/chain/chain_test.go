@@ -0,0 +1,87 @@
+package chain
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/fakeapi"
+	"QA-Bug-Hunter-jr/internal/vars"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const auth = "Bearer secret:qa@example.com"
+
+// fakes starts a fake Release and Dev sharing one data set
+func fakes(t *testing.T) (api.Environment, api.Environment) {
+	release := fakeapi.New(fakeapi.Options{})
+	rel := httptest.NewServer(release)
+	dev := httptest.NewServer(release.Share(fakeapi.Options{Dev: true}))
+	t.Cleanup(rel.Close)
+	t.Cleanup(dev.Close)
+	return api.Environment{Name: "Release", URL: rel.URL + fakeapi.BasePath},
+		api.Environment{Name: "Dev", URL: dev.URL + fakeapi.BasePath}
+}
+
+// fixtures captures a user and a game of the fake catalog
+func fixtures(t *testing.T, c *Client) {
+	_, err := c.Get("/users?limit=1").Task("api-3").Expect(200).Capture("user.uuid", "$.users[0].uuid", vars.UUID).Send()
+	require.NoError(t, err)
+	_, err = c.Get("/games?limit=1").Task("api-8").Expect(200).Capture("game.uuid", "$.games[0].uuid", vars.UUID).Send()
+	require.NoError(t, err)
+}
+
+func TestOrderPaymentChain(t *testing.T) {
+	relEnv, devEnv := fakes(t)
+	set := vars.New()
+	release, dev := New(relEnv, auth, set), New(devEnv, auth, set)
+	fixtures(t, release)
+
+	order := api.OrderCreateRequest{Items: []api.OrderItem{{ItemUUID: "{{game.uuid}}", Quantity: 2}}}
+	_, err := release.Post("/users/{{user.uuid}}/orders", order).Task("api-16").Expect(200).
+		Capture("order.uuid", "$.uuid", vars.UUID).
+		Capture("order.total", "$.total_price", vars.Integer).Send()
+	require.NoError(t, err)
+
+	payment := api.PaymentCreateRequest{OrderUUID: "{{order.uuid}}", PaymentMethod: "mir_pay"}
+	paid, err := release.Post("/users/{{user.uuid}}/payments", payment).Task("api-20").Expect(200).
+		Capture("payment.uuid", "$.uuid", vars.UUID).Send()
+	require.NoError(t, err)
+	total, _ := set.Lookup("Release", "order.total")
+	assert.Equal(t, total, paid.Object()["amount"])
+
+	// Dev reads the payment captured on Release
+	got, err := dev.Get("/payments/{{payment.uuid}}").Task("api-19").Expect(200).Send()
+	require.NoError(t, err)
+	uuid, _ := set.Lookup("Dev", "payment.uuid")
+	assert.Equal(t, "/payments/"+uuid.(string), got.Path)
+	_, err = got.Get("$.created_at")
+	assert.Error(t, err, "the fake Dev reproduces API-19")
+}
+
+func TestChainErrors(t *testing.T) {
+	relEnv, _ := fakes(t)
+	set := vars.New()
+	release := New(relEnv, auth, set)
+
+	_, err := release.Get("/payments/{{payment.uuid}}").Send()
+	assert.EqualError(t, err, "GET /payments/{{payment.uuid}} on Release: {{payment.uuid}} is not set: no fixture has that name and no earlier request captured it")
+
+	resp, err := release.Get("/users/nope").Task("api-3").Capture("user.uuid", "$.uuid", vars.UUID).Send()
+	var ce *vars.CaptureError
+	require.True(t, errors.As(err, &ce), "%v", err)
+	assert.Equal(t, 404, resp.Status)
+	_, err = release.Get("/users/{{user.uuid}}/cart").Send()
+	assert.ErrorContains(t, err, `{{user.uuid}} is not set: capture user.uuid from $.uuid on Release: $ has no key "uuid"`)
+
+	_, err = release.Get("/users/nope").Expect(200).Send()
+	assert.ErrorContains(t, err, "GET /users/nope on Release: status 404, want 200")
+
+	set.Set("query", "Portal 2")
+	resp, err = release.Get("/games/search?query={{query}}").Send()
+	require.NoError(t, err)
+	assert.Equal(t, "/games/search?query=Portal+2", resp.Path)
+}
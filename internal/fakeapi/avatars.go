@@ -0,0 +1,96 @@
+package fakeapi
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+
+	_ "golang.org/x/image/webp"
+)
+
+// maxAvatarBytes is the largest avatar upload accepted.
+const maxAvatarBytes = 5 << 20
+
+// avatarTypes maps the decoded image format to the extension it is stored under
+var avatarTypes = map[string]string{"png": ".png", "jpeg": ".jpg", "gif": ".gif", "webp": ".webp"}
+
+// avatar is an uploaded image as stored by the fake
+type avatar struct {
+	contentType string
+	data        []byte
+}
+
+func (s *account) uploadAvatar(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 2*maxAvatarBytes)
+	file, _, err := r.FormFile("avatar_file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "avatar_file is required: %v", err)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxAvatarBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "reading avatar_file: %v", err)
+		return
+	}
+
+	switch {
+	case len(data) == 0:
+		writeError(w, http.StatusBadRequest, "avatar_file is empty")
+		return
+	case len(data) > maxAvatarBytes:
+		writeError(w, http.StatusRequestEntityTooLarge, "avatar_file is larger than 5 MB")
+		return
+	}
+	// the content decides, whatever the file name says
+	_, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil || avatarTypes[format] == "" {
+		writeError(w, http.StatusBadRequest, "avatar_file is not a supported image")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findUser(r.PathValue("uuid"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	name := newUUID() + avatarTypes[format]
+	s.avatars[name] = &avatar{contentType: "image/" + format, data: data}
+
+	u := *s.users[i]
+	u.AvatarURL = "http://" + r.Host + BasePath + "/avatars/" + name
+	// API-11: Dev answers with the new avatar but never stores it on the user
+	if !s.opts.Dev {
+		s.users[i].AvatarURL = u.AvatarURL
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// serveAvatar writes an uploaded file, whichever account it belongs to
+func (s *Server) serveAvatar(w http.ResponseWriter, name string) {
+	s.registry.mu.Lock()
+	stores := make([]*store, 0, len(s.registry.stores))
+	for _, st := range s.registry.stores {
+		stores = append(stores, st)
+	}
+	s.registry.mu.Unlock()
+
+	for _, st := range stores {
+		st.mu.Lock()
+		a := st.avatars[name]
+		st.mu.Unlock()
+		if a != nil {
+			w.Header().Set("Content-Type", a.contentType)
+			w.Write(a.data)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "avatar not found")
+}
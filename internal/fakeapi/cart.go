@@ -0,0 +1,175 @@
+package fakeapi
+
+import "net/http"
+
+// quantity bounds for cart and order items
+const (
+	minQuantity = 1
+	maxQuantity = 100
+)
+
+// cartItem is one line of a cart or an order
+type cartItem struct {
+	ItemUUID   string `json:"item_uuid"`
+	Quantity   int    `json:"quantity"`
+	TotalPrice int    `json:"total_price"`
+}
+
+// cartResponse mirrors the cart payload of the real API
+type cartResponse struct {
+	Items      []cartItem `json:"items"`
+	TotalPrice int        `json:"total_price"`
+	UserUUID   string     `json:"user_uuid"`
+}
+
+// itemRequest is the body of the cart mutation endpoints
+type itemRequest struct {
+	ItemUUID string `json:"item_uuid"`
+	Quantity int    `json:"quantity"`
+}
+
+// requireUser writes a 404 when the user does not exist. Callers hold s.mu.
+func (s *store) requireUser(w http.ResponseWriter, uuid string) bool {
+	if s.findUser(uuid) < 0 {
+		writeError(w, http.StatusNotFound, "user not found")
+		return false
+	}
+	return true
+}
+
+// validQuantity writes a 400 when n is outside the accepted bounds.
+func validQuantity(w http.ResponseWriter, n int) bool {
+	if n < minQuantity || n > maxQuantity {
+		writeError(w, http.StatusBadRequest, "quantity must be between 1 and 100")
+		return false
+	}
+	return true
+}
+
+// cart prices the user's cart lines against the catalog. Callers hold s.mu.
+func (s *store) cart(userUUID string) cartResponse {
+	resp := cartResponse{Items: []cartItem{}, UserUUID: userUUID}
+	for _, item := range s.carts[userUUID] {
+		if g := s.findGame(item.ItemUUID); g != nil {
+			item.TotalPrice = g.Price * item.Quantity
+		}
+		resp.Items = append(resp.Items, *item)
+		resp.TotalPrice += item.TotalPrice
+	}
+	return resp
+}
+
+// cartLine returns the index of the item in the user's cart, -1 if absent. Callers hold s.mu.
+func (s *store) cartLine(userUUID, itemUUID string) int {
+	for i, item := range s.carts[userUUID] {
+		if item.ItemUUID == itemUUID {
+			return i
+		}
+	}
+	return -1
+}
+
+// lockItem decodes an item body, locks the store and checks the user and item.
+// On success the caller owns s.mu and must unlock it.
+func (s *account) lockItem(w http.ResponseWriter, r *http.Request) (string, itemRequest, bool) {
+	var req itemRequest
+	if !decodeBody(w, r, &req) {
+		return "", req, false
+	}
+	userUUID := r.PathValue("uuid")
+
+	s.mu.Lock()
+	if !s.requireUser(w, userUUID) || s.lookupGame(w, req.ItemUUID) == nil {
+		s.mu.Unlock()
+		return "", req, false
+	}
+	return userUUID, req, true
+}
+
+func (s *account) getCart(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userUUID := r.PathValue("uuid")
+	if s.requireUser(w, userUUID) {
+		writeJSON(w, http.StatusOK, s.cart(userUUID))
+	}
+}
+
+func (s *account) addToCart(w http.ResponseWriter, r *http.Request) {
+	userUUID, req, ok := s.lockItem(w, r)
+	if !ok {
+		return
+	}
+	defer s.mu.Unlock()
+
+	if !validQuantity(w, req.Quantity) {
+		return
+	}
+	if i := s.cartLine(userUUID, req.ItemUUID); i >= 0 {
+		line := s.carts[userUUID][i]
+		if !validQuantity(w, line.Quantity+req.Quantity) {
+			return
+		}
+		line.Quantity += req.Quantity
+	} else {
+		s.carts[userUUID] = append(s.carts[userUUID], &cartItem{ItemUUID: req.ItemUUID, Quantity: req.Quantity})
+	}
+	writeJSON(w, http.StatusOK, s.cart(userUUID))
+}
+
+func (s *account) changeCartItem(w http.ResponseWriter, r *http.Request) {
+	userUUID, req, ok := s.lockItem(w, r)
+	if !ok {
+		return
+	}
+	defer s.mu.Unlock()
+
+	if !validQuantity(w, req.Quantity) {
+		return
+	}
+	i := s.cartLine(userUUID, req.ItemUUID)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "item is not in the cart")
+		return
+	}
+	s.carts[userUUID][i].Quantity = req.Quantity
+	writeJSON(w, http.StatusOK, s.cart(userUUID))
+}
+
+func (s *account) removeCartItem(w http.ResponseWriter, r *http.Request) {
+	userUUID, req, ok := s.lockItem(w, r)
+	if !ok {
+		return
+	}
+	defer s.mu.Unlock()
+
+	i := s.cartLine(userUUID, req.ItemUUID)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "item is not in the cart")
+		return
+	}
+	// API-14: Dev empties the whole cart instead of removing one item
+	if s.opts.Dev {
+		delete(s.carts, userUUID)
+	} else {
+		lines := s.carts[userUUID]
+		s.carts[userUUID] = append(lines[:i:i], lines[i+1:]...)
+	}
+	writeJSON(w, http.StatusOK, s.cart(userUUID))
+}
+
+func (s *account) clearCart(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userUUID := r.PathValue("uuid")
+	if !s.requireUser(w, userUUID) {
+		return
+	}
+	// API-15: Dev answers 200 but keeps the items
+	if !s.opts.Dev {
+		delete(s.carts, userUUID)
+	}
+	writeJSON(w, http.StatusOK, s.cart(userUUID))
+}
@@ -0,0 +1,158 @@
+package fakeapi
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// game as listed in the catalog
+type game struct {
+	UUID          string   `json:"uuid"`
+	Title         string   `json:"title"`
+	Price         int      `json:"price"`
+	CategoryUUIDs []string `json:"category_uuids"`
+}
+
+// category of games
+type category struct {
+	UUID  string `json:"uuid"`
+	Title string `json:"title"`
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// validUUID reports whether s is a canonical lower-case UUID.
+func validUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// findGame returns the game with the UUID, nil if absent. Callers hold s.mu.
+func (s *store) findGame(uuid string) *game {
+	for _, g := range s.games {
+		if g.UUID == uuid {
+			return g
+		}
+	}
+	return nil
+}
+
+// lookupGame validates an item UUID and resolves it, writing a 400 or 404 on failure. Callers hold s.mu.
+func (s *store) lookupGame(w http.ResponseWriter, uuid string) *game {
+	if !validUUID(uuid) {
+		writeError(w, http.StatusBadRequest, "item_uuid must be a UUID")
+		return nil
+	}
+	g := s.findGame(uuid)
+	if g == nil {
+		writeError(w, http.StatusNotFound, "item not found")
+	}
+	return g
+}
+
+// writeGames writes one page of games with the total count.
+func writeGames(w http.ResponseWriter, p page, games []*game) {
+	start, end := p.window(len(games))
+	writeJSON(w, http.StatusOK, struct {
+		Meta  listMeta `json:"meta"`
+		Games []*game  `json:"games"`
+	}{listMeta{Total: len(games)}, games[start:end]})
+}
+
+func (s *account) listGames(w http.ResponseWriter, r *http.Request) {
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeGames(w, p, s.games)
+}
+
+func (s *account) getGame(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if g := s.lookupGame(w, r.PathValue("uuid")); g != nil {
+		writeJSON(w, http.StatusOK, g)
+	}
+}
+
+func (s *account) searchGames(w http.ResponseWriter, r *http.Request) {
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("query")))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found []*game
+	for _, g := range s.games {
+		// API-2: Dev ignores the query and returns every game
+		if s.opts.Dev || strings.Contains(strings.ToLower(g.Title), query) {
+			found = append(found, g)
+		}
+	}
+	writeGames(w, p, found)
+}
+
+func (s *account) listCategories(w http.ResponseWriter, r *http.Request) {
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start, end := p.window(len(s.categories))
+	writeJSON(w, http.StatusOK, struct {
+		Meta       listMeta    `json:"meta"`
+		Categories []*category `json:"categories"`
+	}{listMeta{Total: len(s.categories)}, s.categories[start:end]})
+}
+
+func (s *account) categoryGames(w http.ResponseWriter, r *http.Request) {
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	uuid := r.PathValue("uuid")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	known := -1
+	for i, c := range s.categories {
+		if c.UUID == uuid {
+			known = i
+		}
+	}
+	if known < 0 {
+		writeError(w, http.StatusNotFound, "category not found")
+		return
+	}
+	// API-10: Dev filters by the category listed after the requested one
+	if s.opts.Dev {
+		uuid = s.categories[(known+1)%len(s.categories)].UUID
+	}
+
+	var games []*game
+	for _, g := range s.games {
+		for _, c := range g.CategoryUUIDs {
+			if c == uuid {
+				games = append(games, g)
+				break
+			}
+		}
+	}
+	writeGames(w, p, games)
+}
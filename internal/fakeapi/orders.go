@@ -0,0 +1,164 @@
+package fakeapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// order statuses
+const (
+	statusOpen      = "open"
+	statusPaid      = "paid"
+	statusDelivered = "delivered"
+	statusCanceled  = "canceled"
+)
+
+// transitions lists the statuses an order may move to through PATCH /orders/{uuid}/status.
+// Payments move an order from open to paid.
+var transitions = map[string][]string{
+	statusOpen: {statusCanceled},
+	statusPaid: {statusDelivered},
+}
+
+// order as stored and returned by the fake
+type order struct {
+	UUID       string     `json:"uuid"`
+	UserUUID   string     `json:"user_uuid"`
+	Items      []cartItem `json:"items"`
+	TotalPrice int        `json:"total_price"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// findOrder returns the order with the UUID, nil if absent. Callers hold s.mu.
+func (s *store) findOrder(uuid string) *order {
+	for _, o := range s.orders {
+		if o.UUID == uuid {
+			return o
+		}
+	}
+	return nil
+}
+
+func (s *account) createOrder(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Items []itemRequest `json:"items"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if len(req.Items) == 0 {
+		writeError(w, http.StatusBadRequest, "items must not be empty")
+		return
+	}
+	userUUID := r.PathValue("uuid")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(w, userUUID) {
+		return
+	}
+
+	o := &order{UUID: newUUID(), UserUUID: userUUID, Status: statusOpen, CreatedAt: time.Now().UTC()}
+	o.UpdatedAt = o.CreatedAt
+	seen := map[string]bool{}
+	for _, item := range req.Items {
+		g := s.lookupGame(w, item.ItemUUID)
+		if g == nil || !validQuantity(w, item.Quantity) {
+			return
+		}
+		// API-16: Dev accepts the same item twice in one order
+		if seen[item.ItemUUID] && !s.opts.Dev {
+			writeError(w, http.StatusBadRequest, "duplicate item %s", item.ItemUUID)
+			return
+		}
+		seen[item.ItemUUID] = true
+		line := cartItem{ItemUUID: g.UUID, Quantity: item.Quantity, TotalPrice: g.Price * item.Quantity}
+		o.Items = append(o.Items, line)
+		o.TotalPrice += line.TotalPrice
+	}
+
+	s.orders = append(s.orders, o)
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *account) listOrders(w http.ResponseWriter, r *http.Request) {
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	userUUID := r.PathValue("uuid")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(w, userUUID) {
+		return
+	}
+	orders := []*order{}
+	for _, o := range s.orders {
+		if o.UserUUID == userUUID {
+			orders = append(orders, o)
+		}
+	}
+	start, end := p.window(len(orders))
+	writeJSON(w, http.StatusOK, struct {
+		Meta   listMeta `json:"meta"`
+		Orders []*order `json:"orders"`
+	}{listMeta{Total: len(orders)}, orders[start:end]})
+}
+
+func (s *account) getOrder(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.findOrder(r.PathValue("uuid"))
+	if o == nil {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *account) updateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status string `json:"status"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	switch req.Status {
+	case statusOpen, statusPaid, statusDelivered, statusCanceled:
+	default:
+		writeError(w, http.StatusBadRequest, "unknown status %q", req.Status)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.findOrder(r.PathValue("uuid"))
+	if o == nil {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+
+	allowed := false
+	for _, next := range transitions[o.Status] {
+		allowed = allowed || next == req.Status
+	}
+	// API-18: Dev refuses to cancel open orders
+	if s.opts.Dev && o.Status == statusOpen && req.Status == statusCanceled {
+		allowed = false
+	}
+	if !allowed {
+		writeError(w, http.StatusUnprocessableEntity, "cannot change status from %s to %s", o.Status, req.Status)
+		return
+	}
+
+	o.Status = req.Status
+	o.UpdatedAt = time.Now().UTC()
+	writeJSON(w, http.StatusOK, o)
+}
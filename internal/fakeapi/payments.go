@@ -0,0 +1,95 @@
+package fakeapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// paymentMethods are the methods accepted by POST /users/{uuid}/payments
+var paymentMethods = map[string]bool{"card": true, "sbp": true, "mir_pay": true}
+
+// payment as stored and returned by the fake
+type payment struct {
+	UUID          string    `json:"uuid"`
+	UserUUID      string    `json:"user_uuid"`
+	OrderUUID     string    `json:"order_uuid"`
+	PaymentMethod string    `json:"payment_method"`
+	Amount        int       `json:"amount"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (s *account) createPayment(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OrderUUID     string `json:"order_uuid"`
+		PaymentMethod string `json:"payment_method"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if !paymentMethods[req.PaymentMethod] {
+		writeError(w, http.StatusBadRequest, "unsupported payment method %q", req.PaymentMethod)
+		return
+	}
+	userUUID := r.PathValue("uuid")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.requireUser(w, userUUID) {
+		return
+	}
+	o := s.findOrder(req.OrderUUID)
+	if o == nil || o.UserUUID != userUUID {
+		writeError(w, http.StatusNotFound, "order not found")
+		return
+	}
+	if o.Status != statusOpen {
+		writeError(w, http.StatusUnprocessableEntity, "cannot pay an order in status %s", o.Status)
+		return
+	}
+
+	now := time.Now().UTC()
+	p := &payment{
+		UUID:          newUUID(),
+		UserUUID:      userUUID,
+		OrderUUID:     o.UUID,
+		PaymentMethod: req.PaymentMethod,
+		Amount:        o.TotalPrice,
+		Status:        "completed",
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.payments = append(s.payments, p)
+	o.Status = statusPaid
+	o.UpdatedAt = now
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (s *account) getPayment(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uuid := r.PathValue("uuid")
+	for _, p := range s.payments {
+		if p.UUID != uuid {
+			continue
+		}
+		// API-19: Dev leaves out created_at and updated_at
+		if s.opts.Dev {
+			writeJSON(w, http.StatusOK, struct {
+				UUID          string `json:"uuid"`
+				UserUUID      string `json:"user_uuid"`
+				OrderUUID     string `json:"order_uuid"`
+				PaymentMethod string `json:"payment_method"`
+				Amount        int    `json:"amount"`
+				Status        string `json:"status"`
+			}{p.UUID, p.UserUUID, p.OrderUUID, p.PaymentMethod, p.Amount, p.Status})
+			return
+		}
+		writeJSON(w, http.StatusOK, p)
+		return
+	}
+	writeError(w, http.StatusNotFound, "payment not found")
+}
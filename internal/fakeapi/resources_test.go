@@ -0,0 +1,276 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/avatarkit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testUser  = "00000000-0000-4000-8000-000000000001"
+	testGame1 = "00000000-0000-4000-8001-000000000001"
+	testGame2 = "00000000-0000-4000-8001-000000000002"
+)
+
+func TestCatalog(t *testing.T) {
+	s := New(Options{})
+
+	code, body := do(t, s, "GET", "/games?limit=100", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(len(seedGames)), body["meta"].(map[string]interface{})["total"])
+
+	code, body = do(t, s, "GET", "/games/search?query=PORTAL", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["games"], 1)
+	_, body = do(t, s, "GET", "/games/search?query="+url.QueryEscape("ŌKAMI"), nil)
+	assert.Len(t, body["games"], 1, "case folding covers non-ASCII titles")
+	_, body = do(t, s.Share(Options{Dev: true}), "GET", "/games/search?query=PORTAL", nil)
+	assert.Equal(t, float64(len(seedGames)), body["meta"].(map[string]interface{})["total"], "API-2: Dev ignores the query")
+
+	code, _ = do(t, s, "GET", "/games/"+testGame1, nil)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do(t, s, "GET", "/games/not-a-uuid", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	_, body = do(t, s, "GET", "/categories", nil)
+	first := body["categories"].([]interface{})[0].(map[string]interface{})["uuid"].(string)
+	code, body = do(t, s, "GET", "/categories/"+first+"/games", nil)
+	assert.Equal(t, http.StatusOK, code)
+	for _, g := range body["games"].([]interface{}) {
+		assert.Contains(t, g.(map[string]interface{})["category_uuids"], first)
+	}
+	code, _ = do(t, s, "GET", "/categories/3fa85f64-5717-4562-b3fc-2c963f66afa6/games", nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	_, body = do(t, s.Share(Options{Dev: true}), "GET", "/categories/"+first+"/games", nil)
+	mismatched := 0
+	for _, g := range body["games"].([]interface{}) {
+		if !containsString(g.(map[string]interface{})["category_uuids"], first) {
+			mismatched++
+		}
+	}
+	assert.NotZero(t, mismatched, "API-10: Dev filters by another category")
+}
+
+func TestCart(t *testing.T) {
+	s := New(Options{})
+	cart := "/users/" + testUser + "/cart"
+
+	code, body := do(t, s, "POST", cart+"/add", itemRequest{ItemUUID: testGame1, Quantity: 2})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2*2999), body["total_price"])
+
+	do(t, s, "POST", cart+"/add", itemRequest{ItemUUID: testGame2, Quantity: 1})
+	code, body = do(t, s, "POST", cart+"/change", itemRequest{ItemUUID: testGame1, Quantity: 1})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2999+999), body["total_price"])
+
+	code, body = do(t, s, "POST", cart+"/remove", itemRequest{ItemUUID: testGame2})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["items"], 1)
+
+	code, _ = do(t, s, "POST", cart+"/add", itemRequest{ItemUUID: testGame1, Quantity: 0})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, body = do(t, s, "POST", cart+"/clear", struct{}{})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(0), body["total_price"])
+	assert.Len(t, body["items"], 0)
+}
+
+func TestWishlist(t *testing.T) {
+	s := New(Options{})
+	wishlist := "/users/" + testUser + "/wishlist"
+
+	_, body := do(t, s, "GET", "/games?limit=100", nil)
+	games := body["games"].([]interface{})
+	for i := 0; i < maxWishlistItems; i++ {
+		code, _ := do(t, s, "POST", wishlist+"/add", itemRequest{ItemUUID: games[i].(map[string]interface{})["uuid"].(string)})
+		assert.Equal(t, http.StatusOK, code)
+	}
+	code, _ := do(t, s, "POST", wishlist+"/add", itemRequest{ItemUUID: games[maxWishlistItems].(map[string]interface{})["uuid"].(string)})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	code, _ = do(t, s, "POST", wishlist+"/remove", itemRequest{ItemUUID: testGame1})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do(t, s, "POST", wishlist+"/remove", itemRequest{ItemUUID: testGame1})
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestDevWishlistLimit(t *testing.T) {
+	dev := New(Options{Dev: true})
+	wishlist := "/users/" + testUser + "/wishlist"
+
+	_, body := do(t, dev, "GET", "/games?limit=100", nil)
+	games := body["games"].([]interface{})
+	for i := 0; i < maxWishlistItems-1; i++ {
+		code, _ := do(t, dev, "POST", wishlist+"/add", itemRequest{ItemUUID: games[i].(map[string]interface{})["uuid"].(string)})
+		assert.Equal(t, http.StatusOK, code)
+	}
+	code, _ := do(t, dev, "POST", wishlist+"/add", itemRequest{ItemUUID: games[maxWishlistItems-1].(map[string]interface{})["uuid"].(string)})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+}
+
+func TestDevWishlistRemove(t *testing.T) {
+	dev := New(Options{Dev: true})
+	wishlist := "/users/" + testUser + "/wishlist"
+
+	do(t, dev, "POST", wishlist+"/add", itemRequest{ItemUUID: testGame1})
+	for i := 0; i < 2; i++ {
+		code, body := do(t, dev, "POST", wishlist+"/remove", itemRequest{ItemUUID: testGame1})
+		assert.Equal(t, http.StatusOK, code, "API-8: remove answers 200 every time")
+		assert.Len(t, body["items"], 1, "API-8: remove keeps the item")
+	}
+}
+
+func TestOrders(t *testing.T) {
+	release := New(Options{})
+	dev := release.Share(Options{Dev: true})
+	orders := "/users/" + testUser + "/orders"
+	duplicate := map[string]interface{}{"items": []itemRequest{{ItemUUID: testGame1, Quantity: 2}, {ItemUUID: testGame1, Quantity: 1}}}
+
+	code, _ := do(t, release, "POST", orders, duplicate)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = do(t, dev, "POST", orders, duplicate)
+	assert.Equal(t, http.StatusOK, code)
+
+	single := map[string]interface{}{"items": []itemRequest{{ItemUUID: testGame2, Quantity: 3}}}
+	code, body := do(t, release, "POST", orders, single)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "open", body["status"])
+	assert.Equal(t, float64(3*999), body["total_price"])
+	status := "/orders/" + body["uuid"].(string) + "/status"
+
+	code, _ = do(t, dev, "PATCH", status, map[string]string{"status": "canceled"})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+	code, _ = do(t, release, "PATCH", status, map[string]string{"status": "canceled"})
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = do(t, release, "PATCH", status, map[string]string{"status": "open"})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	code, body = do(t, release, "GET", orders+"?offset=1&limit=1", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["orders"], 1)
+}
+
+func TestDevCartBugs(t *testing.T) {
+	dev := New(Options{Dev: true})
+	cart := "/users/" + testUser + "/cart"
+
+	do(t, dev, "POST", cart+"/add", itemRequest{ItemUUID: testGame1, Quantity: 1})
+	do(t, dev, "POST", cart+"/add", itemRequest{ItemUUID: testGame2, Quantity: 1})
+	_, body := do(t, dev, "POST", cart+"/remove", itemRequest{ItemUUID: testGame2})
+	assert.Len(t, body["items"], 0, "API-14: remove empties the cart")
+
+	do(t, dev, "POST", cart+"/add", itemRequest{ItemUUID: testGame1, Quantity: 1})
+	code, body := do(t, dev, "POST", cart+"/clear", struct{}{})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["items"], 1, "API-15: clear keeps the items")
+}
+
+func TestPayments(t *testing.T) {
+	release := New(Options{})
+	dev := release.Share(Options{Dev: true})
+	payments := "/users/" + testUser + "/payments"
+
+	_, order := do(t, release, "POST", "/users/"+testUser+"/orders", map[string]interface{}{"items": []itemRequest{{ItemUUID: testGame2, Quantity: 2}}})
+	orderUUID := order["uuid"].(string)
+
+	code, _ := do(t, release, "POST", payments, map[string]string{"order_uuid": orderUUID, "payment_method": "cash"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, body := do(t, release, "POST", payments, map[string]string{"order_uuid": orderUUID, "payment_method": "card"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2*999), body["amount"])
+	paymentUUID := body["uuid"].(string)
+
+	_, order = do(t, release, "GET", "/orders/"+orderUUID, nil)
+	assert.Equal(t, "paid", order["status"])
+	code, _ = do(t, release, "POST", payments, map[string]string{"order_uuid": orderUUID, "payment_method": "card"})
+	assert.Equal(t, http.StatusUnprocessableEntity, code)
+
+	_, body = do(t, release, "GET", "/payments/"+paymentUUID, nil)
+	assert.Contains(t, body, "created_at")
+	_, body = do(t, dev, "GET", "/payments/"+paymentUUID, nil)
+	assert.NotContains(t, body, "created_at", "API-19: Dev leaves out the timestamps")
+	assert.Equal(t, paymentUUID, body["uuid"])
+}
+
+func containsString(list interface{}, s string) bool {
+	items, _ := list.([]interface{})
+	for _, item := range items {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// upload sends data as the avatar_file of a multipart PUT
+func upload(t *testing.T, h http.Handler, path, filename string, data []byte) (int, map[string]interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("avatar_file", filename)
+	require.NoError(t, err)
+	part.Write(data)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest("PUT", BasePath+path, &buf)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var out map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &out)
+	return rec.Code, out
+}
+
+func TestAvatarUpload(t *testing.T) {
+	release := New(Options{})
+	dev := release.Share(Options{Dev: true})
+	path := "/users/" + testUser + "/avatar"
+
+	cases, err := avatarkit.Cases()
+	require.NoError(t, err)
+	for _, c := range cases {
+		code, _ := upload(t, release, path, c.Filename, c.Data)
+		switch {
+		case c.Valid, c.Image != nil && len(c.Data) <= maxAvatarBytes:
+			assert.Equal(t, http.StatusOK, code, c.Name)
+		case len(c.Data) > maxAvatarBytes:
+			assert.Equal(t, http.StatusRequestEntityTooLarge, code, c.Name)
+		default:
+			assert.Equal(t, http.StatusBadRequest, code, c.Name)
+		}
+	}
+
+	img, err := avatarkit.Generate("png", 8, 8)
+	require.NoError(t, err)
+	_, body := upload(t, release, path, img.Filename, img.Data)
+	_, user := do(t, release, "GET", "/users/"+testUser, nil)
+	assert.Equal(t, body["avatar_url"], user["avatar_url"])
+
+	served := httptest.NewRequest("GET", body["avatar_url"].(string), nil)
+	rec := httptest.NewRecorder()
+	release.ServeHTTP(rec, served)
+	assert.Equal(t, http.StatusOK, rec.Code, "avatars are served without a token")
+	assert.Equal(t, "image/png", rec.Header().Get("Content-Type"))
+	assert.Equal(t, img.Data, rec.Body.Bytes())
+
+	_, body = upload(t, dev, path, img.Filename, img.Data)
+	_, after := do(t, dev, "GET", "/users/"+testUser, nil)
+	assert.NotEqual(t, body["avatar_url"], after["avatar_url"], "API-11: Dev does not store the avatar")
+	assert.Equal(t, user["avatar_url"], after["avatar_url"])
+}
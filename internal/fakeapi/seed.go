@@ -0,0 +1,87 @@
+package fakeapi
+
+import "fmt"
+
+// defaultAvatarURL is assigned to users that never uploaded an avatar
+const defaultAvatarURL = "https://gravatar.com/avatar/00000000000000000000000000000000"
+
+// seedPassword is the password of every seeded user
+const seedPassword = "password"
+
+var seedNames = []string{
+	"Alice", "Bob", "Carol", "Dave", "Erin", "Frank",
+	"Grace", "Heidi", "Ivan", "Judy", "Mallory",
+}
+
+// seedUsers returns the 11 users the real API starts with after /setup.
+func seedUsers() []*user {
+	users := make([]*user, 0, len(seedNames))
+	for i, name := range seedNames {
+		users = append(users, &user{
+			UUID:      fmt.Sprintf("00000000-0000-4000-8000-%012d", i+1),
+			Email:     fmt.Sprintf("user%d@example.com", i+1),
+			Password:  seedPassword,
+			Name:      name,
+			Nickname:  fmt.Sprintf("user%d", i+1),
+			AvatarURL: defaultAvatarURL,
+		})
+	}
+	return users
+}
+
+// seedCategoryTitles and seedGames describe the catalog: title, price in cents
+// and the indexes of the categories a game belongs to.
+var seedCategoryTitles = []string{"Action", "Adventure", "Puzzle", "Strategy", "Indie"}
+
+var seedGames = []struct {
+	title      string
+	price      int
+	categories []int
+}{
+	{"The Witcher 3: Wild Hunt", 2999, []int{0, 1}},
+	{"Portal 2", 999, []int{2}},
+	{"Civilization VI", 5999, []int{3}},
+	{"Hollow Knight", 1499, []int{0, 4}},
+	{"Stardew Valley", 1499, []int{4}},
+	{"Red Dead Redemption 2", 5999, []int{0, 1}},
+	{"The Talos Principle", 1999, []int{2}},
+	{"Into the Breach", 1499, []int{3, 4}},
+	{"Celeste", 1999, []int{0, 4}},
+	{"Outer Wilds", 2499, []int{1, 2}},
+	{"XCOM 2", 4999, []int{3}},
+	{"Baba Is You", 1499, []int{2, 4}},
+	{"Return of the Obra Dinn", 1999, []int{1, 2}},
+	{"Dark Souls III", 5999, []int{0}},
+	{"Age of Empires II", 1999, []int{3}},
+	{"Hades", 2499, []int{0, 4}},
+	{"Grim Fandango Remastered", 1499, []int{1}},
+	{"The Witness", 3999, []int{2}},
+	{"Slay the Spire", 2499, []int{3, 4}},
+	{"Ōkami HD", 1999, []int{0, 1}},
+}
+
+// seedCatalog returns the games and categories of the initial data set.
+func seedCatalog() ([]*game, []*category) {
+	categories := make([]*category, 0, len(seedCategoryTitles))
+	for i, title := range seedCategoryTitles {
+		categories = append(categories, &category{
+			UUID:  fmt.Sprintf("00000000-0000-4000-8002-%012d", i+1),
+			Title: title,
+		})
+	}
+
+	games := make([]*game, 0, len(seedGames))
+	for i, g := range seedGames {
+		uuids := make([]string, 0, len(g.categories))
+		for _, c := range g.categories {
+			uuids = append(uuids, categories[c].UUID)
+		}
+		games = append(games, &game{
+			UUID:          fmt.Sprintf("00000000-0000-4000-8001-%012d", i+1),
+			Title:         g.title,
+			Price:         g.price,
+			CategoryUUIDs: uuids,
+		})
+	}
+	return games, categories
+}
@@ -0,0 +1,281 @@
+// Package fakeapi is an in-memory stand-in for the QA playground game store
+// API. It serves the same paths and payloads under /api/v1 so the suite and
+// its tooling can run without network access.
+//
+// A server created with Options.Dev reproduces a subset of the documented Dev
+// bugs, so cross-environment checks can be exercised locally as well:
+//
+//	API-2   search ignores the query
+//	API-4   a user update may take another user's email
+//	API-5   the wishlist is full one item before the limit
+//	API-8   wishlist remove answers 200 and keeps the item
+//	API-10  games of the category listed after the requested one
+//	API-11  an avatar upload is not stored on the user
+//	API-14  cart remove empties the whole cart
+//	API-15  cart clear answers 200 and keeps the items
+//	API-16  an order may hold the same item twice
+//	API-18  open orders cannot be canceled
+//	API-19  payments lack created_at and updated_at
+//	API-22  a duplicate user is a 500 instead of a 409
+//
+// Other Dev bugs are not modelled, so baseline tests for them behave on the
+// fake as they would on Release. Like the real deployments, a Release and a
+// Dev server can share one data set, see Share.
+package fakeapi
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BasePath is the prefix every route is served under.
+const BasePath = "/api/v1"
+
+// Options configures a fake server.
+type Options struct {
+	// Dev makes the server reproduce the Dev bugs listed in the package doc.
+	Dev bool
+	// Latency delays every response, to stand in for a slow deployment.
+	Latency time.Duration
+}
+
+// Server is an http.Handler serving the fake API. Like the real API, every
+// bearer token "<secret>:<email>" owns a separate data set, seeded on first use.
+type Server struct {
+	opts     Options
+	registry *registry
+	mu       sync.Mutex
+	accounts map[string]*account // by token
+}
+
+// registry holds the data set of every token, shared by servers created with Share
+type registry struct {
+	mu     sync.Mutex
+	stores map[string]*store
+}
+
+// store returns the data set of a token, seeding it on first use
+func (r *registry) store(token string) *store {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.stores[token]
+	if st == nil {
+		st = &store{}
+		st.reset()
+		r.stores[token] = st
+	}
+	return st
+}
+
+// account serves the routes for one token's data set
+type account struct {
+	opts Options
+	mux  *http.ServeMux
+	*store
+}
+
+// store is the data set behind one token
+type store struct {
+	mu         sync.Mutex
+	users      []*user
+	games      []*game
+	categories []*category
+	carts      map[string][]*cartItem // by user UUID
+	wishlists  map[string][]string    // game UUIDs by user UUID
+	orders     []*order
+	payments   []*payment
+	avatars    map[string]*avatar // by file name
+}
+
+// New returns a server whose accounts start from the initial data set.
+func New(opts Options) *Server {
+	return &Server{opts: opts, registry: &registry{stores: map[string]*store{}}, accounts: map[string]*account{}}
+}
+
+// Share returns a server with different options backed by the same data.
+func (s *Server) Share(opts Options) *Server {
+	return &Server{opts: opts, registry: s.registry, accounts: map[string]*account{}}
+}
+
+// account returns the handler for a token
+func (s *Server) account(token string) *account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.accounts[token]
+	if a == nil {
+		a = &account{opts: s.opts, mux: http.NewServeMux(), store: s.registry.store(token)}
+		a.routes()
+		s.accounts[token] = a
+	}
+	return a
+}
+
+func (s *account) routes() {
+	s.handle("POST /setup", s.setup)
+
+	s.handle("GET /users", s.listUsers)
+	s.handle("POST /users", s.createUser)
+	s.handle("POST /users/login", s.login)
+	s.handle("GET /users/{uuid}", s.getUser)
+	s.handle("PATCH /users/{uuid}", s.updateUser)
+	s.handle("DELETE /users/{uuid}", s.deleteUser)
+	s.handle("PUT /users/{uuid}/avatar", s.uploadAvatar)
+
+	s.handle("GET /games", s.listGames)
+	s.handle("GET /games/search", s.searchGames)
+	s.handle("GET /games/{uuid}", s.getGame)
+	s.handle("GET /categories", s.listCategories)
+	s.handle("GET /categories/{uuid}/games", s.categoryGames)
+
+	s.handle("GET /users/{uuid}/wishlist", s.getWishlist)
+	s.handle("POST /users/{uuid}/wishlist/add", s.addToWishlist)
+	s.handle("POST /users/{uuid}/wishlist/remove", s.removeFromWishlist)
+
+	s.handle("GET /users/{uuid}/cart", s.getCart)
+	s.handle("POST /users/{uuid}/cart/add", s.addToCart)
+	s.handle("POST /users/{uuid}/cart/change", s.changeCartItem)
+	s.handle("POST /users/{uuid}/cart/remove", s.removeCartItem)
+	s.handle("POST /users/{uuid}/cart/clear", s.clearCart)
+
+	s.handle("GET /users/{uuid}/orders", s.listOrders)
+	s.handle("POST /users/{uuid}/orders", s.createOrder)
+	s.handle("GET /orders/{uuid}", s.getOrder)
+	s.handle("PATCH /orders/{uuid}/status", s.updateOrderStatus)
+
+	s.handle("POST /users/{uuid}/payments", s.createPayment)
+	s.handle("GET /payments/{uuid}", s.getPayment)
+}
+
+func (s *account) handle(pattern string, h http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.HandleFunc(method+" "+BasePath+path, h)
+}
+
+// ServeHTTP checks the bearer token and dispatches to the token's account.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.opts.Latency > 0 {
+		time.Sleep(s.opts.Latency)
+	}
+	// avatar_url points at public files, like the CDN of the real API
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, BasePath+"/avatars/") {
+		s.serveAvatar(w, strings.TrimPrefix(r.URL.Path, BasePath+"/avatars/"))
+		return
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !validToken(token) {
+		writeError(w, http.StatusUnauthorized, "missing or malformed bearer token")
+		return
+	}
+	s.account(token).mux.ServeHTTP(w, r)
+}
+
+// validToken checks the "<secret>:<email>" shape of a bearer token
+func validToken(token string) bool {
+	secret, email, ok := strings.Cut(token, ":")
+	if !ok || secret == "" || strings.ContainsAny(secret, " \t") {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// reset restores the seed data, it is what POST /setup does.
+func (s *store) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = seedUsers()
+	s.games, s.categories = seedCatalog()
+	s.carts = map[string][]*cartItem{}
+	s.wishlists = map[string][]string{}
+	s.orders = nil
+	s.payments = nil
+	s.avatars = map[string]*avatar{}
+}
+
+func (s *account) setup(w http.ResponseWriter, r *http.Request) {
+	s.reset()
+	w.WriteHeader(http.StatusResetContent)
+}
+
+// errorBody mirrors the error payload of the real API
+type errorBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...interface{}) {
+	writeJSON(w, status, errorBody{Code: status, Message: fmt.Sprintf(format, args...)})
+}
+
+// decodeBody reads a JSON request body, reporting a 400 when it is invalid.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: %v", err)
+		return false
+	}
+	return true
+}
+
+// page holds validated offset/limit query parameters
+type page struct {
+	offset int
+	limit  int
+}
+
+// maxLimit caps the page size, larger limits are clamped to it.
+const maxLimit = 100
+
+// parsePage reads offset and limit, defaulting to the first 10 items.
+func parsePage(w http.ResponseWriter, r *http.Request) (page, bool) {
+	p := page{offset: 0, limit: 10}
+	for name, dst := range map[string]*int{"offset": &p.offset, "limit": &p.limit} {
+		raw := r.URL.Query().Get(name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "%s must be a non-negative integer", name)
+			return page{}, false
+		}
+		*dst = n
+	}
+	if p.limit < 1 {
+		writeError(w, http.StatusBadRequest, "limit must be at least 1")
+		return page{}, false
+	}
+	p.limit = min(p.limit, maxLimit)
+	return p, true
+}
+
+// window returns the [start, end) bounds of the page within n items.
+func (p page) window(n int) (int, int) {
+	start := min(p.offset, n)
+	return start, min(start+p.limit, n)
+}
+
+// listMeta is the pagination metadata of list responses
+type listMeta struct {
+	Total int `json:"total"`
+}
+
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,154 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testToken is the bearer token the unit tests act as
+const testToken = "secret:qa@example.com"
+
+// do sends an authorized request to the server and decodes a JSON object reply
+func do(t *testing.T, h http.Handler, method, path string, body interface{}) (int, map[string]interface{}) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, BasePath+path, &buf)
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var out map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &out)
+	return rec.Code, out
+}
+
+func TestRequiresBearerToken(t *testing.T) {
+	s := New(Options{})
+	for _, header := range []string{"", "Bearer", "Bearer ", "Basic " + testToken, "bearer " + testToken, "Bearer secret", "Bearer :qa@example.com", "Bearer secret:", "Bearer secret:not-an-email", "Bearer Bearer " + testToken} {
+		req := httptest.NewRequest("GET", BasePath+"/users", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code, "%q", header)
+	}
+}
+
+func TestAccountsAreIsolated(t *testing.T) {
+	s := New(Options{})
+	code, created := do(t, s, "POST", "/users", map[string]string{"email": "mine@example.com", "password": "password", "name": "Mine", "nickname": "mine"})
+	require.Equal(t, http.StatusOK, code)
+	uuid := created["uuid"].(string)
+
+	// the same token sees the user on a shared server as well
+	code, _ = do(t, s.Share(Options{Dev: true}), "GET", "/users/"+uuid, nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	req := httptest.NewRequest("GET", BasePath+"/users/"+uuid, nil)
+	req.Header.Set("Authorization", "Bearer secret:other@example.com")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestListUsersPagination(t *testing.T) {
+	s := New(Options{})
+
+	code, body := do(t, s, "GET", "/users", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(11), body["meta"].(map[string]interface{})["total"])
+	assert.Len(t, body["users"], 10)
+
+	code, body = do(t, s, "GET", "/users?offset=10&limit=5", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["users"], 1)
+
+	code, body = do(t, s, "GET", "/users?offset=20&limit=5", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, body["users"], 0)
+
+	for _, query := range []string{"offset=-1", "limit=0", "limit=abc"} {
+		code, _ = do(t, s, "GET", "/users?"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, code, query)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	valid := map[string]string{"email": "new@example.com", "password": "password", "name": "New", "nickname": "newbie"}
+
+	t.Run("Release", func(t *testing.T) {
+		s := New(Options{})
+		code, body := do(t, s, "POST", "/users", valid)
+		assert.Equal(t, http.StatusOK, code)
+		assert.NotEmpty(t, body["uuid"])
+
+		code, _ = do(t, s, "POST", "/users", valid)
+		assert.Equal(t, http.StatusConflict, code)
+
+		short := map[string]string{"email": "short@example.com", "password": "123", "name": "Short", "nickname": "shorty"}
+		code, _ = do(t, s, "POST", "/users", short)
+		assert.Equal(t, http.StatusBadRequest, code)
+
+		code, _ = do(t, s, "POST", "/users/login", map[string]string{"email": "new@example.com", "password": "password"})
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("Dev", func(t *testing.T) {
+		s := New(Options{Dev: true})
+		do(t, s, "POST", "/users", valid)
+		code, _ := do(t, s, "POST", "/users", valid)
+		assert.Equal(t, http.StatusInternalServerError, code)
+	})
+}
+
+func TestUpdateAndDeleteUser(t *testing.T) {
+	s := New(Options{})
+	_, list := do(t, s, "GET", "/users", nil)
+	users := list["users"].([]interface{})
+	first := users[0].(map[string]interface{})
+	second := users[1].(map[string]interface{})
+
+	code, _ := do(t, s, "PATCH", "/users/"+second["uuid"].(string), map[string]string{"email": first["email"].(string)})
+	assert.Equal(t, http.StatusConflict, code)
+
+	code, body := do(t, s, "PATCH", "/users/"+second["uuid"].(string), map[string]string{"name": "Renamed"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Renamed", body["name"])
+
+	code, _ = do(t, s, "DELETE", "/users/"+second["uuid"].(string), nil)
+	assert.Equal(t, http.StatusNoContent, code)
+	code, _ = do(t, s, "DELETE", "/users/"+second["uuid"].(string), nil)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = do(t, s, "POST", "/setup", nil)
+	assert.Equal(t, http.StatusResetContent, code)
+	code, _ = do(t, s, "GET", "/users/"+second["uuid"].(string), nil)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestShareKeepsOneDataSet(t *testing.T) {
+	release := New(Options{})
+	dev := release.Share(Options{Dev: true})
+
+	user := map[string]string{"email": "shared@example.com", "password": "password", "name": "Shared", "nickname": "shared"}
+	code, _ := do(t, release, "POST", "/users", user)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, body := do(t, dev, "GET", "/users?limit=20", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(12), body["meta"].(map[string]interface{})["total"])
+
+	code, _ = do(t, dev, "POST", "/users", user)
+	assert.Equal(t, http.StatusInternalServerError, code)
+}
@@ -0,0 +1,238 @@
+package fakeapi
+
+import (
+	"net/http"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// user as stored by the fake
+type user struct {
+	UUID      string `json:"uuid"`
+	Email     string `json:"email"`
+	Password  string `json:"-"`
+	Name      string `json:"name"`
+	Nickname  string `json:"nickname"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// userRequest is the body of create and update requests, nil fields are absent
+type userRequest struct {
+	Email    *string `json:"email"`
+	Password *string `json:"password"`
+	Name     *string `json:"name"`
+	Nickname *string `json:"nickname"`
+}
+
+// field rules enforced on user writes
+const (
+	minPasswordLen = 6
+	maxPasswordLen = 100
+	minNicknameLen = 3
+	maxNicknameLen = 50
+	maxNameLen     = 100
+	maxEmailLen    = 100
+)
+
+// validate checks every present field, required lists the ones that must be set.
+func (req userRequest) validate(required bool) string {
+	if required && (req.Email == nil || req.Password == nil || req.Name == nil || req.Nickname == nil) {
+		return "email, password, name and nickname are required"
+	}
+	if req.Email != nil {
+		addr, err := mail.ParseAddress(*req.Email)
+		if err != nil || addr.Address != *req.Email || addr.Name != "" || len(*req.Email) > maxEmailLen {
+			return "email is invalid"
+		}
+	}
+	if req.Password != nil {
+		if n := utf8.RuneCountInString(*req.Password); n < minPasswordLen || n > maxPasswordLen {
+			return "password must be between 6 and 100 characters"
+		}
+	}
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" || utf8.RuneCountInString(*req.Name) > maxNameLen {
+			return "name must be between 1 and 100 characters"
+		}
+	}
+	if req.Nickname != nil {
+		if n := len(*req.Nickname); n < minNicknameLen || n > maxNicknameLen || !validNickname(*req.Nickname) {
+			return "nickname must be 3 to 50 letters, digits, '_', '-' or '.'"
+		}
+	}
+	return ""
+}
+
+func validNickname(s string) bool {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// findUser returns the index of the user with the UUID, -1 if absent. Callers hold s.mu.
+func (s *store) findUser(uuid string) int {
+	for i, u := range s.users {
+		if u.UUID == uuid {
+			return i
+		}
+	}
+	return -1
+}
+
+// conflict reports whether another user already has the email or nickname. Callers hold s.mu.
+func (s *store) conflict(self string, email, nickname *string) bool {
+	for _, u := range s.users {
+		if u.UUID == self {
+			continue
+		}
+		if email != nil && strings.EqualFold(u.Email, *email) || nickname != nil && u.Nickname == *nickname {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *account) listUsers(w http.ResponseWriter, r *http.Request) {
+	p, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start, end := p.window(len(s.users))
+	writeJSON(w, http.StatusOK, struct {
+		Meta  listMeta `json:"meta"`
+		Users []*user  `json:"users"`
+	}{listMeta{Total: len(s.users)}, s.users[start:end]})
+}
+
+func (s *account) createUser(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if msg := req.validate(true); msg != "" {
+		writeError(w, http.StatusBadRequest, "%s", msg)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conflict("", req.Email, req.Nickname) {
+		// API-22: Dev fails with a 500 instead of reporting the conflict
+		if s.opts.Dev {
+			writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		writeError(w, http.StatusConflict, "user with this email or nickname already exists")
+		return
+	}
+
+	u := &user{
+		UUID:      newUUID(),
+		Email:     *req.Email,
+		Password:  *req.Password,
+		Name:      *req.Name,
+		Nickname:  *req.Nickname,
+		AvatarURL: defaultAvatarURL,
+	}
+	s.users = append(s.users, u)
+	writeJSON(w, http.StatusOK, u)
+}
+
+func (s *account) login(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if strings.EqualFold(u.Email, req.Email) && u.Password == req.Password {
+			writeJSON(w, http.StatusOK, u)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "user not found")
+}
+
+func (s *account) getUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findUser(r.PathValue("uuid"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.users[i])
+}
+
+func (s *account) updateUser(w http.ResponseWriter, r *http.Request) {
+	var req userRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if msg := req.validate(false); msg != "" {
+		writeError(w, http.StatusBadRequest, "%s", msg)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findUser(r.PathValue("uuid"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	u := s.users[i]
+	// API-4: Dev does not check the email against other users
+	email := req.Email
+	if s.opts.Dev {
+		email = nil
+	}
+	if s.conflict(u.UUID, email, req.Nickname) {
+		writeError(w, http.StatusConflict, "user with this email or nickname already exists")
+		return
+	}
+
+	if req.Email != nil {
+		u.Email = *req.Email
+	}
+	if req.Password != nil {
+		u.Password = *req.Password
+	}
+	if req.Name != nil {
+		u.Name = *req.Name
+	}
+	if req.Nickname != nil {
+		u.Nickname = *req.Nickname
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+func (s *account) deleteUser(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.findUser(r.PathValue("uuid"))
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	s.users = append(s.users[:i], s.users[i+1:]...)
+	w.WriteHeader(http.StatusNoContent)
+}
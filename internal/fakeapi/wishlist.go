@@ -0,0 +1,87 @@
+package fakeapi
+
+import "net/http"
+
+// maxWishlistItems is the capacity of a user's wishlist.
+const maxWishlistItems = 10
+
+// wishlistResponse mirrors the wishlist payload of the real API
+type wishlistResponse struct {
+	Items    []*game `json:"items"`
+	UserUUID string  `json:"user_uuid"`
+}
+
+// wishlist resolves the user's wishlist against the catalog. Callers hold s.mu.
+func (s *store) wishlist(userUUID string) wishlistResponse {
+	resp := wishlistResponse{Items: []*game{}, UserUUID: userUUID}
+	for _, uuid := range s.wishlists[userUUID] {
+		if g := s.findGame(uuid); g != nil {
+			resp.Items = append(resp.Items, g)
+		}
+	}
+	return resp
+}
+
+// wishlistIndex returns the position of the item in the wishlist, -1 if absent. Callers hold s.mu.
+func (s *store) wishlistIndex(userUUID, itemUUID string) int {
+	for i, uuid := range s.wishlists[userUUID] {
+		if uuid == itemUUID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *account) getWishlist(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userUUID := r.PathValue("uuid")
+	if s.requireUser(w, userUUID) {
+		writeJSON(w, http.StatusOK, s.wishlist(userUUID))
+	}
+}
+
+func (s *account) addToWishlist(w http.ResponseWriter, r *http.Request) {
+	userUUID, req, ok := s.lockItem(w, r)
+	if !ok {
+		return
+	}
+	defer s.mu.Unlock()
+
+	if s.wishlistIndex(userUUID, req.ItemUUID) < 0 {
+		limit := maxWishlistItems
+		// API-5: Dev reports a full wishlist one item before the limit
+		if s.opts.Dev {
+			limit--
+		}
+		if len(s.wishlists[userUUID]) >= limit {
+			writeError(w, http.StatusUnprocessableEntity, "wishlist is full")
+			return
+		}
+		s.wishlists[userUUID] = append(s.wishlists[userUUID], req.ItemUUID)
+	}
+	writeJSON(w, http.StatusOK, s.wishlist(userUUID))
+}
+
+func (s *account) removeFromWishlist(w http.ResponseWriter, r *http.Request) {
+	userUUID, req, ok := s.lockItem(w, r)
+	if !ok {
+		return
+	}
+	defer s.mu.Unlock()
+
+	// API-8: Dev answers 200 but keeps the item, and never answers 404
+	if s.opts.Dev {
+		writeJSON(w, http.StatusOK, s.wishlist(userUUID))
+		return
+	}
+	i := s.wishlistIndex(userUUID, req.ItemUUID)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "item is not in the wishlist")
+		return
+	}
+	items := s.wishlists[userUUID]
+	s.wishlists[userUUID] = append(items[:i:i], items[i+1:]...)
+	writeJSON(w, http.StatusOK, s.wishlist(userUUID))
+}
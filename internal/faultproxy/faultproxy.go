@@ -0,0 +1,230 @@
+// Package faultproxy is a reverse proxy that misbehaves on a schedule. Put in
+// front of any environment, it adds latency, resets connections, truncates
+// bodies, corrupts JSON, lies about the content type and answers with bursts
+// of 5xx, so clients can be checked to fail cleanly instead of panicking.
+package faultproxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault is one kind of misbehaviour.
+type Fault string
+
+const (
+	Latency     Fault = "latency"  // delay the response by Rule.Delay
+	Reset       Fault = "reset"    // drop the connection without a response
+	Truncate    Fault = "truncate" // send half of the body, then close
+	InvalidJSON Fault = "json"     // replace the body with broken JSON
+	ContentType Fault = "ctype"    // label the body text/html
+	ServerError Fault = "5xx"      // answer 503 without asking the target
+)
+
+var faults = map[Fault]bool{Latency: true, Reset: true, Truncate: true, InvalidJSON: true, ContentType: true, ServerError: true}
+
+// Rule applies a fault to Burst consecutive requests out of every Every,
+// counting requests from 1. Every 1 hits every request.
+type Rule struct {
+	Fault Fault
+	Every int
+	Burst int
+	Delay time.Duration
+}
+
+func (r Rule) String() string {
+	s := string(r.Fault)
+	if r.Fault == Latency {
+		s += "=" + r.Delay.String()
+	}
+	s += "/" + strconv.Itoa(r.Every)
+	if r.Burst > 1 {
+		s += "x" + strconv.Itoa(r.Burst)
+	}
+	return s
+}
+
+// hits reports whether the rule applies to request n
+func (r Rule) hits(n int) bool {
+	return (n-1)%r.Every >= r.Every-max(r.Burst, 1)
+}
+
+// ParseSchedule reads a comma separated list of rules of the form
+// fault[=delay]/every[xburst], e.g. "latency=200ms/2,reset/7,5xx/10x3".
+func ParseSchedule(spec string) ([]Rule, error) {
+	var rules []Rule
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		head, every, ok := strings.Cut(field, "/")
+		if !ok {
+			return nil, fmt.Errorf("fault rule %q: want fault/every", field)
+		}
+		name, delay, hasDelay := strings.Cut(head, "=")
+		r := Rule{Fault: Fault(name), Burst: 1}
+		if !faults[r.Fault] {
+			return nil, fmt.Errorf("fault rule %q: unknown fault %q", field, name)
+		}
+		if hasDelay != (r.Fault == Latency) {
+			return nil, fmt.Errorf("fault rule %q: only latency takes a delay, and it needs one", field)
+		}
+		if hasDelay {
+			d, err := time.ParseDuration(delay)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("fault rule %q: bad delay %q", field, delay)
+			}
+			r.Delay = d
+		}
+		every, burst, hasBurst := strings.Cut(every, "x")
+		var err error
+		if r.Every, err = strconv.Atoi(every); err != nil || r.Every < 1 {
+			return nil, fmt.Errorf("fault rule %q: every must be a positive number", field)
+		}
+		if hasBurst {
+			if r.Burst, err = strconv.Atoi(burst); err != nil || r.Burst < 1 || r.Burst > r.Every {
+				return nil, fmt.Errorf("fault rule %q: burst must be between 1 and %d", field, r.Every)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Proxy forwards requests to one target and applies the rules.
+type Proxy struct {
+	rules []Rule
+	next  *httputil.ReverseProxy
+
+	mu    sync.Mutex
+	count int
+	hits  map[Fault]int
+}
+
+// activeKey carries the faults of a request to ModifyResponse
+type activeKey struct{}
+
+// New returns a proxy to target, which is a scheme and host; request paths
+// are forwarded unchanged.
+func New(target string, rules []Rule) (*Proxy, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("fault proxy target %q needs a scheme and a host", target)
+	}
+	p := &Proxy{rules: rules, hits: map[Fault]int{}}
+	p.next = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(&url.URL{Scheme: u.Scheme, Host: u.Host})
+		},
+		ModifyResponse: modifyResponse,
+	}
+	return p, nil
+}
+
+// Hits returns how often each fault has been applied so far.
+func (p *Proxy) Hits() map[Fault]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[Fault]int, len(p.hits))
+	for f, n := range p.hits {
+		out[f] = n
+	}
+	return out
+}
+
+// active counts the request and returns the faults that apply to it
+func (p *Proxy) active() map[Fault]Rule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count++
+	active := map[Fault]Rule{}
+	for _, r := range p.rules {
+		if r.hits(p.count) {
+			active[r.Fault] = r
+			p.hits[r.Fault]++
+		}
+	}
+	return active
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	active := p.active()
+	if rule, ok := active[Latency]; ok {
+		select {
+		case <-time.After(rule.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if _, ok := active[Reset]; ok {
+		reset(w)
+		return
+	}
+	if _, ok := active[ServerError]; ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"code":503,"message":"fault injected by faultproxy"}`)
+		return
+	}
+	p.next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), activeKey{}, active)))
+}
+
+// reset closes the client connection without writing anything, with SO_LINGER
+// 0 on TCP so the client sees a reset rather than an orderly close
+func reset(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// modifyResponse applies the body faults to the target's response
+func modifyResponse(resp *http.Response) error {
+	active, _ := resp.Request.Context().Value(activeKey{}).(map[Fault]Rule)
+	if len(active) == 0 {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := active[InvalidJSON]; ok {
+		body = []byte(`{"code": 200, "data": [{"uuid": "` + "\x00" + `", }`)
+	}
+	if _, ok := active[ContentType]; ok {
+		resp.Header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	length := len(body)
+	if _, ok := active[Truncate]; ok {
+		// the full length is announced, so the client notices the short body
+		body = body[:len(body)/2]
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(length)
+	resp.Header.Set("Content-Length", strconv.Itoa(length))
+	return nil
+}
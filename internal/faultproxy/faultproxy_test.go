@@ -0,0 +1,126 @@
+package faultproxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	rules, err := ParseSchedule("latency=200ms/2, reset/7,5xx/10x3")
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Fault: Latency, Every: 2, Burst: 1, Delay: 200 * time.Millisecond},
+		{Fault: Reset, Every: 7, Burst: 1},
+		{Fault: ServerError, Every: 10, Burst: 3},
+	}, rules)
+	assert.Equal(t, "latency=200ms/2", rules[0].String())
+	assert.Equal(t, "5xx/10x3", rules[2].String())
+
+	for _, spec := range []string{"reset", "boom/2", "latency/2", "reset=1s/2", "latency=fast/2", "json/0", "5xx/3x4", "ctype/2xz"} {
+		_, err := ParseSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRuleHits(t *testing.T) {
+	burst := Rule{Every: 5, Burst: 2}
+	var hit []int
+	for n := 1; n <= 10; n++ {
+		if burst.hits(n) {
+			hit = append(hit, n)
+		}
+	}
+	assert.Equal(t, []int{4, 5, 9, 10}, hit)
+	assert.True(t, Rule{Every: 1, Burst: 1}.hits(1))
+}
+
+// upstream answers every request with a small JSON document
+func upstream(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"path": r.URL.Path, "padding": "0123456789abcdef"})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// through starts a proxy with one rule that hits every request
+func through(t *testing.T, rule Rule) (*Proxy, string) {
+	p, err := New(upstream(t).URL, []Rule{rule})
+	require.NoError(t, err)
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+	return p, srv.URL
+}
+
+func TestPassesThrough(t *testing.T) {
+	_, url := through(t, Rule{Fault: ServerError, Every: 2, Burst: 1})
+	resp, err := http.Get(url + "/api/v1/games")
+	require.NoError(t, err)
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	assert.Equal(t, "/api/v1/games", body["path"])
+
+	resp, err = http.Get(url + "/api/v1/games")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestFaults(t *testing.T) {
+	t.Run("latency", func(t *testing.T) {
+		_, url := through(t, Rule{Fault: Latency, Every: 1, Delay: 30 * time.Millisecond})
+		start := time.Now()
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	})
+
+	t.Run("reset", func(t *testing.T) {
+		p, url := through(t, Rule{Fault: Reset, Every: 1})
+		resp, err := http.Get(url)
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+		assert.Equal(t, 1, p.Hits()[Reset])
+	})
+
+	t.Run("truncate", func(t *testing.T) {
+		_, url := through(t, Rule{Fault: Truncate, Every: 1})
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		_, err = io.ReadAll(resp.Body)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		_, url := through(t, Rule{Fault: InvalidJSON, Every: 1})
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var v interface{}
+		assert.Error(t, json.NewDecoder(resp.Body).Decode(&v))
+	})
+
+	t.Run("ctype", func(t *testing.T) {
+		_, url := through(t, Rule{Fault: ContentType, Every: 1})
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	})
+}
+
+func TestNewNeedsHost(t *testing.T) {
+	_, err := New("/api/v1", nil)
+	assert.Error(t, err)
+}
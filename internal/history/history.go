@@ -0,0 +1,108 @@
+// Package history keeps the results of suite runs in a JSON-lines file, one
+// run per line, and folds them into per-task trends: when a bug was first
+// and last seen, whether it is still there and how often it came and went.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Run is one run of the suite.
+type Run struct {
+	ID     string        `json:"id"`
+	Time   time.Time     `json:"time"`
+	Target string        `json:"target"` // live or fake
+	Envs   []Fingerprint `json:"envs"`
+	Tests  []Test        `json:"tests"`
+}
+
+// Fingerprint identifies the build an environment ran during a run.
+type Fingerprint struct {
+	Name    string            `json:"name"`
+	URL     string            `json:"url"`
+	Hash    string            `json:"hash,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"` // headers naming a version or build
+	Err     string            `json:"error,omitempty"`   // why no fingerprint was taken
+}
+
+// Test is the outcome of one top-level test.
+type Test struct {
+	Name    string   `json:"name"`
+	Tasks   []string `json:"tasks,omitempty"` // task IDs the test covers
+	Task    string   `json:"task,omitempty"`  // the single task ID of runs stored before Tasks
+	Outcome string   `json:"outcome"`         // pass, fail, skip, flaky or recovered
+	Elapsed float64  `json:"elapsed"`
+	Diffs   []string `json:"diffs,omitempty"` // failure messages, how the environments differed
+}
+
+// TaskIDs returns the task IDs of the test, also for runs stored with a single task.
+func (t Test) TaskIDs() []string {
+	if len(t.Tasks) == 0 && t.Task != "" {
+		return []string{t.Task}
+	}
+	return t.Tasks
+}
+
+// NewID names a run after the time it started.
+func NewID(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// Append adds a run to the history file at path, creating it if needed.
+func Append(path string, run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read reads the runs of a history, in the order they were added.
+func Read(r io.Reader) ([]Run, error) {
+	var runs []Run
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 64<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(sc.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, sc.Err()
+}
+
+// ReadFile reads a history file, a missing file has no runs.
+func ReadFile(path string) ([]Run, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	runs, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,108 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// run builds a run on day d of October 2026 with the given outcomes by test
+func run(d int, devHash string, outcomes map[string]string) Run {
+	tasks := map[string][]string{"TestRemoveFromCart": {"api-14"}, "TestClearCart": {"api-15"}, "TestSearchGames": {"api-2"}}
+	t := time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC)
+	r := Run{ID: NewID(t), Time: t, Target: "live", Envs: []Fingerprint{{Name: "Release", Hash: "aaaa"}, {Name: "Dev", Hash: devHash}}}
+	for _, name := range []string{"TestClearCart", "TestHelpersFailCleanly", "TestRemoveFromCart", "TestSearchGames"} {
+		if o, ok := outcomes[name]; ok {
+			test := Test{Name: name, Tasks: tasks[name], Outcome: o}
+			if o == "fail" {
+				test.Diffs = []string{name + " differs on Dev"}
+			}
+			r.Tests = append(r.Tests, test)
+		}
+	}
+	return r
+}
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	runs, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, runs, "a missing file has no runs")
+
+	first := run(1, "bbbb", map[string]string{"TestRemoveFromCart": "fail"})
+	require.NoError(t, Append(path, first))
+	require.NoError(t, Append(path, run(2, "bbbb", map[string]string{"TestRemoveFromCart": "pass"})))
+	runs, err = ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, first, runs[0])
+	assert.Equal(t, "20261001T090000Z", runs[0].ID)
+
+	_, err = Read(strings.NewReader("{\"id\":\"a\"}\n\n{"))
+	assert.EqualError(t, err, "line 3: unexpected end of JSON input")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = ReadFile(path)
+	assert.ErrorContains(t, err, path+": line 1")
+}
+
+func TestTrends(t *testing.T) {
+	runs := []Run{
+		run(1, "bbbb", map[string]string{"TestRemoveFromCart": "pass", "TestSearchGames": "fail", "TestHelpersFailCleanly": "pass"}),
+		run(2, "cccc", map[string]string{"TestRemoveFromCart": "fail", "TestSearchGames": "fail", "TestClearCart": "flaky"}),
+		run(3, "cccc", map[string]string{"TestRemoveFromCart": "pass", "TestSearchGames": "fail"}),
+		run(4, "dddd", map[string]string{"TestRemoveFromCart": "fail", "TestSearchGames": "fail", "TestHelpersFailCleanly": "recovered"}),
+	}
+	trends := Trends(runs)
+	var tasks []string
+	for _, tr := range trends {
+		tasks = append(tasks, tr.Task)
+	}
+	assert.Equal(t, []string{"api-2", "api-14", "api-15", "TestHelpersFailCleanly"}, tasks, "task IDs sort by number, before test names")
+
+	search, cart, clear, helpers := trends[0], trends[1], trends[2], trends[3]
+	assert.Equal(t, 4, search.Failed)
+	assert.Equal(t, 0, search.Flips)
+	assert.False(t, search.Flapped())
+	assert.Equal(t, runs[0].ID, search.First.Run)
+
+	assert.Equal(t, []string{"TestRemoveFromCart"}, cart.Tests)
+	assert.Equal(t, 4, cart.Runs)
+	assert.Equal(t, 2, cart.Failed)
+	assert.Equal(t, runs[1].ID, cart.First.Run)
+	assert.Equal(t, "cccc", cart.First.Envs[1].Hash, "the Dev build the bug first appeared on")
+	assert.Equal(t, runs[3].ID, cart.Last.Run)
+	assert.Equal(t, []string{"TestRemoveFromCart differs on Dev"}, cart.Last.Diffs)
+	assert.Equal(t, 3, cart.Flips)
+	assert.True(t, cart.Flapped())
+	assert.Equal(t, "fail", cart.Current)
+
+	assert.Equal(t, 1, clear.Runs)
+	assert.Nil(t, clear.First, "a flaky test is not a sighting")
+	assert.Equal(t, "pass", clear.Current)
+	assert.Equal(t, 0, helpers.Failed)
+}
+
+func TestTrendsOfTestsWithSeveralTasks(t *testing.T) {
+	day := func(d int, outcome string) Run {
+		at := time.Date(2026, 10, d, 9, 0, 0, 0, time.UTC)
+		return Run{ID: NewID(at), Time: at, Tests: []Test{
+			{Name: "TestCartStateMachine", Tasks: []string{"api-12", "api-14"}, Outcome: outcome},
+			{Name: "TestRemoveFromCart", Task: "api-14", Outcome: "pass"}, // stored before Tasks
+		}}
+	}
+	trends := Trends([]Run{day(1, "pass"), day(2, "fail")})
+	require.Len(t, trends, 2)
+	assert.Equal(t, "api-12", trends[0].Task)
+	assert.Equal(t, []string{"TestCartStateMachine"}, trends[0].Tests)
+	assert.Equal(t, "api-14", trends[1].Task)
+	assert.Equal(t, []string{"TestCartStateMachine", "TestRemoveFromCart"}, trends[1].Tests)
+	for _, tr := range trends {
+		assert.Equal(t, 1, tr.Failed, tr.Task)
+		assert.Equal(t, "fail", tr.Current, tr.Task)
+	}
+}
@@ -0,0 +1,129 @@
+package history
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sighting is a run in which a task failed.
+type Sighting struct {
+	Run   string
+	Time  time.Time
+	Envs  []Fingerprint
+	Diffs []string
+}
+
+// Trend is the history of one task ID, or of one test without a task.
+type Trend struct {
+	Task   string
+	Tests  []string // tests of the task seen in the history, sorted
+	Runs   int      // runs that included the task
+	Failed int      // runs in which a test of the task failed
+	First  *Sighting
+	Last   *Sighting
+	// Flips counts the changes between failing and passing from run to run,
+	// more than one means the bug went away and came back
+	Flips   int
+	Current string // fail or pass in the latest run that included the task
+}
+
+// Flapped reports whether the task went from failing to passing and back.
+func (t *Trend) Flapped() bool {
+	return t.Flips > 1
+}
+
+// Trends folds runs, oldest first, into one trend per task. A test covering
+// several tasks counts for each of them. A task fails in a run when one of
+// its tests fails for good; flaky and recovered tests do not count and
+// skipped tests are left out.
+func Trends(runs []Run) []*Trend {
+	byTask := map[string]*Trend{}
+	tests := map[string]map[string]bool{}
+	for _, run := range runs {
+		failing := map[string]bool{}
+		diffs := map[string][]string{}
+		seen := map[string]bool{}
+		var order []string
+		for _, t := range run.Tests {
+			if t.Outcome == "skip" {
+				continue
+			}
+			keys := t.TaskIDs()
+			if len(keys) == 0 {
+				keys = []string{t.Name}
+			}
+			for _, key := range keys {
+				if !seen[key] {
+					seen[key] = true
+					order = append(order, key)
+				}
+				if tests[key] == nil {
+					tests[key] = map[string]bool{}
+				}
+				tests[key][t.Name] = true
+				if t.Outcome == "fail" {
+					failing[key] = true
+					diffs[key] = append(diffs[key], t.Diffs...)
+				}
+			}
+		}
+
+		for _, key := range order {
+			tr := byTask[key]
+			if tr == nil {
+				tr = &Trend{Task: key}
+				byTask[key] = tr
+			}
+			status := "pass"
+			if failing[key] {
+				status = "fail"
+				tr.Failed++
+				s := &Sighting{Run: run.ID, Time: run.Time, Envs: run.Envs, Diffs: diffs[key]}
+				if tr.First == nil {
+					tr.First = s
+				}
+				tr.Last = s
+			}
+			if tr.Runs > 0 && status != tr.Current {
+				tr.Flips++
+			}
+			tr.Runs++
+			tr.Current = status
+		}
+	}
+
+	out := make([]*Trend, 0, len(byTask))
+	for key, tr := range byTask {
+		for name := range tests[key] {
+			tr.Tests = append(tr.Tests, name)
+		}
+		sort.Strings(tr.Tests)
+		out = append(out, tr)
+	}
+	sort.Slice(out, func(i, j int) bool { return taskLess(out[i].Task, out[j].Task) })
+	return out
+}
+
+// taskLess sorts api-N task IDs by number, before test names
+func taskLess(a, b string) bool {
+	na, okA := taskNumber(a)
+	nb, okB := taskNumber(b)
+	switch {
+	case okA && okB:
+		return na < nb
+	case okA != okB:
+		return okA
+	}
+	return a < b
+}
+
+func taskNumber(task string) (int, bool) {
+	s, ok := strings.CutPrefix(task, "api-")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
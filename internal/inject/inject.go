@@ -0,0 +1,306 @@
+// Package inject probes the path and query parameters of an API with hostile
+// values: SQL and NoSQL injection strings, path traversal, encoded slashes,
+// over-long values and nil UUIDs. Each parameter of a target is replaced by
+// one payload at a time while the others keep a valid value, and the response
+// is classified. A 4xx is the expected answer; a 5xx, an error signature in
+// the body or a 2xx for an identifier is a finding.
+package inject
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Class groups payloads by the weakness they look for.
+type Class string
+
+const (
+	SQL       Class = "sql"
+	NoSQL     Class = "nosql"
+	Traversal Class = "traversal"
+	Encoding  Class = "encoding"
+	Overlong  Class = "overlong"
+	NilUUID   Class = "nil uuid"
+	Format    Class = "format"
+)
+
+// Payload is one hostile value. Raw payloads are inserted into the URL as
+// they are, so encoded slashes and dot segments reach the server unchanged;
+// the others are escaped like any regular value. PathOnly payloads are not
+// sent as query values, where they mean "use the default".
+type Payload struct {
+	Name     string
+	Class    Class
+	Value    string
+	Raw      bool
+	PathOnly bool
+}
+
+// Payloads returns the hostile values sent to every parameter.
+func Payloads() []Payload {
+	return []Payload{
+		{Name: "sql quote", Class: SQL, Value: "'"},
+		{Name: "sql tautology", Class: SQL, Value: "' OR '1'='1"},
+		{Name: "sql comment", Class: SQL, Value: "1' --"},
+		{Name: "sql numeric tautology", Class: SQL, Value: "1 OR 1=1"},
+		{Name: "sql union", Class: SQL, Value: "' UNION SELECT NULL,NULL,NULL--"},
+		{Name: "sql stacked", Class: SQL, Value: "1; DROP TABLE users--"},
+		{Name: "sql sleep", Class: SQL, Value: "1' AND pg_sleep(0)--"},
+		{Name: "nosql operator", Class: NoSQL, Value: `{"$ne": null}`},
+		{Name: "nosql bracket", Class: NoSQL, Value: "[$ne]=1"},
+		{Name: "nosql where", Class: NoSQL, Value: "'; return true; var x='"},
+		{Name: "dot segments", Class: Traversal, Value: "../../../etc/passwd", Raw: true},
+		{Name: "encoded dot segments", Class: Traversal, Value: "..%2F..%2F..%2Fetc%2Fpasswd", Raw: true},
+		{Name: "parent", Class: Traversal, Value: "..", Raw: true},
+		{Name: "encoded slash", Class: Encoding, Value: "a%2Fb", Raw: true},
+		{Name: "double encoded slash", Class: Encoding, Value: "a%252Fb", Raw: true},
+		{Name: "encoded nul", Class: Encoding, Value: "a%00b", Raw: true},
+		{Name: "unicode", Class: Encoding, Value: "名前😀"},
+		{Name: "overlong 1k", Class: Overlong, Value: strings.Repeat("a", 1<<10)},
+		{Name: "overlong 8k", Class: Overlong, Value: strings.Repeat("a", 8<<10)},
+		{Name: "overlong number", Class: Overlong, Value: strings.Repeat("9", 64)},
+		{Name: "nil uuid", Class: NilUUID, Value: "00000000-0000-0000-0000-000000000000"},
+		{Name: "max uuid", Class: NilUUID, Value: "ffffffff-ffff-ffff-ffff-ffffffffffff"},
+		{Name: "negative", Class: Format, Value: "-1"},
+		{Name: "float", Class: Format, Value: "1.5"},
+		{Name: "empty", Class: Format, Value: "", PathOnly: true},
+		{Name: "whitespace", Class: Format, Value: " "},
+	}
+}
+
+// Target is one endpoint. Path holds {name} placeholders, Query names the
+// query parameters. Lenient parameters are free text or paging numbers that
+// may fall back to a default, a 2xx is a normal answer for them; for any
+// other parameter it means the hostile value was resolved to data.
+type Target struct {
+	Method  string
+	Path    string
+	Query   []string
+	Body    interface{}
+	Lenient []string
+}
+
+func (t Target) String() string {
+	return t.Method + " " + t.Path
+}
+
+// Params lists the path placeholders followed by the query parameters.
+func (t Target) Params() []string {
+	var params []string
+	for rest := t.Path; ; {
+		i := strings.Index(rest, "{")
+		j := strings.Index(rest, "}")
+		if i < 0 || j < i {
+			break
+		}
+		params = append(params, rest[i+1:j])
+		rest = rest[j+1:]
+	}
+	return append(params, t.Query...)
+}
+
+func (t Target) inPath(param string) bool {
+	return strings.Contains(t.Path, "{"+param+"}")
+}
+
+func (t Target) lenient(param string) bool {
+	for _, name := range t.Lenient {
+		if name == param {
+			return true
+		}
+	}
+	return false
+}
+
+// URL fills the target from values, replacing param with the payload.
+func (t Target) URL(base string, values map[string]string, param string, p Payload) string {
+	value := func(name string, escape func(string) string) string {
+		if name != param {
+			return escape(values[name])
+		}
+		if p.Raw {
+			return p.Value
+		}
+		return escape(p.Value)
+	}
+
+	path := t.Path
+	for _, name := range t.Params() {
+		path = strings.ReplaceAll(path, "{"+name+"}", value(name, url.PathEscape))
+	}
+	var query []string
+	for _, name := range t.Query {
+		query = append(query, url.QueryEscape(name)+"="+value(name, url.QueryEscape))
+	}
+	if len(query) == 0 {
+		return base + path
+	}
+	return base + path + "?" + strings.Join(query, "&")
+}
+
+// Outcome is how a server answered a hostile value.
+type Outcome string
+
+const (
+	Rejected    Outcome = "rejected"
+	NotFound    Outcome = "not found"
+	Redirected  Outcome = "redirected"
+	Answered    Outcome = "answered"
+	Resolved    Outcome = "resolved"
+	ServerError Outcome = "server error"
+	Leak        Outcome = "leak"
+)
+
+// Finding reports whether the outcome needs a look: the value reached
+// something it should not have, or broke the server.
+func (o Outcome) Finding() bool {
+	return o == Resolved || o == ServerError || o == Leak
+}
+
+// LeakSignatures are fragments of database errors, stack traces and system
+// files. A response containing one exposes internals, whatever its status.
+var LeakSignatures = []string{
+	"SQLSTATE", "syntax error at or near", "pq: ", "ORA-0", "You have an error in your SQL syntax",
+	"sqlite3.", "unterminated quoted string", "MongoError", "MongoServerError", "$where",
+	"goroutine ", "panic:", "runtime error", "Traceback (most recent call last)", "at java.", "Exception in thread",
+	"root:x:0:0",
+}
+
+// Response is what a Sender got back.
+type Response struct {
+	Status int
+	Body   []byte
+}
+
+// Classify maps the response to a hostile value to an outcome, lenient as
+// for a free text parameter.
+func Classify(lenient bool, r Response) Outcome {
+	for _, sig := range LeakSignatures {
+		if bytes.Contains(r.Body, []byte(sig)) {
+			return Leak
+		}
+	}
+	switch {
+	case r.Status >= 500:
+		return ServerError
+	case r.Status == http.StatusNotFound:
+		return NotFound
+	case r.Status >= 300 && r.Status < 400:
+		return Redirected
+	case r.Status >= 200 && r.Status < 300 && lenient:
+		return Answered
+	case r.Status >= 200 && r.Status < 300:
+		return Resolved
+	default:
+		return Rejected
+	}
+}
+
+// Sender sends one request. It must not follow redirects, so a server
+// cleaning dot segments shows up as Redirected rather than as the page it
+// redirects to.
+type Sender func(method, url string, body interface{}) (Response, error)
+
+// Result is the outcome of one payload in one parameter of one target.
+type Result struct {
+	Target  string
+	Param   string
+	Payload Payload
+	Status  int
+	Outcome Outcome
+}
+
+func (r Result) key() string {
+	return r.Target + " " + r.Param + " " + r.Payload.Name
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s %s=%s: %d %s", r.Target, r.Param, r.Payload.Name, r.Status, r.Outcome)
+}
+
+// Run sends every payload in every parameter of every target. values holds
+// a valid value for each parameter, used while another one is probed.
+func Run(base string, targets []Target, values map[string]string, payloads []Payload, send Sender) ([]Result, error) {
+	var results []Result
+	for _, t := range targets {
+		for _, param := range t.Params() {
+			if _, ok := values[param]; !ok {
+				return nil, fmt.Errorf("%s: no valid value for %s", t, param)
+			}
+			for _, p := range payloads {
+				if p.PathOnly && !t.inPath(param) {
+					continue
+				}
+				resp, err := send(t.Method, t.URL(base, values, param, p), t.Body)
+				if err != nil {
+					return nil, fmt.Errorf("%s %s=%s: %v", t, param, p.Name, err)
+				}
+				results = append(results, Result{
+					Target:  t.String(),
+					Param:   param,
+					Payload: p,
+					Status:  resp.Status,
+					Outcome: Classify(t.lenient(param), resp),
+				})
+			}
+		}
+	}
+	return results, nil
+}
+
+// Findings keeps the results whose outcome is a finding.
+func Findings(results []Result) []Result {
+	var out []Result
+	for _, r := range results {
+		if r.Outcome.Finding() {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Diff lists the probes two environments answered with different outcomes.
+func Diff(a, b []Result) []string {
+	byKey := make(map[string]Result, len(b))
+	for _, r := range b {
+		byKey[r.key()] = r
+	}
+	var diffs []string
+	for _, r := range a {
+		if other, ok := byKey[r.key()]; ok && other.Outcome != r.Outcome {
+			diffs = append(diffs, fmt.Sprintf("%s %s=%s: %s vs %s", r.Target, r.Param, r.Payload.Name, r.Outcome, other.Outcome))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// Summary counts outcomes by payload class, rendered one class per line.
+func Summary(results []Result) []string {
+	counts := map[Class]map[Outcome]int{}
+	for _, r := range results {
+		if counts[r.Payload.Class] == nil {
+			counts[r.Payload.Class] = map[Outcome]int{}
+		}
+		counts[r.Payload.Class][r.Outcome]++
+	}
+	var lines []string
+	for _, class := range []Class{SQL, NoSQL, Traversal, Encoding, Overlong, NilUUID, Format} {
+		c := counts[class]
+		if c == nil {
+			continue
+		}
+		var parts []string
+		for _, o := range []Outcome{Rejected, NotFound, Redirected, Answered, Resolved, ServerError, Leak} {
+			if c[o] > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", c[o], o))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", class, strings.Join(parts, ", ")))
+	}
+	return lines
+}
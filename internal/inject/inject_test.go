@@ -0,0 +1,105 @@
+package inject
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cartAdd = Target{Method: "POST", Path: "/users/{user}/cart/add"}
+
+var search = Target{Method: "GET", Path: "/games/search", Query: []string{"query", "offset"}, Lenient: []string{"query"}}
+
+func TestParams(t *testing.T) {
+	assert.Equal(t, []string{"user"}, cartAdd.Params())
+	assert.Equal(t, []string{"query", "offset"}, search.Params())
+	assert.Equal(t, []string{"a", "b"}, Target{Path: "/x/{a}/y/{b}"}.Params())
+}
+
+func TestURL(t *testing.T) {
+	values := map[string]string{"user": "u-1", "query": "halo", "offset": "0"}
+
+	assert.Equal(t, "http://api/users/%27%20OR%20%271%27=%271/cart/add",
+		cartAdd.URL("http://api", values, "user", Payload{Value: "' OR '1'='1"}))
+	assert.Equal(t, "http://api/users/a%2Fb/cart/add",
+		cartAdd.URL("http://api", values, "user", Payload{Value: "a%2Fb", Raw: true}))
+	assert.Equal(t, "http://api/users/a%252Fb/cart/add",
+		cartAdd.URL("http://api", values, "user", Payload{Value: "a%2Fb"}))
+	assert.Equal(t, "http://api/games/search?query=halo&offset=1+OR+1%3D1",
+		search.URL("http://api", values, "offset", Payload{Value: "1 OR 1=1"}))
+	assert.Equal(t, "http://api/games/search?query=1&limit=100000&offset=0",
+		search.URL("http://api", values, "query", Payload{Value: "1&limit=100000", Raw: true}))
+}
+
+func TestClassify(t *testing.T) {
+	assert.Equal(t, Rejected, Classify(false, Response{Status: http.StatusBadRequest}))
+	assert.Equal(t, NotFound, Classify(false, Response{Status: http.StatusNotFound}))
+	assert.Equal(t, Redirected, Classify(false, Response{Status: http.StatusMovedPermanently}))
+	assert.Equal(t, Resolved, Classify(false, Response{Status: http.StatusOK}))
+	assert.Equal(t, Answered, Classify(true, Response{Status: http.StatusOK}))
+	assert.Equal(t, ServerError, Classify(true, Response{Status: http.StatusBadGateway}))
+	assert.Equal(t, Leak, Classify(false, Response{Status: http.StatusBadRequest, Body: []byte(`{"message":"pq: syntax error at or near \"OR\""}`)}))
+
+	assert.False(t, Answered.Finding())
+	assert.False(t, Redirected.Finding())
+	assert.True(t, Resolved.Finding())
+	assert.True(t, Leak.Finding())
+}
+
+func TestPayloads(t *testing.T) {
+	names := map[string]bool{}
+	for _, p := range Payloads() {
+		assert.False(t, names[p.Name], "duplicate payload %s", p.Name)
+		names[p.Name] = true
+	}
+}
+
+// sqlServer answers like a backend that builds its SQL from the user path segment
+func sqlServer(method, url string, body interface{}) (Response, error) {
+	switch {
+	case strings.Contains(url, "%27"), strings.Contains(url, "'"):
+		return Response{Status: http.StatusInternalServerError, Body: []byte("SQLSTATE 42601")}, nil
+	case strings.Contains(url, "/users/u-1/"):
+		return Response{Status: http.StatusOK}, nil
+	default:
+		return Response{Status: http.StatusNotFound}, nil
+	}
+}
+
+func TestRunAndDiff(t *testing.T) {
+	payloads := []Payload{{Name: "quote", Class: SQL, Value: "'"}, {Name: "nil", Class: NilUUID, Value: "00000000-0000-0000-0000-000000000000"}}
+	values := map[string]string{"user": "u-1"}
+
+	results, err := Run("http://api", []Target{cartAdd}, values, payloads, sqlServer)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, Leak, results[0].Outcome)
+	assert.Equal(t, NotFound, results[1].Outcome)
+	assert.Equal(t, results[:1], Findings(results))
+	assert.Equal(t, []string{"sql: 1 leak", "nil uuid: 1 not found"}, Summary(results))
+
+	clean, err := Run("http://api", []Target{cartAdd}, values, payloads, func(string, string, interface{}) (Response, error) {
+		return Response{Status: http.StatusNotFound}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"POST /users/{user}/cart/add user=quote: leak vs not found"}, Diff(results, clean))
+
+	_, err = Run("http://api", []Target{cartAdd}, map[string]string{}, payloads, sqlServer)
+	assert.Error(t, err)
+}
+
+func TestPathOnlyPayloads(t *testing.T) {
+	empty := []Payload{{Name: "empty", Class: Format, PathOnly: true}}
+	ok := func(string, string, interface{}) (Response, error) { return Response{Status: http.StatusOK}, nil }
+
+	results, err := Run("http://api", []Target{search}, map[string]string{"query": "a", "offset": "0"}, empty, ok)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = Run("http://api", []Target{cartAdd}, map[string]string{"user": "u-1"}, empty, ok)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
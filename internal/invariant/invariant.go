@@ -0,0 +1,191 @@
+// Package invariant checks the prices in cart, order and payment payloads
+// against the game catalog. Check works on a decoded payload; Transport runs
+// the checks on every response passing through an http.Client, so a suite
+// gets them without touching its request code.
+package invariant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Catalog resolves the reference values the invariants are checked against.
+type Catalog interface {
+	// Price returns the catalog price of a game.
+	Price(itemUUID string) (int, error)
+	// OrderTotal returns the total_price of an order.
+	OrderTotal(orderUUID string) (int, error)
+}
+
+// Kind is the resource a response carries.
+type Kind int
+
+const (
+	None Kind = iota
+	Cart
+	Order
+	OrderList
+	Payment
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Cart:
+		return "cart"
+	case Order:
+		return "order"
+	case OrderList:
+		return "order list"
+	case Payment:
+		return "payment"
+	}
+	return "none"
+}
+
+// Classify maps a request to the kind of resource its response carries.
+func Classify(method, path string) Kind {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	at := func(i int) string {
+		if i < 0 || i >= len(segs) {
+			return ""
+		}
+		return segs[i]
+	}
+	n := len(segs)
+
+	switch {
+	case at(n-3) == "users" && at(n-1) == "cart", at(n-4) == "users" && at(n-2) == "cart":
+		return Cart
+	case at(n-3) == "users" && at(n-1) == "orders":
+		if method == http.MethodGet {
+			return OrderList
+		}
+		return Order
+	case at(n-2) == "orders", at(n-3) == "orders" && at(n-1) == "status":
+		return Order
+	case at(n-3) == "users" && at(n-1) == "payments", at(n-2) == "payments":
+		return Payment
+	}
+	return None
+}
+
+// Check verifies a payload of the given kind and returns every violation.
+func Check(c Catalog, kind Kind, body map[string]interface{}) []error {
+	switch kind {
+	case Cart:
+		return checkTotal(c, "cart", body)
+	case Order:
+		return checkTotal(c, fmt.Sprintf("order %v", body["uuid"]), body)
+	case OrderList:
+		orders, _ := body["orders"].([]interface{})
+		var errs []error
+		for _, o := range orders {
+			if order, ok := o.(map[string]interface{}); ok {
+				errs = append(errs, Check(c, Order, order)...)
+			}
+		}
+		return errs
+	case Payment:
+		return checkPayment(c, body)
+	}
+	return nil
+}
+
+// checkTotal checks every line against the catalog and the total against the lines
+func checkTotal(c Catalog, what string, body map[string]interface{}) []error {
+	var errs []error
+	items, _ := body["items"].([]interface{})
+	sum := 0
+	for _, it := range items {
+		item, _ := it.(map[string]interface{})
+		uuid, _ := item["item_uuid"].(string)
+		quantity, _ := number(item["quantity"])
+
+		price, err := c.Price(uuid)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s item %s: %v", what, uuid, err))
+			continue
+		}
+		line, ok := number(item["total_price"])
+		if !ok {
+			line = price * quantity
+		} else if line != price*quantity {
+			errs = append(errs, fmt.Errorf("%s item %s total_price is %d, want %d × %d = %d", what, uuid, line, price, quantity, price*quantity))
+		}
+		sum += line
+	}
+
+	if total, ok := number(body["total_price"]); ok && total != sum {
+		errs = append(errs, fmt.Errorf("%s total_price is %d, sum of items is %d", what, total, sum))
+	}
+	return errs
+}
+
+func checkPayment(c Catalog, body map[string]interface{}) []error {
+	amount, ok := number(body["amount"])
+	orderUUID, _ := body["order_uuid"].(string)
+	if !ok || orderUUID == "" {
+		return nil
+	}
+	total, err := c.OrderTotal(orderUUID)
+	if err != nil {
+		return []error{fmt.Errorf("payment %v: %v", body["uuid"], err)}
+	}
+	if amount != total {
+		return []error{fmt.Errorf("payment %v amount is %d, order %s total_price is %d", body["uuid"], amount, orderUUID, total)}
+	}
+	return nil
+}
+
+// number converts a decoded JSON number to an int
+func number(v interface{}) (int, bool) {
+	f, ok := v.(float64)
+	return int(f), ok
+}
+
+// Transport is an http.RoundTripper that checks successful cart, order and
+// payment responses and hands violations to Report. Bodies are buffered and
+// restored, so callers read them as usual; a body that cannot be read in full
+// fails the request with the read error.
+type Transport struct {
+	Next    http.RoundTripper
+	Catalog Catalog
+	Report  func(req *http.Request, err error)
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Next.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+	kind := Classify(req.Method, req.URL.Path)
+	if kind == None {
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err // a truncated or reset body is the caller's failure, not a partial answer
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	var body map[string]interface{}
+	if json.Unmarshal(data, &body) != nil {
+		return resp, nil
+	}
+	for _, violation := range Check(t.Catalog, kind, body) {
+		t.Report(req, violation)
+	}
+	return resp, nil
+}
+
+// CloseIdleConnections forwards to the wrapped transport, see http.Client.CloseIdleConnections.
+func (t *Transport) CloseIdleConnections() {
+	if c, ok := t.Next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,155 @@
+package invariant
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// catalog is a stub with fixed prices and order totals
+type catalog struct {
+	prices map[string]int
+	orders map[string]int
+}
+
+func (c catalog) Price(uuid string) (int, error) {
+	if p, ok := c.prices[uuid]; ok {
+		return p, nil
+	}
+	return 0, fmt.Errorf("not in the catalog")
+}
+
+func (c catalog) OrderTotal(uuid string) (int, error) {
+	if t, ok := c.orders[uuid]; ok {
+		return t, nil
+	}
+	return 0, fmt.Errorf("order not found")
+}
+
+var stub = catalog{
+	prices: map[string]int{"g1": 999, "g2": 1499},
+	orders: map[string]int{"o1": 3497},
+}
+
+func line(uuid string, quantity, total int) map[string]interface{} {
+	return map[string]interface{}{"item_uuid": uuid, "quantity": float64(quantity), "total_price": float64(total)}
+}
+
+func TestClassify(t *testing.T) {
+	cases := []struct {
+		method, path string
+		want         Kind
+	}{
+		{"GET", "/api/v1/users/u1/cart", Cart},
+		{"POST", "/api/v1/users/u1/cart/add", Cart},
+		{"POST", "/api/v1/users/u1/cart/clear", Cart},
+		{"POST", "/api/v1/users/u1/orders", Order},
+		{"GET", "/api/v1/users/u1/orders", OrderList},
+		{"GET", "/api/v1/orders/o1", Order},
+		{"PATCH", "/api/v1/orders/o1/status", Order},
+		{"POST", "/api/v1/users/u1/payments", Payment},
+		{"GET", "/api/v1/payments/p1", Payment},
+		{"GET", "/api/v1/users/u1/wishlist", None},
+		{"GET", "/api/v1/games/g1", None},
+		{"GET", "/api/v1/users/u1", None},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, Classify(c.method, c.path), "%s %s", c.method, c.path)
+	}
+}
+
+func TestCheckCart(t *testing.T) {
+	good := map[string]interface{}{
+		"items":       []interface{}{line("g1", 2, 1998), line("g2", 1, 1499)},
+		"total_price": float64(3497),
+	}
+	assert.Empty(t, Check(stub, Cart, good))
+
+	bad := map[string]interface{}{
+		"items":       []interface{}{line("g1", 2, 999), line("g3", 1, 100)},
+		"total_price": float64(5000),
+	}
+	errs := Check(stub, Cart, bad)
+	if assert.Len(t, errs, 3) {
+		assert.EqualError(t, errs[0], "cart item g1 total_price is 999, want 999 × 2 = 1998")
+		assert.EqualError(t, errs[1], "cart item g3: not in the catalog")
+		assert.EqualError(t, errs[2], "cart total_price is 5000, sum of items is 999")
+	}
+}
+
+func TestCheckOrderWithoutLineTotals(t *testing.T) {
+	order := map[string]interface{}{
+		"uuid":        "o1",
+		"items":       []interface{}{map[string]interface{}{"item_uuid": "g2", "quantity": float64(2)}},
+		"total_price": float64(2998),
+	}
+	assert.Empty(t, Check(stub, Order, order))
+
+	order["total_price"] = float64(1499)
+	list := map[string]interface{}{"orders": []interface{}{order}}
+	errs := Check(stub, OrderList, list)
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "order o1 total_price is 1499, sum of items is 2998")
+	}
+}
+
+func TestCheckPayment(t *testing.T) {
+	assert.Empty(t, Check(stub, Payment, map[string]interface{}{"uuid": "p1", "order_uuid": "o1", "amount": float64(3497)}))
+
+	errs := Check(stub, Payment, map[string]interface{}{"uuid": "p1", "order_uuid": "o1", "amount": float64(100)})
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "payment p1 amount is 100, order o1 total_price is 3497")
+	}
+}
+
+func TestTransportRestoresBodyAndReports(t *testing.T) {
+	payload := `{"items":[{"item_uuid":"g1","quantity":1,"total_price":1}],"total_price":1}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, payload)
+	}))
+	defer server.Close()
+
+	var reported []string
+	client := &http.Client{Transport: &Transport{
+		Next:    http.DefaultTransport,
+		Catalog: stub,
+		Report: func(req *http.Request, err error) {
+			reported = append(reported, req.URL.Path+": "+err.Error())
+		},
+	}}
+
+	resp, err := client.Get(server.URL + "/api/v1/users/u1/cart")
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, payload, string(body))
+	assert.Equal(t, []string{"/api/v1/users/u1/cart: cart item g1 total_price is 1, want 999 × 1 = 999"}, reported)
+
+	resp, err = client.Get(server.URL + "/api/v1/users/u1/wishlist")
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+	assert.Len(t, reported, 1, "wishlist responses are not checked")
+}
+
+func TestTransportReturnsReadErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		io.WriteString(w, `{"items":[`)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &Transport{
+		Next:    http.DefaultTransport,
+		Catalog: stub,
+		Report:  func(req *http.Request, err error) { t.Errorf("unexpected report: %v", err) },
+	}}
+	_, err := client.Get(server.URL + "/api/v1/users/u1/cart")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "a short body is not passed on as an answer")
+}
@@ -0,0 +1,122 @@
+// Package jsondiff lists the differences between two decoded JSON documents,
+// one line per differing value, addressed by a path such as $.users[2].name.
+package jsondiff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Difference is one value that is not the same on both sides. A value
+// present on one side only has InA or InB false.
+type Difference struct {
+	Path     string
+	A, B     interface{}
+	InA, InB bool
+}
+
+// Format renders the difference with the names of both sides, e.g.
+// `$.meta.total: Release 10, Dev 12`.
+func (d Difference) Format(nameA, nameB string) string {
+	switch {
+	case !d.InA:
+		return fmt.Sprintf("%s: only on %s, %s", d.Path, nameB, render(d.B))
+	case !d.InB:
+		return fmt.Sprintf("%s: only on %s, %s", d.Path, nameA, render(d.A))
+	}
+	return fmt.Sprintf("%s: %s %s, %s %s", d.Path, nameA, render(d.A), nameB, render(d.B))
+}
+
+func render(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(data) > 80 {
+		return string(data[:77]) + "..."
+	}
+	return string(data)
+}
+
+// Options tune a comparison. Keys named in Ignore are skipped at any depth,
+// for values that always differ such as generated UUIDs.
+type Options struct {
+	Ignore map[string]bool
+}
+
+// Compare returns the differences between a and b in path order.
+func Compare(a, b interface{}, opts Options) []Difference {
+	var out []Difference
+	compare("$", a, b, opts, &out)
+	return out
+}
+
+// Decode parses a JSON document for Compare.
+func Decode(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func compare(path string, a, b interface{}, opts Options, out *[]Difference) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			compareObjects(path, a, b, opts, out)
+			return
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			compareArrays(path, a, b, opts, out)
+			return
+		}
+	default:
+		if a == b {
+			return
+		}
+	}
+	*out = append(*out, Difference{Path: path, A: a, B: b, InA: true, InB: true})
+}
+
+func compareObjects(path string, a, b map[string]interface{}, opts Options, out *[]Difference) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if opts.Ignore[k] {
+			continue
+		}
+		va, inA := a[k]
+		vb, inB := b[k]
+		if inA && inB {
+			compare(path+"."+k, va, vb, opts, out)
+		} else {
+			*out = append(*out, Difference{Path: path + "." + k, A: va, B: vb, InA: inA, InB: inB})
+		}
+	}
+}
+
+func compareArrays(path string, a, b []interface{}, opts Options, out *[]Difference) {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		p := path + "[" + strconv.Itoa(i) + "]"
+		switch {
+		case i >= len(a):
+			*out = append(*out, Difference{Path: p, B: b[i], InB: true})
+		case i >= len(b):
+			*out = append(*out, Difference{Path: p, A: a[i], InA: true})
+		default:
+			compare(p, a[i], b[i], opts, out)
+		}
+	}
+}
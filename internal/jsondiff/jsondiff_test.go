@@ -0,0 +1,42 @@
+package jsondiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	v, err := Decode([]byte(s))
+	require.NoError(t, err)
+	return v
+}
+
+func TestCompare(t *testing.T) {
+	a := decode(t, `{"meta":{"total":10},"users":[{"uuid":"a","name":"x"},{"uuid":"b"}],"code":200}`)
+	b := decode(t, `{"meta":{"total":12},"users":[{"uuid":"c","name":"y"}],"extra":true,"code":200}`)
+
+	var lines []string
+	for _, d := range Compare(a, b, Options{Ignore: map[string]bool{"uuid": true}}) {
+		lines = append(lines, d.Format("Release", "Dev"))
+	}
+	assert.Equal(t, []string{
+		"$.extra: only on Dev, true",
+		"$.meta.total: Release 10, Dev 12",
+		`$.users[0].name: Release "x", Dev "y"`,
+		`$.users[1]: only on Release, {"uuid":"b"}`,
+	}, lines)
+
+	assert.Empty(t, Compare(a, a, Options{}))
+}
+
+func TestCompareTypes(t *testing.T) {
+	diffs := Compare(decode(t, `{"v":[1]}`), decode(t, `{"v":{"0":1}}`), Options{})
+	require.Len(t, diffs, 1)
+	assert.Equal(t, `$.v: A [1], B {"0":1}`, diffs[0].Format("A", "B"))
+
+	diffs = Compare(decode(t, `null`), decode(t, `0`), Options{})
+	require.Len(t, diffs, 1)
+	assert.Equal(t, "$", diffs[0].Path)
+}
@@ -0,0 +1,157 @@
+// Package jsonpath reads values out of decoded JSON with a small subset of
+// JSONPath: $ is the document, .key selects a field, [n] an array element
+// (negative counts from the end) and [*] every element, e.g.
+// $.items[*].item_uuid or $.users[-1].name.
+package jsonpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// step is one selector of a parsed path
+type step struct {
+	key   string
+	index int
+	kind  int
+}
+
+const (
+	field = iota
+	element
+	every
+)
+
+// Path is a parsed path.
+type Path struct {
+	text  string
+	steps []step
+}
+
+func (p Path) String() string {
+	return p.text
+}
+
+// Parse checks the syntax of a path.
+func Parse(text string) (Path, error) {
+	rest, ok := strings.CutPrefix(text, "$")
+	if !ok {
+		return Path{}, fmt.Errorf("path %q must start with $", text)
+	}
+	p := Path{text: text}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return Path{}, fmt.Errorf("path %q: empty key", text)
+			}
+			p.steps = append(p.steps, step{kind: field, key: key})
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return Path{}, fmt.Errorf("path %q: unclosed [", text)
+			}
+			inside := rest[1:end]
+			if inside == "*" {
+				p.steps = append(p.steps, step{kind: every})
+			} else {
+				n, err := strconv.Atoi(inside)
+				if err != nil {
+					return Path{}, fmt.Errorf("path %q: [%s] is not an index", text, inside)
+				}
+				p.steps = append(p.steps, step{kind: element, index: n})
+			}
+			rest = rest[end+1:]
+		default:
+			return Path{}, fmt.Errorf("path %q: unexpected %q", text, rest[0])
+		}
+	}
+	return p, nil
+}
+
+// Get parses path and reads it from doc, see Path.Get.
+func Get(doc interface{}, path string) (interface{}, error) {
+	p, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Get(doc)
+}
+
+// Get reads the value at the path. After [*] the result is an array of the
+// values selected from every element. A missing key or index is an error
+// that names the part of the path that was found.
+func (p Path) Get(doc interface{}) (interface{}, error) {
+	return get(doc, p.steps, "$")
+}
+
+func get(v interface{}, steps []step, at string) (interface{}, error) {
+	for i, s := range steps {
+		switch s.kind {
+		case field:
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is %s, not an object", at, describe(v))
+			}
+			next, ok := obj[s.key]
+			if !ok {
+				return nil, fmt.Errorf("%s has no key %q", at, s.key)
+			}
+			v, at = next, at+"."+s.key
+		case element:
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is %s, not an array", at, describe(v))
+			}
+			n := s.index
+			if n < 0 {
+				n += len(arr)
+			}
+			if n < 0 || n >= len(arr) {
+				return nil, fmt.Errorf("%s has %d elements, no [%d]", at, len(arr), s.index)
+			}
+			v, at = arr[n], at+"["+strconv.Itoa(s.index)+"]"
+		case every:
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s is %s, not an array", at, describe(v))
+			}
+			out := make([]interface{}, 0, len(arr))
+			for j, elem := range arr {
+				got, err := get(elem, steps[i+1:], at+"["+strconv.Itoa(j)+"]")
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, got)
+			}
+			return out, nil
+		}
+	}
+	return v, nil
+}
+
+// describe names the JSON type of a value for error messages
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return fmt.Sprintf("a %T", v)
+}
@@ -0,0 +1,53 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const cart = `{"items":[{"item_uuid":"a","quantity":2},{"item_uuid":"b","quantity":1}],"total_price":10,"meta":null}`
+
+func doc(t *testing.T) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(cart), &v))
+	return v
+}
+
+func TestGet(t *testing.T) {
+	d := doc(t)
+	for path, want := range map[string]interface{}{
+		"$":                    d,
+		"$.total_price":        10.0,
+		"$.items[0].item_uuid": "a",
+		"$.items[-1].quantity": 1.0,
+		"$.items[*].item_uuid": []interface{}{"a", "b"},
+		"$.meta":               nil,
+	} {
+		got, err := Get(d, path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, got, path)
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	d := doc(t)
+	for path, want := range map[string]string{
+		"$.missing":            `$ has no key "missing"`,
+		"$.items[2]":           "$.items has 2 elements, no [2]",
+		"$.total_price.amount": "$.total_price is a number, not an object",
+		"$.items.uuid":         "$.items is an array, not an object",
+		"$.meta[0]":            "$.meta is null, not an array",
+		"$.items[*].price":     `$.items[0] has no key "price"`,
+		"items":                `path "items" must start with $`,
+		"$.items[x]":           `path "$.items[x]": [x] is not an index`,
+		"$.items[0":            `path "$.items[0": unclosed [`,
+		"$..items":             `path "$..items": empty key`,
+		"$items":               `path "$items": unexpected 'i'`,
+	} {
+		_, err := Get(d, path)
+		assert.EqualError(t, err, want, path)
+	}
+}
@@ -0,0 +1,175 @@
+// Package latency records how long every request of a run takes, grouped by
+// environment and endpoint, and compares the result with a baseline saved
+// by an earlier run. An endpoint whose median grew by more than a factor is
+// reported as a regression.
+package latency
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// uuidPattern matches the identifiers replaced by {uuid} in endpoint keys
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// Endpoint turns a request path into a template by replacing UUIDs and
+// numeric segments, so requests for different users share one key.
+func Endpoint(method, path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		seg = uuidPattern.ReplaceAllString(seg, "{uuid}")
+		if seg != "" && strings.Trim(seg, "0123456789") == "" {
+			seg = "{n}"
+		}
+		segments[i] = seg
+	}
+	return method + " " + strings.Join(segments, "/")
+}
+
+// Recorder collects request durations by key.
+type Recorder struct {
+	mu      sync.Mutex
+	samples map[string][]time.Duration
+}
+
+// NewRecorder returns an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{samples: map[string][]time.Duration{}}
+}
+
+// Add records one duration under key.
+func (r *Recorder) Add(key string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples[key] = append(r.samples[key], d)
+}
+
+// Baseline summarises the recorded durations.
+func (r *Recorder) Baseline() Baseline {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := make(Baseline, len(r.samples))
+	for key, samples := range r.samples {
+		sorted := append([]time.Duration(nil), samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		b[key] = Entry{
+			MedianMs: ms(sorted[(len(sorted)-1)/2]),
+			P90Ms:    ms(sorted[(len(sorted)*9+9)/10-1]),
+			Samples:  len(sorted),
+		}
+	}
+	return b
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Transport times every round trip up to the response headers. Key names
+// the request, e.g. "Dev GET /users/{uuid}"; requests it returns "" for are
+// not recorded.
+type Transport struct {
+	Next     http.RoundTripper
+	Key      func(req *http.Request) string
+	Recorder *Recorder
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.Next.RoundTrip(req)
+	if err == nil {
+		if key := t.Key(req); key != "" {
+			t.Recorder.Add(key, time.Since(start))
+		}
+	}
+	return resp, err
+}
+
+// CloseIdleConnections lets http.Client.CloseIdleConnections reach the wrapped transport.
+func (t *Transport) CloseIdleConnections() {
+	if c, ok := t.Next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// Entry is the summary of one endpoint on one environment.
+type Entry struct {
+	MedianMs float64 `json:"median_ms"`
+	P90Ms    float64 `json:"p90_ms"`
+	Samples  int     `json:"samples"`
+}
+
+// Baseline maps keys to their summaries, it is stored as JSON.
+type Baseline map[string]Entry
+
+// Load reads a baseline file. A missing file gives a nil baseline and no error.
+func Load(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return b, nil
+}
+
+// Save writes the baseline as indented JSON with sorted keys.
+func (b Baseline) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Thresholds decide what counts as a regression. An endpoint regresses when
+// its median exceeds Factor times the baseline median and is at least
+// MinDelta slower; both sides need MinSamples requests.
+type Thresholds struct {
+	Factor     float64
+	MinDelta   time.Duration
+	MinSamples int
+}
+
+// DefaultThresholds ignore sub-millisecond noise and single requests.
+var DefaultThresholds = Thresholds{Factor: 2, MinDelta: 5 * time.Millisecond, MinSamples: 3}
+
+// Regression is an endpoint slower than its baseline.
+type Regression struct {
+	Key      string
+	Baseline Entry
+	Current  Entry
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s: median %.1fms, baseline %.1fms (x%.1f, %d samples)",
+		r.Key, r.Current.MedianMs, r.Baseline.MedianMs, r.Current.MedianMs/r.Baseline.MedianMs, r.Current.Samples)
+}
+
+// Compare lists the keys of current that regressed against base, sorted by key.
+func Compare(base, current Baseline, th Thresholds) []Regression {
+	var out []Regression
+	for key, cur := range current {
+		old, ok := base[key]
+		if !ok || old.Samples < th.MinSamples || cur.Samples < th.MinSamples {
+			continue
+		}
+		if cur.MedianMs > th.Factor*old.MedianMs && cur.MedianMs-old.MedianMs >= ms(th.MinDelta) {
+			out = append(out, Regression{Key: key, Baseline: old, Current: cur})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
@@ -0,0 +1,92 @@
+package latency
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "GET /api/v1/users/{uuid}/cart", Endpoint("GET", "/api/v1/users/3fa85f64-5717-4562-b3fc-2c963f66afa6/cart"))
+	assert.Equal(t, "GET /api/v1/avatars/{uuid}.png", Endpoint("GET", "/api/v1/avatars/3fa85f64-5717-4562-b3fc-2c963f66afa6.png"))
+	assert.Equal(t, "GET /api/v1/games/{n}", Endpoint("GET", "/api/v1/games/42"))
+	assert.Equal(t, "POST /api/v1/users/login", Endpoint("POST", "/api/v1/users/login"))
+}
+
+func TestBaseline(t *testing.T) {
+	r := NewRecorder()
+	for i := 1; i <= 10; i++ {
+		r.Add("Dev GET /games", time.Duration(i)*time.Millisecond)
+	}
+	r.Add("Release GET /games", 4*time.Millisecond)
+
+	b := r.Baseline()
+	assert.Equal(t, Entry{MedianMs: 5, P90Ms: 9, Samples: 10}, b["Dev GET /games"])
+	assert.Equal(t, Entry{MedianMs: 4, P90Ms: 4, Samples: 1}, b["Release GET /games"])
+
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, b.Save(path))
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, b, loaded)
+
+	missing, err := Load(filepath.Join(t.TempDir(), "none.json"))
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestCompare(t *testing.T) {
+	base := Baseline{
+		"Dev GET /games":     {MedianMs: 10, Samples: 5},
+		"Dev GET /users":     {MedianMs: 0.2, Samples: 5},
+		"Dev GET /orders":    {MedianMs: 10, Samples: 1},
+		"Release GET /games": {MedianMs: 10, Samples: 5},
+	}
+	current := Baseline{
+		"Dev GET /games":     {MedianMs: 25, Samples: 5},  // x2.5 and 15ms slower
+		"Dev GET /users":     {MedianMs: 0.9, Samples: 5}, // x4.5 but under MinDelta
+		"Dev GET /orders":    {MedianMs: 50, Samples: 5},  // baseline too small
+		"Release GET /games": {MedianMs: 19, Samples: 5},  // under the factor
+		"Dev GET /new":       {MedianMs: 99, Samples: 5},  // no baseline
+	}
+	regs := Compare(base, current, DefaultThresholds)
+	require.Len(t, regs, 1)
+	assert.Equal(t, "Dev GET /games", regs[0].Key)
+	assert.Equal(t, "Dev GET /games: median 25.0ms, baseline 10.0ms (x2.5, 5 samples)", regs[0].String())
+
+	assert.Len(t, Compare(base, current, Thresholds{Factor: 1.5, MinSamples: 1}), 4)
+}
+
+func TestTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(2 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	r := NewRecorder()
+	client := &http.Client{Transport: &Transport{
+		Next:     http.DefaultTransport,
+		Recorder: r,
+		Key: func(req *http.Request) string {
+			if req.URL.Path == "/skip" {
+				return ""
+			}
+			return Endpoint(req.Method, req.URL.Path)
+		},
+	}}
+	for _, path := range []string{"/users/7", "/users/8", "/skip"} {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	b := r.Baseline()
+	require.Len(t, b, 1)
+	assert.Equal(t, 2, b["GET /users/{n}"].Samples)
+	assert.GreaterOrEqual(t, b["GET /users/{n}"].MedianMs, 2.0)
+}
@@ -0,0 +1,210 @@
+// Package load drives one request function at a fixed rate from a pool of
+// workers for a given duration and summarises the latencies: percentiles,
+// throughput and error rate.
+package load
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config is how hard one endpoint is driven. A zero Rate sends as fast as
+// the workers allow.
+type Config struct {
+	Rate        float64 // requests per second across all workers
+	Concurrency int
+	Duration    time.Duration
+}
+
+// DefaultConfig is a gentle load suitable for shared environments.
+var DefaultConfig = Config{Rate: 10, Concurrency: 4, Duration: 10 * time.Second}
+
+// interval is the time between two requests at Rate, at least 1ns so a
+// ticker can be made for it
+func (c Config) interval() time.Duration {
+	return max(time.Duration(float64(time.Second)/c.Rate), 1)
+}
+
+func (c Config) String() string {
+	return fmt.Sprintf("rate=%g,concurrency=%d,duration=%s", c.Rate, c.Concurrency, c.Duration)
+}
+
+// ParseConfig applies a "rate=20,concurrency=4,duration=10s" spec on top of
+// base. Keys left out keep the value from base.
+func ParseConfig(spec string, base Config) (Config, error) {
+	c := base
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("load config %q: want key=value", field)
+		}
+		var err error
+		switch key {
+		case "rate":
+			c.Rate, err = strconv.ParseFloat(value, 64)
+			switch {
+			case err != nil:
+			case math.IsNaN(c.Rate) || c.Rate < 0:
+				err = fmt.Errorf("must not be negative")
+			case c.Rate > float64(time.Second):
+				err = fmt.Errorf("must be at most %g, one request per nanosecond", float64(time.Second))
+			}
+		case "concurrency":
+			c.Concurrency, err = strconv.Atoi(value)
+			if err == nil && c.Concurrency < 1 {
+				err = fmt.Errorf("must be at least 1")
+			}
+		case "duration":
+			c.Duration, err = time.ParseDuration(value)
+			if err == nil && c.Duration <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		default:
+			err = fmt.Errorf("unknown key")
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("load config %s: %v", key, err)
+		}
+	}
+	return c, nil
+}
+
+// Request sends one request and returns its status code. An error counts
+// as a failed request, as does a status of 500 or above.
+type Request func() (int, error)
+
+// Stats summarises one run.
+type Stats struct {
+	Latencies []time.Duration // sorted
+	Errors    int
+	Elapsed   time.Duration
+}
+
+// Count is the number of requests sent.
+func (s Stats) Count() int {
+	return len(s.Latencies)
+}
+
+// Percentile returns the latency below which p percent of requests finished,
+// by the nearest-rank method.
+func (s Stats) Percentile(p float64) time.Duration {
+	if len(s.Latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(s.Latencies))))
+	return s.Latencies[min(max(rank-1, 0), len(s.Latencies)-1)]
+}
+
+// Throughput is the number of requests completed per second.
+func (s Stats) Throughput() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Count()) / s.Elapsed.Seconds()
+}
+
+// ErrorRate is the share of failed requests, between 0 and 1.
+func (s Stats) ErrorRate() float64 {
+	if s.Count() == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Count())
+}
+
+// Run drives req with c until the duration is over or ctx is done.
+func Run(ctx context.Context, c Config, req Request) Stats {
+	ctx, cancel := context.WithTimeout(ctx, c.Duration)
+	defer cancel()
+
+	// tokens paces the workers; without a rate it is never read
+	var tokens <-chan time.Time
+	if c.Rate > 0 {
+		ticker := time.NewTicker(c.interval())
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	var (
+		mu    sync.Mutex
+		stats Stats
+		wg    sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < max(c.Concurrency, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if tokens != nil {
+					select {
+					case <-tokens:
+					case <-ctx.Done():
+						return
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+
+				began := time.Now()
+				code, err := req()
+				took := time.Since(began)
+
+				mu.Lock()
+				stats.Latencies = append(stats.Latencies, took)
+				if err != nil || code >= 500 {
+					stats.Errors++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	stats.Elapsed = time.Since(start)
+	sort.Slice(stats.Latencies, func(i, j int) bool { return stats.Latencies[i] < stats.Latencies[j] })
+	return stats
+}
+
+// Row is the result of one endpoint on every environment, in the order of
+// the table's columns.
+type Row struct {
+	Endpoint string
+	Stats    []Stats
+}
+
+// Table renders rows side by side, one column group per environment.
+func Table(envs []string, rows []Row) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-16s", "endpoint")
+	for _, env := range envs {
+		fmt.Fprintf(&b, " | %-41s", env)
+	}
+	fmt.Fprintf(&b, "\n%-16s", "")
+	for range envs {
+		fmt.Fprintf(&b, " | %8s %8s %8s %7s %6s", "p50", "p90", "p99", "req/s", "err%")
+	}
+	b.WriteString("\n")
+	for _, row := range rows {
+		fmt.Fprintf(&b, "%-16s", row.Endpoint)
+		for _, s := range row.Stats {
+			fmt.Fprintf(&b, " | %8s %8s %8s %7.1f %5.1f%%",
+				ms(s.Percentile(50)), ms(s.Percentile(90)), ms(s.Percentile(99)), s.Throughput(), 100*s.ErrorRate())
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ms renders a latency in milliseconds with one decimal
+func ms(d time.Duration) string {
+	return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,97 @@
+package load
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig("rate=2.5, duration=3s", DefaultConfig)
+	require.NoError(t, err)
+	assert.Equal(t, Config{Rate: 2.5, Concurrency: DefaultConfig.Concurrency, Duration: 3 * time.Second}, c)
+	assert.Equal(t, "rate=2.5,concurrency=4,duration=3s", c.String())
+
+	c, err = ParseConfig("", DefaultConfig)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultConfig, c)
+
+	for _, spec := range []string{"rate", "rate=-1", "rate=NaN", "rate=Inf", "rate=1e12", "concurrency=0", "duration=0s", "duration=soon", "burst=3"} {
+		_, err := ParseConfig(spec, DefaultConfig)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestHugeRateDoesNotPanic(t *testing.T) {
+	c, err := ParseConfig("rate=1e9", DefaultConfig)
+	require.NoError(t, err)
+	assert.Equal(t, time.Nanosecond, c.interval())
+	assert.Equal(t, time.Nanosecond, Config{Rate: 1e12}.interval(), "a rate set in code is clamped")
+	stats := Run(context.Background(), Config{Rate: 1e12, Concurrency: 1, Duration: 10 * time.Millisecond}, func() (int, error) { return 200, nil })
+	assert.NotEmpty(t, stats.Latencies)
+}
+
+func TestPercentile(t *testing.T) {
+	s := Stats{}
+	for i := 1; i <= 100; i++ {
+		s.Latencies = append(s.Latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, s.Percentile(50))
+	assert.Equal(t, 90*time.Millisecond, s.Percentile(90))
+	assert.Equal(t, 99*time.Millisecond, s.Percentile(99))
+	assert.Equal(t, 100*time.Millisecond, s.Percentile(100))
+	assert.Equal(t, time.Millisecond, s.Percentile(0))
+	assert.Equal(t, time.Duration(0), Stats{}.Percentile(50))
+}
+
+func TestRunPacesAndCountsErrors(t *testing.T) {
+	var calls atomic.Int64
+	stats := Run(context.Background(), Config{Rate: 100, Concurrency: 4, Duration: 300 * time.Millisecond}, func() (int, error) {
+		switch calls.Add(1) % 4 {
+		case 0:
+			return 0, errors.New("connection reset")
+		case 1:
+			return 503, nil
+		default:
+			return 200, nil
+		}
+	})
+	// 100/s for 0.3s, with slack for slow machines
+	assert.InDelta(t, 30, stats.Count(), 10)
+	assert.InDelta(t, 0.5, stats.ErrorRate(), 0.1)
+	assert.InDelta(t, 100, stats.Throughput(), 35)
+	assert.True(t, stats.Percentile(50) <= stats.Percentile(99))
+}
+
+func TestRunUnpacedStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int64
+	stats := Run(ctx, Config{Concurrency: 2, Duration: time.Minute}, func() (int, error) {
+		if calls.Add(1) == 50 {
+			cancel()
+		}
+		return 200, nil
+	})
+	assert.GreaterOrEqual(t, stats.Count(), 50)
+	assert.Zero(t, stats.Errors)
+}
+
+func TestTable(t *testing.T) {
+	fast := Stats{Latencies: []time.Duration{time.Millisecond, 2 * time.Millisecond}, Elapsed: time.Second}
+	slow := Stats{Latencies: []time.Duration{40 * time.Millisecond}, Errors: 1, Elapsed: time.Second}
+	out := Table([]string{"Release", "Dev"}, []Row{{Endpoint: "list games", Stats: []Stats{fast, slow}}})
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "Release")
+	assert.Contains(t, lines[0], "Dev")
+	assert.Contains(t, lines[2], "1.0ms")
+	assert.Contains(t, lines[2], "40.0ms")
+	assert.Contains(t, lines[2], "100.0%")
+}
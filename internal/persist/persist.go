@@ -0,0 +1,234 @@
+// Package persist checks that a mutation the API accepted is visible when
+// the resource is read back. Several Dev bugs answer 200 with the changed
+// resource but never store the change; Plan maps a mutating call to the
+// matching read and Check compares what was asked for, what was answered
+// and what was stored. Transport does this after every successful mutation
+// passing through an http.Client.
+package persist
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"QA-Bug-Hunter-jr/internal/jsondiff"
+)
+
+// Answer is the status and decoded JSON body of a response.
+type Answer struct {
+	Status int
+	Body   interface{}
+}
+
+// Mutation is a mutating call that succeeded.
+type Mutation struct {
+	Method   string
+	Path     string                 // URL path, with whatever prefix the environment has
+	Request  map[string]interface{} // decoded request body, nil when there is none
+	Response interface{}            // decoded response body
+}
+
+// Verification is the read that shows whether a mutation persisted.
+type Verification struct {
+	Name  string // the mutation, e.g. "wishlist add"
+	Read  string // URL path to GET
+	check func(m Mutation, stored Answer) []string
+}
+
+// Check compares the mutation with the stored state and describes every gap.
+func (v Verification) Check(m Mutation, stored Answer) []string {
+	return v.check(m, stored)
+}
+
+// ignored are keys a read may legitimately answer differently from the mutation
+var ignored = jsondiff.Options{Ignore: map[string]bool{"updated_at": true}}
+
+// Plan returns the verification of a mutation, false for calls it does not know.
+func Plan(m Mutation) (Verification, bool) {
+	segs := strings.Split(strings.Trim(m.Path, "/"), "/")
+	n := len(segs)
+	at := func(i int) string {
+		if i < 0 || i >= n {
+			return ""
+		}
+		return segs[i]
+	}
+	// up drops the last k segments of the path
+	up := func(k int) string {
+		return "/" + strings.Join(segs[:n-k], "/")
+	}
+
+	switch {
+	case m.Method == "PATCH" && at(n-2) == "users":
+		return Verification{"user update", m.Path, checkUserUpdate}, true
+	case m.Method == "DELETE" && at(n-2) == "users":
+		return Verification{"user delete", m.Path, checkGone}, true
+	case m.Method == "PUT" && at(n-3) == "users" && at(n-1) == "avatar":
+		return Verification{"avatar upload", up(1), checkSame}, true
+	case m.Method == "POST" && at(n-4) == "users" && at(n-2) == "wishlist":
+		switch at(n - 1) {
+		case "add":
+			return Verification{"wishlist add", up(1), checkListed("items", "uuid", true)}, true
+		case "remove":
+			return Verification{"wishlist remove", up(1), checkListed("items", "uuid", false)}, true
+		}
+	case m.Method == "POST" && at(n-4) == "users" && at(n-2) == "cart":
+		switch at(n - 1) {
+		case "add":
+			return Verification{"cart add", up(1), checkListed("items", "item_uuid", true)}, true
+		case "change":
+			return Verification{"cart change", up(1), checkQuantity}, true
+		case "remove":
+			return Verification{"cart remove", up(1), checkListed("items", "item_uuid", false)}, true
+		case "clear":
+			return Verification{"cart clear", up(1), checkEmpty}, true
+		}
+	case m.Method == "POST" && at(n-3) == "users" && at(n-1) == "orders":
+		uuid, _ := field(m.Response, "uuid").(string)
+		if uuid == "" {
+			return Verification{}, false
+		}
+		return Verification{"order create", up(3) + "/orders/" + uuid, checkSame}, true
+	case m.Method == "PATCH" && at(n-3) == "orders" && at(n-1) == "status":
+		return Verification{"order status change", up(1), checkStatus}, true
+	case m.Method == "POST" && at(n-3) == "users" && at(n-1) == "payments":
+		order, _ := m.Request["order_uuid"].(string)
+		if order == "" {
+			return Verification{}, false
+		}
+		return Verification{"payment", up(3) + "/orders/" + order, checkPaid}, true
+	}
+	return Verification{}, false
+}
+
+// readable reports a read that did not find the resource
+func readable(stored Answer) []string {
+	if stored.Status < 200 || stored.Status >= 300 {
+		return []string{fmt.Sprintf("read back answers %d", stored.Status)}
+	}
+	return nil
+}
+
+// same lists how the answer of the mutation differs from the stored resource
+func same(m Mutation, stored Answer) []string {
+	var gaps []string
+	for _, d := range jsondiff.Compare(m.Response, stored.Body, ignored) {
+		gaps = append(gaps, d.Format("answered", "stored"))
+	}
+	return gaps
+}
+
+func checkSame(m Mutation, stored Answer) []string {
+	if gaps := readable(stored); gaps != nil {
+		return gaps
+	}
+	return same(m, stored)
+}
+
+func checkUserUpdate(m Mutation, stored Answer) []string {
+	if gaps := readable(stored); gaps != nil {
+		return gaps
+	}
+	var gaps []string
+	for _, key := range sortedKeys(m.Request) {
+		if key == "password" {
+			continue // never part of a user payload
+		}
+		if got := field(stored.Body, key); !reflect.DeepEqual(got, m.Request[key]) {
+			gaps = append(gaps, fmt.Sprintf("%s was set to %s, stored %s", key, render(m.Request[key]), render(got)))
+		}
+	}
+	return append(gaps, same(m, stored)...)
+}
+
+func checkGone(m Mutation, stored Answer) []string {
+	if stored.Status != 404 {
+		return []string{fmt.Sprintf("read back answers %d, want 404", stored.Status)}
+	}
+	return nil
+}
+
+// checkListed checks that the request's item_uuid is, or is no longer, in a list of the stored resource
+func checkListed(list, key string, want bool) func(Mutation, Answer) []string {
+	return func(m Mutation, stored Answer) []string {
+		if gaps := readable(stored); gaps != nil {
+			return gaps
+		}
+		item, _ := m.Request["item_uuid"].(string)
+		var gaps []string
+		if _, found := find(field(stored.Body, list), key, item); found != want {
+			if want {
+				gaps = append(gaps, fmt.Sprintf("item %s is not stored", item))
+			} else {
+				gaps = append(gaps, fmt.Sprintf("item %s is still stored", item))
+			}
+		}
+		return append(gaps, same(m, stored)...)
+	}
+}
+
+func checkQuantity(m Mutation, stored Answer) []string {
+	if gaps := readable(stored); gaps != nil {
+		return gaps
+	}
+	item, _ := m.Request["item_uuid"].(string)
+	var gaps []string
+	line, found := find(field(stored.Body, "items"), "item_uuid", item)
+	switch {
+	case !found:
+		gaps = append(gaps, fmt.Sprintf("item %s is not stored", item))
+	case !reflect.DeepEqual(field(line, "quantity"), m.Request["quantity"]):
+		gaps = append(gaps, fmt.Sprintf("item %s quantity was set to %s, stored %s", item, render(m.Request["quantity"]), render(field(line, "quantity"))))
+	}
+	return append(gaps, same(m, stored)...)
+}
+
+func checkEmpty(m Mutation, stored Answer) []string {
+	if gaps := readable(stored); gaps != nil {
+		return gaps
+	}
+	var gaps []string
+	if items, _ := field(stored.Body, "items").([]interface{}); len(items) > 0 {
+		gaps = append(gaps, fmt.Sprintf("%d items are still stored", len(items)))
+	}
+	return append(gaps, same(m, stored)...)
+}
+
+func checkStatus(m Mutation, stored Answer) []string {
+	if gaps := readable(stored); gaps != nil {
+		return gaps
+	}
+	var gaps []string
+	if got := field(stored.Body, "status"); !reflect.DeepEqual(got, m.Request["status"]) {
+		gaps = append(gaps, fmt.Sprintf("status was set to %s, stored %s", render(m.Request["status"]), render(got)))
+	}
+	return append(gaps, same(m, stored)...)
+}
+
+// checkPaid checks the order of a payment, the payment itself is a new resource
+func checkPaid(m Mutation, stored Answer) []string {
+	if gaps := readable(stored); gaps != nil {
+		return gaps
+	}
+	if got := field(stored.Body, "status"); got != "paid" {
+		return []string{fmt.Sprintf("order %v is stored as %s after the payment, want \"paid\"", m.Request["order_uuid"], render(got))}
+	}
+	return nil
+}
+
+// field returns a key of a JSON object, nil for anything else
+func field(v interface{}, key string) interface{} {
+	obj, _ := v.(map[string]interface{})
+	return obj[key]
+}
+
+// find returns the element of a JSON array whose key equals value
+func find(list interface{}, key, value string) (interface{}, bool) {
+	elems, _ := list.([]interface{})
+	for _, e := range elems {
+		if field(e, key) == value {
+			return e, true
+		}
+	}
+	return nil, false
+}
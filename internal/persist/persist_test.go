@@ -0,0 +1,270 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/avatarkit"
+	"QA-Bug-Hunter-jr/internal/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	for _, c := range []struct {
+		m          Mutation
+		name, read string
+	}{
+		{Mutation{Method: "PATCH", Path: "/api/v1/users/u1"}, "user update", "/api/v1/users/u1"},
+		{Mutation{Method: "DELETE", Path: "/api/v1/users/u1"}, "user delete", "/api/v1/users/u1"},
+		{Mutation{Method: "PUT", Path: "/api/v1/users/u1/avatar"}, "avatar upload", "/api/v1/users/u1"},
+		{Mutation{Method: "POST", Path: "/api/v1/users/u1/wishlist/add"}, "wishlist add", "/api/v1/users/u1/wishlist"},
+		{Mutation{Method: "POST", Path: "/api/v1/users/u1/wishlist/remove"}, "wishlist remove", "/api/v1/users/u1/wishlist"},
+		{Mutation{Method: "POST", Path: "/api/v1/users/u1/cart/change"}, "cart change", "/api/v1/users/u1/cart"},
+		{Mutation{Method: "POST", Path: "/api/v1/users/u1/cart/clear"}, "cart clear", "/api/v1/users/u1/cart"},
+		{Mutation{Method: "POST", Path: "/api/v1/users/u1/orders", Response: map[string]interface{}{"uuid": "o1"}}, "order create", "/api/v1/orders/o1"},
+		{Mutation{Method: "PATCH", Path: "/api/v1/orders/o1/status"}, "order status change", "/api/v1/orders/o1"},
+		{Mutation{Method: "POST", Path: "/api/v1/users/u1/payments", Request: map[string]interface{}{"order_uuid": "o1"}}, "payment", "/api/v1/orders/o1"},
+	} {
+		v, ok := Plan(c.m)
+		if assert.True(t, ok, c.m.Path) {
+			assert.Equal(t, c.name, v.Name, c.m.Path)
+			assert.Equal(t, c.read, v.Read, c.m.Path)
+		}
+	}
+	for _, m := range []Mutation{
+		{Method: "POST", Path: "/api/v1/users"},
+		{Method: "POST", Path: "/api/v1/users/login"},
+		{Method: "POST", Path: "/api/v1/users/u1/orders", Response: map[string]interface{}{"code": 400.0}},
+		{Method: "POST", Path: "/api/v1/users/u1/wishlist/share"},
+	} {
+		_, ok := Plan(m)
+		assert.False(t, ok, m.Path)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	item := map[string]interface{}{"item_uuid": "g1", "quantity": 2.0}
+	cart := func(items ...interface{}) Answer {
+		return Answer{Status: 200, Body: map[string]interface{}{"items": items}}
+	}
+	add := Mutation{Method: "POST", Path: "/users/u1/cart/add", Request: map[string]interface{}{"item_uuid": "g1", "quantity": 2.0},
+		Response: cart(item).Body}
+	v, _ := Plan(add)
+	assert.Empty(t, v.Check(add, cart(item)))
+	assert.Equal(t, []string{"item g1 is not stored", `$.items[0]: only on answered, {"item_uuid":"g1","quantity":2}`}, v.Check(add, cart()))
+	assert.Equal(t, []string{"read back answers 404"}, v.Check(add, Answer{Status: 404}))
+
+	change := add
+	change.Path = "/users/u1/cart/change"
+	change.Request = map[string]interface{}{"item_uuid": "g1", "quantity": 3.0}
+	v, _ = Plan(change)
+	assert.Contains(t, v.Check(change, cart(item)), "item g1 quantity was set to 3, stored 2")
+
+	update := Mutation{Method: "PATCH", Path: "/users/u1", Request: map[string]interface{}{"name": "New", "password": "secret"},
+		Response: map[string]interface{}{"name": "New", "updated_at": "b"}}
+	v, _ = Plan(update)
+	assert.Equal(t, []string{`name was set to "New", stored "Old"`, `$.name: answered "New", stored "Old"`},
+		v.Check(update, Answer{Status: 200, Body: map[string]interface{}{"name": "Old", "updated_at": "a"}}))
+
+	del := Mutation{Method: "DELETE", Path: "/users/u1"}
+	v, _ = Plan(del)
+	assert.Equal(t, []string{"read back answers 200, want 404"}, v.Check(del, Answer{Status: 200}))
+}
+
+// fakes starts a fake Release and Dev sharing one data set
+func fakes(t *testing.T) (release, dev string) {
+	srv := fakeapi.New(fakeapi.Options{})
+	rel := httptest.NewServer(srv)
+	d := httptest.NewServer(srv.Share(fakeapi.Options{Dev: true}))
+	t.Cleanup(rel.Close)
+	t.Cleanup(d.Close)
+	return rel.URL + fakeapi.BasePath, d.URL + fakeapi.BasePath
+}
+
+// gaps collects what a transport reports
+type gaps struct {
+	sync.Mutex
+	list []string
+}
+
+func (g *gaps) report(req *http.Request, v Verification, gap string) {
+	g.Lock()
+	defer g.Unlock()
+	g.list = append(g.list, fmt.Sprintf("%s: %s", v.Name, gap))
+}
+
+// client sends JSON requests through a Transport
+type client struct {
+	t    *testing.T
+	http *http.Client
+}
+
+func (c client) do(method, url string, body interface{}) map[string]interface{} {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	require.NoError(c.t, err)
+	req.Header.Set("Authorization", "Bearer secret:qa@example.com")
+	req.Header.Set("X-Task-Id", "api-test")
+	resp, err := c.http.Do(req)
+	require.NoError(c.t, err)
+	defer resp.Body.Close()
+	var out map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&out)
+	return out
+}
+
+func (c client) upload(url string) {
+	img, err := avatarkit.Generate("png", 8, 8)
+	require.NoError(c.t, err)
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	part, err := w.CreateFormFile("avatar_file", img.Filename)
+	require.NoError(c.t, err)
+	part.Write(img.Data)
+	w.Close()
+	req, err := http.NewRequest("PUT", url, &b)
+	require.NoError(c.t, err)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer secret:qa@example.com")
+	resp, err := c.http.Do(req)
+	require.NoError(c.t, err)
+	resp.Body.Close()
+}
+
+// exercise runs every mutation the package knows against base
+func exercise(c client, base string) {
+	user := c.do("POST", base+"/users", map[string]interface{}{"email": "p@example.com", "password": "password", "name": "P", "nickname": "persist"})
+	u := base + "/users/" + user["uuid"].(string)
+	games := c.do("GET", base+"/games?limit=2", nil)["games"].([]interface{})
+	g1 := games[0].(map[string]interface{})["uuid"]
+	g2 := games[1].(map[string]interface{})["uuid"]
+
+	c.do("PATCH", u, map[string]interface{}{"name": "Persisted"})
+	c.upload(u + "/avatar")
+	c.do("POST", u+"/wishlist/add", map[string]interface{}{"item_uuid": g1})
+	c.do("POST", u+"/wishlist/remove", map[string]interface{}{"item_uuid": g1})
+	c.do("POST", u+"/cart/add", map[string]interface{}{"item_uuid": g1, "quantity": 1})
+	c.do("POST", u+"/cart/add", map[string]interface{}{"item_uuid": g2, "quantity": 1})
+	c.do("POST", u+"/cart/change", map[string]interface{}{"item_uuid": g1, "quantity": 3})
+	c.do("POST", u+"/cart/remove", map[string]interface{}{"item_uuid": g2})
+	c.do("POST", u+"/cart/add", map[string]interface{}{"item_uuid": g2, "quantity": 1}) // Dev emptied the cart, API-14
+	c.do("POST", u+"/cart/clear", nil)
+	order := c.do("POST", u+"/orders", map[string]interface{}{"items": []interface{}{map[string]interface{}{"item_uuid": g1, "quantity": 1}}})
+	c.do("POST", u+"/payments", map[string]interface{}{"order_uuid": order["uuid"], "payment_method": "mir_pay"})
+	other := c.do("POST", u+"/orders", map[string]interface{}{"items": []interface{}{map[string]interface{}{"item_uuid": g2, "quantity": 1}}})
+	c.do("PATCH", base+"/orders/"+other["uuid"].(string)+"/status", map[string]interface{}{"status": "canceled"})
+	c.do("DELETE", u, nil)
+}
+
+func TestTransport(t *testing.T) {
+	release, dev := fakes(t)
+
+	var g gaps
+	c := client{t, &http.Client{Transport: &Transport{Next: http.DefaultTransport, Report: g.report}}}
+	exercise(c, release)
+	assert.Empty(t, g.list, "Release persists every mutation")
+
+	g.list = nil
+	exercise(c, dev)
+	sort.Strings(g.list)
+	require.Len(t, g.list, 3, "%v", g.list)
+	assert.Regexp(t, `^avatar upload: \$\.avatar_url: answered "http://.*", stored "https://gravatar.com/.*"$`, g.list[0], "API-11")
+	assert.Equal(t, "cart clear: 1 items are still stored", g.list[1], "API-15")
+	assert.Regexp(t, `^wishlist remove: item .* is still stored$`, g.list[2], "API-8")
+}
+
+func TestTransportReturnsReadErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(`{"items":[`))
+	}))
+	defer server.Close()
+
+	var g gaps
+	c := &http.Client{Transport: &Transport{Next: http.DefaultTransport, Report: g.report}}
+	_, err := c.Post(server.URL+"/users/u1/cart/clear", "application/json", nil)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "a short body is not passed on as an answer")
+	assert.Empty(t, g.list)
+}
+
+// counting passes requests on and notes their methods
+type counting struct {
+	next    http.RoundTripper
+	mu      sync.Mutex
+	methods []string
+}
+
+func (c *counting) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.methods = append(c.methods, req.Method)
+	c.mu.Unlock()
+	return c.next.RoundTrip(req)
+}
+
+func TestTransportReadsBackThroughRead(t *testing.T) {
+	release, _ := fakes(t)
+	setup := client{t, http.DefaultClient}
+	user := setup.do("POST", release+"/users", map[string]interface{}{"email": "r@example.com", "password": "password", "name": "R", "nickname": "readback"})
+
+	var g gaps
+	next := &counting{next: http.DefaultTransport}
+	read := &counting{next: http.DefaultTransport}
+	c := client{t, &http.Client{Transport: &Transport{Next: next, Read: read, Report: g.report}}}
+	c.do("PATCH", release+"/users/"+user["uuid"].(string), map[string]interface{}{"name": "Read"})
+	assert.Equal(t, []string{"PATCH"}, next.methods, "the read back does not pass through Next")
+	assert.Equal(t, []string{"GET"}, read.methods)
+	assert.Empty(t, g.list)
+}
+
+// blocking holds mutations until released, to overlap two of them
+type blocking struct {
+	next    http.RoundTripper
+	arrived chan struct{}
+	release chan struct{}
+}
+
+func (b *blocking) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		b.arrived <- struct{}{}
+		<-b.release
+	}
+	return b.next.RoundTrip(req)
+}
+
+func TestTransportSkipsOverlappingMutations(t *testing.T) {
+	release, _ := fakes(t)
+	var g gaps
+	setup := client{t, http.DefaultClient}
+	user := setup.do("POST", release+"/users", map[string]interface{}{"email": "o@example.com", "password": "password", "name": "O", "nickname": "overlap"})
+	u := release + "/users/" + user["uuid"].(string)
+	game := setup.do("GET", release+"/games?limit=1", nil)["games"].([]interface{})[0].(map[string]interface{})["uuid"]
+
+	b := &blocking{next: http.DefaultTransport, arrived: make(chan struct{}), release: make(chan struct{})}
+	c := client{t, &http.Client{Transport: &Transport{Next: b, Report: g.report}}}
+	var wg sync.WaitGroup
+	for _, path := range []string{"/cart/add", "/cart/clear"} {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			c.do("POST", u+path, map[string]interface{}{"item_uuid": game, "quantity": 1})
+		}(path)
+	}
+	<-b.arrived
+	<-b.arrived
+	close(b.release)
+	wg.Wait()
+	assert.Empty(t, g.list, "either call may see the other's change")
+}
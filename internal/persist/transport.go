@@ -0,0 +1,188 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Transport is an http.RoundTripper that reads the resource back after
+// every successful mutation and hands each gap to Report. The read carries
+// the Authorization and X-Task-Id headers of the mutation. Bodies are
+// buffered and restored, so callers read them as usual; a body that cannot
+// be read in full fails the request with the read error.
+//
+// Mutations of the same user or order that overlap in time are not
+// checked, the stored state may then rightly show the other call's change.
+type Transport struct {
+	Next http.RoundTripper
+	// Read sends the read backs, Next when nil; it keeps them out of
+	// transports that should only see the caller's requests
+	Read   http.RoundTripper
+	Report func(req *http.Request, v Verification, gap string)
+
+	mu      sync.Mutex
+	running map[string]map[*flight]bool // mutations in flight by owner
+}
+
+// flight is one mutation between sending it and checking the read back
+type flight struct {
+	overlapped bool
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return t.Next.RoundTrip(req)
+	}
+	m := Mutation{Method: req.Method, Path: req.URL.Path}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			json.Unmarshal(data, &m.Request)
+		}
+	}
+
+	key := owner(req.URL.Path)
+	f := t.begin(key)
+	defer t.end(key, f)
+
+	resp, err := t.Next.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err // a truncated or reset body is the caller's failure, not a partial answer
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if json.Unmarshal(data, &m.Response) != nil {
+		return resp, nil
+	}
+	v, ok := Plan(m)
+	if !ok {
+		return resp, nil
+	}
+
+	stored, err := t.read(req, v.Read)
+	if err != nil {
+		t.Report(req, v, fmt.Sprintf("read back %s: %v", v.Read, err))
+		return resp, nil
+	}
+	if t.overlapped(f) {
+		return resp, nil
+	}
+	for _, gap := range v.Check(m, stored) {
+		t.Report(req, v, gap)
+	}
+	return resp, nil
+}
+
+// read GETs path on the host of req with its credentials
+func (t *Transport) read(req *http.Request, path string) (Answer, error) {
+	u := *req.URL
+	u.Path, u.RawPath, u.RawQuery = path, "", ""
+	get, err := http.NewRequestWithContext(req.Context(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Answer{}, err
+	}
+	for _, h := range []string{"Authorization", "X-Task-Id"} {
+		if v := req.Header.Get(h); v != "" {
+			get.Header.Set(h, v)
+		}
+	}
+	rt := t.Read
+	if rt == nil {
+		rt = t.Next
+	}
+	resp, err := rt.RoundTrip(get)
+	if err != nil {
+		return Answer{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Answer{}, err
+	}
+	a := Answer{Status: resp.StatusCode}
+	json.Unmarshal(data, &a.Body)
+	return a, nil
+}
+
+// owner names the user or order a path belongs to, e.g. "users/<uuid>"
+func owner(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segs) - 2; i >= 0; i-- {
+		if segs[i] == "users" || segs[i] == "orders" {
+			return segs[i] + "/" + segs[i+1]
+		}
+	}
+	return path
+}
+
+// begin registers a mutation of key, marking it and every other one in flight as overlapped
+func (t *Transport) begin(key string) *flight {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.running == nil {
+		t.running = map[string]map[*flight]bool{}
+	}
+	if t.running[key] == nil {
+		t.running[key] = map[*flight]bool{}
+	}
+	f := &flight{}
+	for other := range t.running[key] {
+		other.overlapped, f.overlapped = true, true
+	}
+	t.running[key][f] = true
+	return f
+}
+
+func (t *Transport) end(key string, f *flight) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.running[key], f)
+	if len(t.running[key]) == 0 {
+		delete(t.running, key)
+	}
+}
+
+// overlapped reports whether another mutation of the same owner ran while f did
+func (t *Transport) overlapped(f *flight) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return f.overlapped
+}
+
+// CloseIdleConnections forwards to the wrapped transport, see http.Client.CloseIdleConnections.
+func (t *Transport) CloseIdleConnections() {
+	if c, ok := t.Next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func render(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(data) > 80 {
+		return string(data[:77]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,273 @@
+// Package probe infers the validation rules of an API field by sending
+// candidate values and observing which ones are accepted. Lengths and
+// numeric ranges are found by binary search, character sets and formats by
+// sampling one representative value per class.
+package probe
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Verdict is how the API treated a probed value.
+type Verdict string
+
+const (
+	Accepted    Verdict = "accepted"
+	Rejected    Verdict = "rejected"
+	NotFound    Verdict = "not found"
+	ServerError Verdict = "server error"
+)
+
+// VerdictFor maps a response status to a verdict. A conflict means the value
+// passed validation and clashed with existing data, so it counts as accepted.
+func VerdictFor(status int) Verdict {
+	switch {
+	case status >= 500:
+		return ServerError
+	case status == http.StatusNotFound:
+		return NotFound
+	case status >= 200 && status < 300, status == http.StatusConflict:
+		return Accepted
+	default:
+		return Rejected
+	}
+}
+
+// StringProbe sends value in the probed field and reports the verdict.
+type StringProbe func(value string) (Verdict, error)
+
+// IntProbe sends n in the probed field and reports the verdict.
+type IntProbe func(n int) (Verdict, error)
+
+// Bound is one inferred edge of a length or range. Open means the search
+// limit itself was accepted, so the real edge lies beyond Value.
+type Bound struct {
+	Value int
+	Open  bool
+}
+
+// Span is an inferred accepted interval. Found is false when no probed value
+// was accepted at all.
+type Span struct {
+	Found    bool
+	Min, Max Bound
+}
+
+func (s Span) String() string {
+	if !s.Found {
+		return "none accepted"
+	}
+	lo, hi := fmt.Sprint(s.Min.Value), fmt.Sprint(s.Max.Value)
+	if s.Min.Open {
+		lo = "<=" + lo
+	}
+	if s.Max.Open {
+		hi = ">=" + hi
+	}
+	return lo + ".." + hi
+}
+
+// Lengths infers the accepted length interval of a string field. fill builds
+// an otherwise valid value of exactly n characters, limit caps the search.
+func Lengths(p StringProbe, fill func(n int) string, limit int) (Span, error) {
+	return searchSpan(func(n int) (bool, error) {
+		v, err := p(fill(n))
+		return v == Accepted, err
+	}, 0, limit, []int{8, 1, 16, 32, 64, 4, 2, 0})
+}
+
+// Range infers the accepted interval of an integer field within [lo, hi].
+func Range(p IntProbe, lo, hi int) (Span, error) {
+	return searchSpan(func(n int) (bool, error) {
+		v, err := p(n)
+		return v == Accepted, err
+	}, lo, hi, []int{1, 2, 10, 0, 100, -1})
+}
+
+// searchSpan finds an accepted seed among candidates, then binary searches the
+// lower and upper edges assuming acceptance is contiguous around the seed.
+func searchSpan(accept func(n int) (bool, error), lo, hi int, candidates []int) (Span, error) {
+	seed, found := 0, false
+	for _, c := range candidates {
+		if c < lo || c > hi {
+			continue
+		}
+		ok, err := accept(c)
+		if err != nil {
+			return Span{}, err
+		}
+		if ok {
+			seed, found = c, true
+			break
+		}
+	}
+	if !found {
+		return Span{}, nil
+	}
+
+	span := Span{Found: true}
+
+	// lowest accepted value in [lo, seed]
+	if ok, err := accept(lo); err != nil {
+		return Span{}, err
+	} else if ok {
+		// a zero length is a real floor, any other limit may hide smaller values
+		span.Min = Bound{Value: lo, Open: lo != 0}
+	} else {
+		bad, good := lo, seed
+		for good-bad > 1 {
+			mid := bad + (good-bad)/2
+			ok, err := accept(mid)
+			if err != nil {
+				return Span{}, err
+			}
+			if ok {
+				good = mid
+			} else {
+				bad = mid
+			}
+		}
+		span.Min = Bound{Value: good}
+	}
+
+	// highest accepted value in [seed, hi]
+	if ok, err := accept(hi); err != nil {
+		return Span{}, err
+	} else if ok {
+		span.Max = Bound{Value: hi, Open: true}
+	} else {
+		good, bad := seed, hi
+		for bad-good > 1 {
+			mid := good + (bad-good)/2
+			ok, err := accept(mid)
+			if err != nil {
+				return Span{}, err
+			}
+			if ok {
+				good = mid
+			} else {
+				bad = mid
+			}
+		}
+		span.Max = Bound{Value: good}
+	}
+
+	return span, nil
+}
+
+// Sample is a named representative value, e.g. a character class or a format.
+type Sample struct {
+	Name  string
+	Value string
+}
+
+// CharClasses are single characters standing for the classes a field may allow.
+var CharClasses = []Sample{
+	{"lower", "a"},
+	{"upper", "A"},
+	{"digit", "7"},
+	{"space", " "},
+	{"underscore", "_"},
+	{"hyphen", "-"},
+	{"dot", "."},
+	{"plus", "+"},
+	{"at", "@"},
+	{"quote", "'"},
+	{"angle", "<"},
+	{"slash", "/"},
+	{"latin-1", "é"},
+	{"cjk", "名"},
+	{"emoji", "😀"},
+	{"tab", "\t"},
+	{"nul", "\x00"},
+}
+
+// Samples probes every sample through build, which turns the sample value
+// into a complete field value, and records the verdict by sample name.
+func Samples(p StringProbe, samples []Sample, build func(sample string) string) (map[string]Verdict, error) {
+	out := make(map[string]Verdict, len(samples))
+	for _, s := range samples {
+		v, err := p(build(s.Value))
+		if err != nil {
+			return nil, err
+		}
+		out[s.Name] = v
+	}
+	return out, nil
+}
+
+// FieldRules collects everything inferred about one field on one environment.
+type FieldRules struct {
+	Field   string
+	Length  *Span
+	Range   *Span
+	Classes map[string]Verdict
+	Formats map[string]Verdict
+}
+
+// Lines renders the rules as "aspect: value" lines in a stable order.
+func (r FieldRules) Lines() []string {
+	var lines []string
+	if r.Length != nil {
+		lines = append(lines, "length: "+r.Length.String())
+	}
+	if r.Range != nil {
+		lines = append(lines, "range: "+r.Range.String())
+	}
+	lines = append(lines, verdictLines("chars", r.Classes)...)
+	lines = append(lines, verdictLines("format", r.Formats)...)
+	return lines
+}
+
+func verdictLines(aspect string, verdicts map[string]Verdict) []string {
+	if len(verdicts) == 0 {
+		return nil
+	}
+	byVerdict := map[Verdict][]string{}
+	for name, v := range verdicts {
+		byVerdict[v] = append(byVerdict[v], name)
+	}
+	var lines []string
+	for _, v := range []Verdict{Accepted, Rejected, NotFound, ServerError} {
+		names := byVerdict[v]
+		if len(names) == 0 {
+			continue
+		}
+		sort.Strings(names)
+		lines = append(lines, fmt.Sprintf("%s %s: %s", aspect, v, strings.Join(names, ", ")))
+	}
+	return lines
+}
+
+// Diff lists the aspects on which two environments disagree about a field.
+func Diff(a, b FieldRules) []string {
+	var diffs []string
+	if a.Length != nil && b.Length != nil && *a.Length != *b.Length {
+		diffs = append(diffs, fmt.Sprintf("length %s vs %s", a.Length, b.Length))
+	}
+	if a.Range != nil && b.Range != nil && *a.Range != *b.Range {
+		diffs = append(diffs, fmt.Sprintf("range %s vs %s", a.Range, b.Range))
+	}
+	diffs = append(diffs, diffVerdicts("chars", a.Classes, b.Classes)...)
+	diffs = append(diffs, diffVerdicts("format", a.Formats, b.Formats)...)
+	return diffs
+}
+
+func diffVerdicts(aspect string, a, b map[string]Verdict) []string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diffs []string
+	for _, name := range names {
+		if bv, ok := b[name]; ok && bv != a[name] {
+			diffs = append(diffs, fmt.Sprintf("%s %s: %s vs %s", aspect, name, a[name], bv))
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,99 @@
+package probe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lengthProbe accepts strings whose length lies in [min, max] and counts calls
+func lengthProbe(min, max int, calls *int) StringProbe {
+	return func(v string) (Verdict, error) {
+		*calls++
+		if n := len(v); n >= min && n <= max {
+			return Accepted, nil
+		}
+		return Rejected, nil
+	}
+}
+
+func fillA(n int) string { return strings.Repeat("a", n) }
+
+func TestLengths(t *testing.T) {
+	calls := 0
+	span, err := Lengths(lengthProbe(6, 100, &calls), fillA, 4096)
+	assert.NoError(t, err)
+	assert.Equal(t, Span{Found: true, Min: Bound{Value: 6}, Max: Bound{Value: 100}}, span)
+	assert.Equal(t, "6..100", span.String())
+	assert.Less(t, calls, 30, "binary search should need few probes")
+}
+
+func TestLengthsOpenEnded(t *testing.T) {
+	calls := 0
+	span, err := Lengths(lengthProbe(0, 1<<20, &calls), fillA, 512)
+	assert.NoError(t, err)
+	assert.Equal(t, "0..>=512", span.String())
+}
+
+func TestLengthsNoneAccepted(t *testing.T) {
+	calls := 0
+	span, err := Lengths(lengthProbe(5000, 6000, &calls), fillA, 512)
+	assert.NoError(t, err)
+	assert.False(t, span.Found)
+	assert.Equal(t, "none accepted", span.String())
+}
+
+func TestRange(t *testing.T) {
+	span, err := Range(func(n int) (Verdict, error) {
+		if n >= 1 && n <= 99 {
+			return Accepted, nil
+		}
+		return Rejected, nil
+	}, -1<<31, 1<<31)
+	assert.NoError(t, err)
+	assert.Equal(t, "1..99", span.String())
+
+	span, err = Range(func(n int) (Verdict, error) { return Accepted, nil }, -10, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, "<=-10..>=10", span.String())
+}
+
+func TestSamplesAndDiff(t *testing.T) {
+	alnum := func(v string) (Verdict, error) {
+		for _, c := range v {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+				return Rejected, nil
+			}
+		}
+		return Accepted, nil
+	}
+	lenient := func(v string) (Verdict, error) {
+		if strings.Contains(v, "\x00") {
+			return ServerError, nil
+		}
+		return Accepted, nil
+	}
+	build := func(s string) string { return "nick" + s }
+
+	strict, err := Samples(alnum, CharClasses, build)
+	assert.NoError(t, err)
+	loose, err := Samples(lenient, CharClasses, build)
+	assert.NoError(t, err)
+
+	a := FieldRules{Field: "nickname", Classes: strict}
+	b := FieldRules{Field: "nickname", Classes: loose}
+	assert.Contains(t, a.Lines(), "chars accepted: digit, lower, upper")
+	assert.Contains(t, Diff(a, b), "chars nul: rejected vs server error")
+	assert.Contains(t, Diff(a, b), "chars space: rejected vs accepted")
+	assert.NotContains(t, Diff(a, b), "chars lower: accepted vs accepted")
+}
+
+func TestVerdictFor(t *testing.T) {
+	assert.Equal(t, Accepted, VerdictFor(200))
+	assert.Equal(t, Accepted, VerdictFor(409))
+	assert.Equal(t, Rejected, VerdictFor(400))
+	assert.Equal(t, Rejected, VerdictFor(422))
+	assert.Equal(t, NotFound, VerdictFor(404))
+	assert.Equal(t, ServerError, VerdictFor(502))
+}
@@ -0,0 +1,168 @@
+// Package proptest is a small property-based testing helper: it generates
+// random inputs, checks a property against each one and shrinks the first
+// failing input down to a minimal counterexample.
+package proptest
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Gen produces random values of T and proposes simpler variants of a value.
+type Gen[T any] struct {
+	// Generate returns a new random value.
+	Generate func(r *rand.Rand) T
+	// Shrink returns candidates that are "smaller" than v, simplest first.
+	// A nil Shrink disables shrinking.
+	Shrink func(v T) []T
+}
+
+// Config controls a property run.
+type Config struct {
+	Seed       int64 // 0 picks BUGHUNTER_SEED or the current time
+	Runs       int   // number of generated inputs, 100 if zero
+	MaxShrinks int   // upper bound on successful shrink steps, 200 if zero
+}
+
+// Failure describes the minimal counterexample found for a property.
+type Failure[T any] struct {
+	Seed     int64
+	Run      int   // 1-based index of the first failing input
+	Original T     // first failing input as generated
+	Minimal  T     // input after shrinking
+	Err      error // property error for Minimal
+	Shrinks  int   // number of successful shrink steps
+}
+
+func (f *Failure[T]) String() string {
+	return fmt.Sprintf("seed=%d run=%d shrinks=%d\n  original: %+v\n  minimal:  %+v\n  error:    %v",
+		f.Seed, f.Run, f.Shrinks, f.Original, f.Minimal, f.Err)
+}
+
+// Check runs prop against cfg.Runs generated values and returns nil when
+// every value satisfies it, or the shrunk counterexample otherwise.
+func Check[T any](cfg Config, gen Gen[T], prop func(T) error) *Failure[T] {
+	cfg = cfg.withDefaults()
+	r := rand.New(rand.NewSource(cfg.Seed))
+
+	for run := 1; run <= cfg.Runs; run++ {
+		v := gen.Generate(r)
+		err := prop(v)
+		if err == nil {
+			continue
+		}
+
+		minimal, minErr, steps := shrink(cfg, gen, prop, v, err)
+		return &Failure[T]{
+			Seed:     cfg.Seed,
+			Run:      run,
+			Original: v,
+			Minimal:  minimal,
+			Err:      minErr,
+			Shrinks:  steps,
+		}
+	}
+
+	return nil
+}
+
+// Sample returns cfg.Runs generated values without checking anything, for
+// statistics that must not be skewed by shrinking or stop at a failure.
+func Sample[T any](cfg Config, gen Gen[T]) []T {
+	cfg = cfg.withDefaults()
+	r := rand.New(rand.NewSource(cfg.Seed))
+	values := make([]T, cfg.Runs)
+	for i := range values {
+		values[i] = gen.Generate(r)
+	}
+	return values
+}
+
+// shrink greedily replaces v by the first simpler candidate that still fails.
+func shrink[T any](cfg Config, gen Gen[T], prop func(T) error, v T, err error) (T, error, int) {
+	if gen.Shrink == nil {
+		return v, err, 0
+	}
+
+	steps := 0
+	for steps < cfg.MaxShrinks {
+		progressed := false
+		for _, c := range gen.Shrink(v) {
+			if cErr := prop(c); cErr != nil {
+				v, err = c, cErr
+				steps++
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	return v, err, steps
+}
+
+func (c Config) withDefaults() Config {
+	if c.Seed == 0 {
+		if s, err := strconv.ParseInt(os.Getenv("BUGHUNTER_SEED"), 10, 64); err == nil && s != 0 {
+			c.Seed = s
+		} else {
+			c.Seed = time.Now().UnixNano()
+		}
+	}
+	if c.Runs <= 0 {
+		c.Runs = 100
+	}
+	if c.MaxShrinks <= 0 {
+		c.MaxShrinks = 200
+	}
+	return c
+}
+
+// ShrinkInt proposes integers closer to zero: 0, then halving, then one step.
+func ShrinkInt(n int) []int {
+	if n == 0 {
+		return nil
+	}
+
+	out := []int{0}
+	if half := n / 2; half != 0 && half != n {
+		out = append(out, half)
+	}
+	if n > 1 {
+		out = append(out, n-1)
+	} else if n < -1 {
+		out = append(out, n+1)
+	}
+	return out
+}
+
+// ShrinkSlice proposes shorter slices first, dropping the back or front half and
+// then each single element, and finally slices with one element shrunk by elem.
+// elem may be nil.
+func ShrinkSlice[T any](s []T, elem func(T) []T) [][]T {
+	var out [][]T
+	if n := len(s); n > 1 {
+		out = append(out, s[:n/2:n/2], s[n/2:])
+	}
+	for i := range s {
+		c := make([]T, 0, len(s)-1)
+		c = append(c, s[:i]...)
+		out = append(out, append(c, s[i+1:]...))
+	}
+	if elem == nil {
+		return out
+	}
+	for i, v := range s {
+		for _, smaller := range elem(v) {
+			c := append([]T(nil), s...)
+			c[i] = smaller
+			out = append(out, c)
+		}
+	}
+	return out
+}
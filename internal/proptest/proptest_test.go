@@ -0,0 +1,101 @@
+package proptest
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intGen(max int) Gen[int] {
+	return Gen[int]{
+		Generate: func(r *rand.Rand) int { return r.Intn(2*max) - max },
+		Shrink:   ShrinkInt,
+	}
+}
+
+func TestCheckPasses(t *testing.T) {
+	failure := Check(Config{Seed: 1, Runs: 200}, intGen(1000), func(n int) error {
+		if n*n < 0 {
+			return fmt.Errorf("square of %d is negative", n)
+		}
+		return nil
+	})
+	assert.Nil(t, failure)
+}
+
+func TestSample(t *testing.T) {
+	sample := Sample(Config{Seed: 1, Runs: 50}, intGen(10))
+	assert.Len(t, sample, 50)
+	assert.Equal(t, sample, Sample(Config{Seed: 1, Runs: 50}, intGen(10)), "the same seed gives the same sample")
+
+	// Check sees the same inputs for the same seed
+	var checked []int
+	Check(Config{Seed: 1, Runs: 50}, intGen(10), func(n int) error {
+		checked = append(checked, n)
+		return nil
+	})
+	assert.Equal(t, sample, checked)
+}
+
+func TestCheckShrinksToMinimal(t *testing.T) {
+	failure := Check(Config{Seed: 1, Runs: 200}, intGen(100000), func(n int) error {
+		if n >= 37 {
+			return fmt.Errorf("%d is too large", n)
+		}
+		return nil
+	})
+	if assert.NotNil(t, failure) {
+		assert.Equal(t, 37, failure.Minimal)
+		assert.GreaterOrEqual(t, failure.Original, 37)
+		assert.EqualError(t, failure.Err, "37 is too large")
+	}
+}
+
+func TestCheckShrinksNegatives(t *testing.T) {
+	failure := Check(Config{Seed: 7, Runs: 200}, intGen(100000), func(n int) error {
+		if n < 0 {
+			return fmt.Errorf("%d is negative", n)
+		}
+		return nil
+	})
+	if assert.NotNil(t, failure) {
+		assert.Equal(t, -1, failure.Minimal)
+	}
+}
+
+func TestShrinkInt(t *testing.T) {
+	assert.Nil(t, ShrinkInt(0))
+	assert.Equal(t, []int{0, 5, 9}, ShrinkInt(10))
+	assert.Equal(t, []int{0}, ShrinkInt(1))
+	assert.Equal(t, []int{0, -5, -9}, ShrinkInt(-10))
+}
+
+func TestShrinkSliceFindsMinimalSequence(t *testing.T) {
+	gen := Gen[[]int]{
+		Generate: func(r *rand.Rand) []int {
+			s := make([]int, r.Intn(20))
+			for i := range s {
+				s[i] = r.Intn(10)
+			}
+			return s
+		},
+		Shrink: func(s []int) [][]int { return ShrinkSlice(s, ShrinkInt) },
+	}
+
+	// fails whenever a 7 is followed, at any distance, by a 3
+	failure := Check(Config{Seed: 3, Runs: 500}, gen, func(s []int) error {
+		seen7 := false
+		for _, v := range s {
+			if v == 3 && seen7 {
+				return fmt.Errorf("%v has a 3 after a 7", s)
+			}
+			seen7 = seen7 || v == 7
+		}
+		return nil
+	})
+	if assert.NotNil(t, failure) {
+		assert.Equal(t, []int{7, 3}, failure.Minimal)
+	}
+}
@@ -0,0 +1,173 @@
+// Package repeat sends a mutating call twice and checks the second call
+// against what repeating it should do. Removing something twice must say
+// that it is gone, setting a state twice must answer the same, and either
+// way the second call must leave the stored state as the first call left it.
+// Dev bugs such as a remove that answers 200 every time show up as a second
+// call that disagrees with the semantics, or with Release.
+package repeat
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/jsondiff"
+)
+
+// Semantics is what the second of two identical calls should do.
+type Semantics string
+
+const (
+	// Gone: the first call removed what the call names, the second answers 404
+	Gone Semantics = "gone"
+	// Same: the call sets a state, the second succeeds and answers like the first
+	Same Semantics = "same"
+	// Refused: the state the call moves to cannot be entered again, the second answers 4xx
+	Refused Semantics = "refused"
+)
+
+// Call is a mutating call to repeat.
+type Call struct {
+	Name   string      // e.g. "wishlist remove"
+	Method string      // HTTP method
+	Path   string      // below the environment URL
+	Body   interface{} // sent as JSON, nil for none
+	Read   string      // path whose answer is the state the call changes
+	Want   Semantics
+}
+
+// Answer is the status and decoded JSON body of a response, Body is nil when it is not JSON.
+type Answer struct {
+	Status int
+	Body   interface{}
+}
+
+// Outcome is what one environment did with a call sent twice.
+type Outcome struct {
+	Env           string
+	First, Second Answer
+	// After and Again are the state read after the first and after the second call
+	After, Again Answer
+	Problems     []string
+}
+
+// ignored are keys that may change without the state changing
+var ignored = jsondiff.Options{Ignore: map[string]bool{"updated_at": true}}
+
+// Run sends c twice to env, reading the state after each call, and checks the outcome.
+func Run(env api.Environment, auth, task string, c Call) (Outcome, error) {
+	out := Outcome{Env: env.Name}
+	steps := []struct {
+		method, path string
+		body         interface{}
+		answer       *Answer
+	}{
+		{c.Method, c.Path, c.Body, &out.First},
+		{http.MethodGet, c.Read, nil, &out.After},
+		{c.Method, c.Path, c.Body, &out.Second},
+		{http.MethodGet, c.Read, nil, &out.Again},
+	}
+	for _, s := range steps {
+		a, err := send(env.URL+s.path, s.method, auth, s.body, task)
+		if err != nil {
+			return out, fmt.Errorf("%s %s on %s: %w", s.method, s.path, env.Name, err)
+		}
+		*s.answer = a
+	}
+	out.Problems = Check(c, out)
+	return out, nil
+}
+
+func send(url, method, auth string, body interface{}, task string) (Answer, error) {
+	resp, err := api.SendRequestWithAuth(method, url, auth, body, task)
+	if err != nil {
+		return Answer{}, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Answer{}, err
+	}
+	a := Answer{Status: resp.StatusCode}
+	if doc, err := jsondiff.Decode(data); err == nil {
+		a.Body = doc
+	}
+	return a, nil
+}
+
+// Check compares the two answers and states of an outcome with the semantics of c.
+func Check(c Call, o Outcome) []string {
+	if !ok(o.First.Status) {
+		return []string{fmt.Sprintf("first call answers %d, nothing to repeat", o.First.Status)}
+	}
+	var problems []string
+	switch c.Want {
+	case Gone:
+		if o.Second.Status != http.StatusNotFound {
+			problems = append(problems, fmt.Sprintf("second call answers %d, want 404", o.Second.Status))
+		}
+	case Same:
+		if !ok(o.Second.Status) {
+			problems = append(problems, fmt.Sprintf("second call answers %d, want %d", o.Second.Status, o.First.Status))
+			break
+		}
+		for _, d := range jsondiff.Compare(o.First.Body, o.Second.Body, ignored) {
+			problems = append(problems, "second answer differs, "+d.Format("first", "second"))
+		}
+	case Refused:
+		if o.Second.Status < 400 || o.Second.Status >= 500 {
+			problems = append(problems, fmt.Sprintf("second call answers %d, want 4xx", o.Second.Status))
+		}
+	}
+
+	if o.After.Status != o.Again.Status {
+		problems = append(problems, fmt.Sprintf("state reads %d after the first call, %d after the second", o.After.Status, o.Again.Status))
+	} else {
+		for _, d := range jsondiff.Compare(o.After.Body, o.Again.Body, ignored) {
+			problems = append(problems, "second call changed the state, "+d.Format("after first", "after second"))
+		}
+	}
+	return problems
+}
+
+// Compare lists how the outcomes of one call differ between environments,
+// the first outcome is the reference, e.g.
+// "second call: Release 404, Dev 200"
+func Compare(outcomes []Outcome) []string {
+	if len(outcomes) < 2 {
+		return nil
+	}
+	ref := outcomes[0]
+	var diffs []string
+	for _, o := range outcomes[1:] {
+		for _, f := range []struct {
+			what     string
+			ref, got int
+		}{
+			{"first call", ref.First.Status, o.First.Status},
+			{"second call", ref.Second.Status, o.Second.Status},
+			{"state after both calls", ref.Again.Status, o.Again.Status},
+		} {
+			if f.ref != f.got {
+				diffs = append(diffs, fmt.Sprintf("%s: %s %d, %s %d", f.what, ref.Env, f.ref, o.Env, f.got))
+			}
+		}
+		if held(ref) != held(o) {
+			diffs = append(diffs, fmt.Sprintf("semantics: %s %s, %s %s", ref.Env, held(ref), o.Env, held(o)))
+		}
+	}
+	return diffs
+}
+
+// held tells whether an outcome met the semantics, its Problems say how not
+func held(o Outcome) string {
+	if len(o.Problems) == 0 {
+		return "held"
+	}
+	return "broken"
+}
+
+func ok(status int) bool {
+	return status >= 200 && status < 300
+}
@@ -0,0 +1,114 @@
+package repeat
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/fakeapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const auth = "Bearer secret:qa@example.com"
+
+// fakes starts a fake Release and Dev sharing one data set
+func fakes(t *testing.T) []api.Environment {
+	srv := fakeapi.New(fakeapi.Options{})
+	rel := httptest.NewServer(srv)
+	dev := httptest.NewServer(srv.Share(fakeapi.Options{Dev: true}))
+	t.Cleanup(rel.Close)
+	t.Cleanup(dev.Close)
+	return []api.Environment{
+		{Name: "Release", URL: rel.URL + fakeapi.BasePath},
+		{Name: "Dev", URL: dev.URL + fakeapi.BasePath},
+	}
+}
+
+// post sends a request that prepares a call and returns the decoded answer
+func post(t *testing.T, env api.Environment, method, path string, body interface{}) map[string]interface{} {
+	a, err := send(env.URL+path, method, auth, body, "api-test")
+	require.NoError(t, err)
+	require.True(t, ok(a.Status), "%s %s answers %d", method, path, a.Status)
+	obj, _ := a.Body.(map[string]interface{})
+	return obj
+}
+
+// calls prepares one of every repeatable call on env
+func calls(t *testing.T, env api.Environment) []Call {
+	user := post(t, env, "POST", "/users", map[string]interface{}{
+		"email": env.Name + "@example.com", "password": "password", "name": "R", "nickname": "repeat" + env.Name,
+	})
+	u := "/users/" + user["uuid"].(string)
+	game := "00000000-0000-4000-8001-000000000001"
+	item := map[string]interface{}{"item_uuid": game}
+
+	post(t, env, "POST", u+"/wishlist/add", item)
+	post(t, env, "POST", u+"/cart/add", map[string]interface{}{"item_uuid": game, "quantity": 1})
+	order := post(t, env, "POST", u+"/orders", map[string]interface{}{"items": []interface{}{map[string]interface{}{"item_uuid": game, "quantity": 1}}})
+	o := "/orders/" + order["uuid"].(string)
+
+	return []Call{
+		{Name: "wishlist remove", Method: "POST", Path: u + "/wishlist/remove", Body: item, Read: u + "/wishlist", Want: Gone},
+		{Name: "cart remove", Method: "POST", Path: u + "/cart/remove", Body: item, Read: u + "/cart", Want: Gone},
+		{Name: "cart clear", Method: "POST", Path: u + "/cart/clear", Read: u + "/cart", Want: Same},
+		{Name: "order cancel", Method: "PATCH", Path: o + "/status", Body: map[string]interface{}{"status": "canceled"}, Read: o, Want: Refused},
+		{Name: "user delete", Method: "DELETE", Path: u, Read: u, Want: Gone},
+	}
+}
+
+func TestRun(t *testing.T) {
+	envs := fakes(t)
+	release := calls(t, envs[0])
+	dev := calls(t, envs[1])
+
+	problems := map[string][]string{}
+	diffs := map[string][]string{}
+	for i, c := range release {
+		r, err := Run(envs[0], auth, "api-test", c)
+		require.NoError(t, err)
+		assert.Empty(t, r.Problems, "Release %s", c.Name)
+
+		d, err := Run(envs[1], auth, "api-test", dev[i])
+		require.NoError(t, err)
+		problems[c.Name] = d.Problems
+		diffs[c.Name] = Compare([]Outcome{r, d})
+	}
+
+	assert.Equal(t, []string{"second call answers 200, want 404"}, problems["wishlist remove"], "API-8")
+	assert.Equal(t, []string{"second call: Release 404, Dev 200", "semantics: Release held, Dev broken"},
+		diffs["wishlist remove"])
+	assert.Equal(t, []string{"first call answers 422, nothing to repeat"}, problems["order cancel"], "API-18")
+	assert.Contains(t, diffs["order cancel"], "first call: Release 200, Dev 422")
+	for _, name := range []string{"cart remove", "cart clear", "user delete"} {
+		assert.Empty(t, problems[name], name)
+		assert.Empty(t, diffs[name], name)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cart := func(n int) Answer {
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i] = map[string]interface{}{"item_uuid": "g1"}
+		}
+		return Answer{Status: 200, Body: map[string]interface{}{"items": items}}
+	}
+
+	clear := Call{Name: "cart clear", Want: Same}
+	assert.Empty(t, Check(clear, Outcome{First: cart(0), Second: cart(0), After: cart(0), Again: cart(0)}))
+	assert.Equal(t, []string{
+		`second answer differs, $.items[0]: only on second, {"item_uuid":"g1"}`,
+		`second call changed the state, $.items[0]: only on after second, {"item_uuid":"g1"}`,
+	}, Check(clear, Outcome{First: cart(0), Second: cart(1), After: cart(0), Again: cart(1)}))
+
+	del := Call{Name: "user delete", Want: Gone}
+	assert.Empty(t, Check(del, Outcome{First: Answer{Status: 204}, Second: Answer{Status: 404}, After: Answer{Status: 404}, Again: Answer{Status: 404}}))
+	assert.Equal(t, []string{"second call answers 204, want 404", "state reads 404 after the first call, 200 after the second"},
+		Check(del, Outcome{First: Answer{Status: 204}, Second: Answer{Status: 204}, After: Answer{Status: 404}, Again: Answer{Status: 200}}))
+
+	cancel := Call{Name: "order cancel", Want: Refused}
+	assert.Equal(t, []string{"second call answers 200, want 4xx"},
+		Check(cancel, Outcome{First: Answer{Status: 200}, Second: Answer{Status: 200}}))
+}
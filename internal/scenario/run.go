@@ -0,0 +1,300 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/chain"
+	"QA-Bug-Hunter-jr/internal/jsonpath"
+	"QA-Bug-Hunter-jr/internal/vars"
+)
+
+// Runner runs the steps of scenarios against a set of environments.
+type Runner struct {
+	Envs []api.Environment
+	Auth string
+	Vars *vars.Set // fixtures and the values earlier steps captured
+}
+
+// answer is what one environment replied to a step
+type answer struct {
+	env    string
+	status int
+	doc    interface{} // decoded JSON body
+	err    error       // why the body could not be decoded
+}
+
+// Check reports a placeholder that neither a fixture nor an earlier step of
+// s provides, before anything is sent.
+func (r *Runner) Check(s *Scenario) error {
+	captured := map[string]int{} // name to the first step capturing it
+	for i := len(s.Steps) - 1; i >= 0; i-- {
+		for _, c := range s.Steps[i].Capture {
+			captured[c.Name] = i
+		}
+	}
+	for i, st := range s.Steps {
+		for _, name := range st.uses() {
+			if r.Vars.Has(name) {
+				continue
+			}
+			first, ok := captured[name]
+			switch {
+			case !ok:
+				return fmt.Errorf("%s: {{%s}} is not a fixture and no step captures it", st.Title(i), name)
+			case first >= i:
+				return fmt.Errorf("%s: {{%s}} is only captured by %s", st.Title(i), name, s.Steps[first].Title(first))
+			}
+		}
+	}
+	return nil
+}
+
+// Step runs step st of s. Failures are checks that did not hold, including
+// captures that found nothing; an error means the step could not run at all
+// and later steps should not either.
+func (r *Runner) Step(s *Scenario, st Step) ([]string, error) {
+	envs, err := r.environments(st.Envs)
+	if err != nil {
+		return nil, err
+	}
+	for name := range st.ExpectEnv {
+		if _, ok := r.lookup(name); !ok {
+			return nil, fmt.Errorf("expect_env: unknown environment %q", name)
+		}
+	}
+	for _, c := range st.Capture {
+		if _, ok := r.lookup(c.Env); c.Env != "" && !ok {
+			return nil, fmt.Errorf("capture %s: unknown environment %q", c.Name, c.Env)
+		}
+	}
+	task := st.Task
+	if task == "" {
+		task = s.Task
+	}
+
+	var answers []answer
+	for _, env := range envs {
+		resp, err := chain.New(env, r.Auth, r.Vars).Do(st.Method, st.Path, st.Body).Task(task).Send()
+		if err != nil {
+			return nil, err
+		}
+		a := answer{env: env.Name, status: resp.Status, doc: resp.Doc}
+		if resp.Doc == nil {
+			a.err = fmt.Errorf("body is not JSON: %q", truncate(string(resp.Body)))
+		}
+		answers = append(answers, a)
+	}
+
+	var failures []string
+	for _, a := range answers {
+		for _, e := range []*Expect{st.Expect, r.expectFor(st, a.env)} {
+			f, err := r.check(a, e)
+			if err != nil {
+				return nil, err
+			}
+			failures = append(failures, f...)
+		}
+	}
+	for _, c := range st.Compare {
+		failures = append(failures, compare(answers, c)...)
+	}
+	for _, a := range answers {
+		for _, c := range st.Capture {
+			if c.Env != "" && !strings.EqualFold(c.Env, a.env) {
+				continue
+			}
+			if _, err := r.Vars.Capture(a.env, c.Name, c.Path, c.Type, a.doc); err != nil {
+				failures = append(failures, err.Error())
+			}
+		}
+	}
+	return failures, nil
+}
+
+// environments resolves the names of a step, every environment when there are none
+func (r *Runner) environments(names []string) ([]api.Environment, error) {
+	if len(names) == 0 {
+		return r.Envs, nil
+	}
+	var out []api.Environment
+	for _, name := range names {
+		env, ok := r.lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown environment %q", name)
+		}
+		out = append(out, env)
+	}
+	return out, nil
+}
+
+func (r *Runner) lookup(name string) (api.Environment, bool) {
+	for _, env := range r.Envs {
+		if strings.EqualFold(env.Name, name) {
+			return env, true
+		}
+	}
+	return api.Environment{}, false
+}
+
+// expectFor returns the expectations of one environment, matched ignoring case
+func (r *Runner) expectFor(st Step, env string) *Expect {
+	for name, e := range st.ExpectEnv {
+		if strings.EqualFold(name, env) {
+			return e
+		}
+	}
+	return nil
+}
+
+// check returns the expectations a did not meet
+func (r *Runner) check(a answer, e *Expect) ([]string, error) {
+	if e == nil {
+		return nil, nil
+	}
+	var failures []string
+	if e.Status != 0 && a.status != e.Status {
+		failures = append(failures, fmt.Sprintf("%s: status %d, want %d", a.env, a.status, e.Status))
+	}
+	for _, as := range e.JSON {
+		if a.err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s: %v", a.env, as.Path, a.err))
+			continue
+		}
+		msg, err := r.assert(a.env, a.doc, as)
+		if err != nil {
+			return nil, err
+		}
+		if msg != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", a.env, msg))
+		}
+	}
+	return failures, nil
+}
+
+// assert checks one assertion against a document and describes a failure
+func (r *Runner) assert(env string, doc interface{}, as Assertion) (string, error) {
+	got, lookupErr := jsonpath.Get(doc, as.Path)
+	switch as.checks[0] {
+	case "exists":
+		if exists := lookupErr == nil; exists != *as.Exists {
+			if exists {
+				return fmt.Sprintf("%s is %s, want it absent", as.Path, render(got)), nil
+			}
+			return lookupErr.Error(), nil
+		}
+		return "", nil
+	}
+	if lookupErr != nil {
+		return lookupErr.Error(), nil
+	}
+
+	switch as.checks[0] {
+	case "equals":
+		want, err := r.Vars.ExpandValue(env, as.Equals)
+		if err != nil {
+			return "", err
+		}
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Sprintf("%s is %s, want %s", as.Path, render(got), render(want)), nil
+		}
+	case "length":
+		n, ok := length(got)
+		if !ok {
+			return fmt.Sprintf("%s is %s, it has no length", as.Path, render(got)), nil
+		}
+		if n != *as.Length {
+			return fmt.Sprintf("%s has length %d, want %d", as.Path, n, *as.Length), nil
+		}
+	case "contains":
+		want, err := r.Vars.ExpandValue(env, as.Contains)
+		if err != nil {
+			return "", err
+		}
+		if !contains(got, want) {
+			return fmt.Sprintf("%s is %s, want it to contain %s", as.Path, render(got), render(want)), nil
+		}
+	}
+	return "", nil
+}
+
+// compare checks one comparison across the answers, the first is the reference
+func compare(answers []answer, c Comparison) []string {
+	if len(answers) < 2 {
+		return []string{fmt.Sprintf("compare %s: needs two environments", c.Path)}
+	}
+	values := make([]interface{}, len(answers))
+	var parts []string
+	for i, a := range answers {
+		switch {
+		case c.Path == "status":
+			values[i] = a.status
+		case a.err != nil:
+			return []string{fmt.Sprintf("compare %s: %s: %v", c.Path, a.env, a.err)}
+		default:
+			v, err := jsonpath.Get(a.doc, c.Path)
+			if err != nil {
+				return []string{fmt.Sprintf("compare %s: %s: %v", c.Path, a.env, err)}
+			}
+			values[i] = v
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", a.env, render(values[i])))
+	}
+
+	var failures []string
+	for i := 1; i < len(values); i++ {
+		same := reflect.DeepEqual(values[0], values[i])
+		if same != (c.Want == "same") {
+			failures = append(failures, fmt.Sprintf("compare %s: %s, want %s", c.Path, strings.Join(parts, ", "), c.Want))
+			break
+		}
+	}
+	return failures
+}
+
+func length(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return len(v), true
+	case map[string]interface{}:
+		return len(v), true
+	case string:
+		return len(v), true
+	case nil:
+		return 0, true // the API sends null for empty lists
+	}
+	return 0, false
+}
+
+func contains(got, want interface{}) bool {
+	switch got := got.(type) {
+	case []interface{}:
+		for _, elem := range got {
+			if reflect.DeepEqual(elem, want) {
+				return true
+			}
+		}
+	case string:
+		s, ok := want.(string)
+		return ok && strings.Contains(got, s)
+	}
+	return false
+}
+
+func render(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return truncate(string(data))
+}
+
+func truncate(s string) string {
+	if len(s) > 80 {
+		return s[:77] + "..."
+	}
+	return s
+}
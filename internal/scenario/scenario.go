@@ -0,0 +1,308 @@
+// Package scenario reads Release vs Dev checks from YAML or JSON files and
+// runs them step by step. A step sends one request to each of its
+// environments, checks the status and JSONPath values of every answer and
+// compares the answers across environments:
+//
+//	name: Dev search ignores the query
+//	task: api-2
+//	steps:
+//	  - method: GET
+//	    path: /games/search?query=no-such-title
+//	    expect:
+//	      status: 200
+//	    expect_env:
+//	      Release:
+//	        json:
+//	          - {path: $.meta.total, equals: 0}
+//	    compare:
+//	      - {path: $.meta.total, want: differ}
+//
+// Paths, bodies and expected values may use {{name}} placeholders, filled
+// from the runner's fixtures and from values earlier steps captured:
+//
+//	steps:
+//	  - method: POST
+//	    path: /users/{{user.uuid}}/orders
+//	    body: {items: [{item_uuid: "{{game.uuid}}", quantity: 1}]}
+//	    capture:
+//	      - {name: order.uuid, path: $.uuid, type: uuid}
+//	  - method: GET
+//	    path: /orders/{{order.uuid}}
+//
+// Every environment keeps what it captured, see package vars.
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"QA-Bug-Hunter-jr/internal/jsonpath"
+	"QA-Bug-Hunter-jr/internal/vars"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is one file: a named sequence of steps.
+type Scenario struct {
+	Name  string `yaml:"name"`
+	Task  string `yaml:"task"` // X-Task-Id of steps without their own
+	Bug   string `yaml:"bug"`  // what the scenario reproduces, for readers
+	Steps []Step `yaml:"steps"`
+
+	File string `yaml:"-"`
+}
+
+// Step sends one request to each of Envs, all environments when empty.
+type Step struct {
+	Name      string             `yaml:"name"`
+	Method    string             `yaml:"method"`
+	Path      string             `yaml:"path"`
+	Task      string             `yaml:"task"`
+	Body      interface{}        `yaml:"body"`
+	Envs      []string           `yaml:"envs"`
+	Expect    *Expect            `yaml:"expect"`     // checked on every environment
+	ExpectEnv map[string]*Expect `yaml:"expect_env"` // checked on the named environment
+	Compare   []Comparison       `yaml:"compare"`
+	Capture   []Capture          `yaml:"capture"` // values for later steps
+}
+
+// Title names the step for subtests and messages.
+func (s Step) Title(i int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return fmt.Sprintf("step %d %s %s", i+1, s.Method, s.Path)
+}
+
+// Expect is what one answer must look like; a zero Status is not checked.
+type Expect struct {
+	Status int         `yaml:"status"`
+	JSON   []Assertion `yaml:"json"`
+}
+
+// Assertion checks the value at Path with exactly one of equals, exists,
+// length and contains.
+type Assertion struct {
+	Path     string      `yaml:"path"`
+	Equals   interface{} `yaml:"equals"`
+	Exists   *bool       `yaml:"exists"`
+	Length   *int        `yaml:"length"`
+	Contains interface{} `yaml:"contains"`
+
+	checks []string // the checks present in the file, equals may be null
+}
+
+// UnmarshalYAML records which checks are present, so "equals: null" is
+// told apart from a missing equals.
+func (a *Assertion) UnmarshalYAML(node *yaml.Node) error {
+	type plain Assertion
+	if err := node.Decode((*plain)(a)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch key := node.Content[i].Value; key {
+		case "equals", "exists", "length", "contains":
+			a.checks = append(a.checks, key)
+		}
+	}
+	return nil
+}
+
+// Comparison checks that a value is the same, or differs, on every
+// environment of the step. Path is a JSONPath or "status".
+type Comparison struct {
+	Path string `yaml:"path"`
+	Want string `yaml:"want"` // same or differ
+}
+
+// Capture stores the value at Path of an answer as a variable for later
+// steps. Every answer of the step is captured unless Env names one.
+type Capture struct {
+	Name string    `yaml:"name"`
+	Path string    `yaml:"path"`
+	Type vars.Type `yaml:"type"` // any when empty
+	Env  string    `yaml:"env"`
+}
+
+// Load reads a scenario from a .yaml, .yml or .json file. Unknown keys are
+// rejected, so a typo does not silently skip a check.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var s Scenario
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	s.File = path
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &s, nil
+}
+
+// LoadDir reads every scenario file of dir, sorted by file name.
+func LoadDir(dir string) ([]*Scenario, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []*Scenario
+	for _, e := range entries {
+		switch filepath.Ext(e.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+		s, err := Load(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].File < out[j].File })
+	return out, nil
+}
+
+var methods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+func (s *Scenario) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("scenario needs a name")
+	}
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("scenario %q has no steps", s.Name)
+	}
+	for i := range s.Steps {
+		st := &s.Steps[i]
+		st.Method = strings.ToUpper(st.Method)
+		if err := st.validate(); err != nil {
+			return fmt.Errorf("%s: %v", st.Title(i), err)
+		}
+		st.Body = normalize(st.Body)
+	}
+	return nil
+}
+
+func (st *Step) validate() error {
+	if !methods[st.Method] {
+		return fmt.Errorf("method %q is not one of GET, POST, PUT, PATCH, DELETE", st.Method)
+	}
+	if !strings.HasPrefix(st.Path, "/") {
+		return fmt.Errorf("path %q must start with /", st.Path)
+	}
+	expects := []*Expect{st.Expect}
+	for _, e := range st.ExpectEnv {
+		expects = append(expects, e)
+	}
+	for _, e := range expects {
+		if e == nil {
+			continue
+		}
+		for j := range e.JSON {
+			a := &e.JSON[j]
+			if _, err := jsonpath.Parse(a.Path); err != nil {
+				return err
+			}
+			if len(a.checks) != 1 {
+				return fmt.Errorf("assertion on %s needs exactly one of equals, exists, length, contains", a.Path)
+			}
+			a.Equals, a.Contains = normalize(a.Equals), normalize(a.Contains)
+		}
+	}
+	for i := range st.Capture {
+		c := &st.Capture[i]
+		if !captureName.MatchString(c.Name) {
+			return fmt.Errorf("capture name %q must be letters, digits, '.', '_' or '-'", c.Name)
+		}
+		if _, err := jsonpath.Parse(c.Path); err != nil {
+			return fmt.Errorf("capture %s: %v", c.Name, err)
+		}
+		typ, err := vars.ParseType(string(c.Type))
+		if err != nil {
+			return fmt.Errorf("capture %s: %v", c.Name, err)
+		}
+		c.Type = typ
+	}
+	for _, c := range st.Compare {
+		if c.Want != "same" && c.Want != "differ" {
+			return fmt.Errorf("comparison of %s: want must be same or differ, got %q", c.Path, c.Want)
+		}
+		if c.Path != "status" {
+			if _, err := jsonpath.Parse(c.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// normalize turns a value decoded from YAML into what encoding/json would
+// produce for it, so 3 from a file equals 3 from a response
+func normalize(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out interface{}
+	if json.Unmarshal(data, &out) != nil {
+		return v
+	}
+	return out
+}
+
+// captureName is what a {{name}} placeholder accepts, without @Env
+var captureName = regexp.MustCompile(`^[A-Za-z0-9_.\-]+$`)
+
+// names returns the placeholder names used inside a decoded value
+func names(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return vars.Names(v)
+	case []interface{}:
+		var out []string
+		for _, elem := range v {
+			out = append(out, names(elem)...)
+		}
+		return out
+	case map[string]interface{}:
+		var out []string
+		for _, elem := range v {
+			out = append(out, names(elem)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// uses returns the placeholder names a step reads, in its path, body and
+// expected values
+func (st *Step) uses() []string {
+	out := append(vars.Names(st.Path), names(st.Body)...)
+	expects := []*Expect{st.Expect}
+	for _, e := range st.ExpectEnv {
+		expects = append(expects, e)
+	}
+	for _, e := range expects {
+		if e == nil {
+			continue
+		}
+		for _, a := range e.JSON {
+			out = append(out, names(a.Equals)...)
+			out = append(out, names(a.Contains)...)
+		}
+	}
+	return out
+}
@@ -0,0 +1,217 @@
+package scenario
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"QA-Bug-Hunter-jr/internal/api"
+	"QA-Bug-Hunter-jr/internal/fakeapi"
+	"QA-Bug-Hunter-jr/internal/vars"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const searchScenario = `
+name: Dev search ignores the query
+task: api-2
+steps:
+  - method: get
+    path: /games/search?query={{query}}
+    expect:
+      status: 200
+      json:
+        - {path: $.games, exists: true}
+    expect_env:
+      Release:
+        json:
+          - {path: $.meta.total, equals: 0}
+          - {path: $.games, length: 0}
+      Dev:
+        json:
+          - {path: "$.games[*].title", contains: Portal 2}
+    compare:
+      - {path: status, want: same}
+      - {path: $.meta.total, want: differ}
+`
+
+// write puts a scenario file into a temporary directory
+func write(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+// fakes starts a fake Release and Dev sharing one data set
+func fakes(t *testing.T) []api.Environment {
+	release := fakeapi.New(fakeapi.Options{})
+	rel := httptest.NewServer(release)
+	dev := httptest.NewServer(release.Share(fakeapi.Options{Dev: true}))
+	t.Cleanup(rel.Close)
+	t.Cleanup(dev.Close)
+	return []api.Environment{
+		{Name: "Release", URL: rel.URL + fakeapi.BasePath},
+		{Name: "Dev", URL: dev.URL + fakeapi.BasePath},
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	write(t, dir, "b_search.yaml", searchScenario)
+	write(t, dir, "a_users.json", `{"name": "list users", "steps": [{"method": "GET", "path": "/users", "expect": {"status": 200}}]}`)
+	write(t, dir, "notes.txt", "not a scenario")
+
+	scenarios, err := LoadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, scenarios, 2)
+	assert.Equal(t, "list users", scenarios[0].Name)
+	s := scenarios[1]
+	assert.Equal(t, "GET", s.Steps[0].Method)
+	assert.Equal(t, 0.0, s.Steps[0].ExpectEnv["Release"].JSON[0].Equals, "numbers compare like decoded JSON")
+	assert.Equal(t, "step 1 GET /games/search?query={{query}}", s.Steps[0].Title(0))
+}
+
+func TestLoadErrors(t *testing.T) {
+	for content, want := range map[string]string{
+		"steps: []":          "scenario needs a name",
+		"name: x":            `scenario "x" has no steps`,
+		"name: x\nstpes: []": "field stpes not found",
+		"name: x\nsteps: [{method: FETCH, path: /}]":                                                       `method "FETCH" is not one of`,
+		"name: x\nsteps: [{method: GET, path: users}]":                                                     `path "users" must start with /`,
+		"name: x\nsteps: [{method: GET, path: /, compare: [{path: status}]}]":                              "want must be same or differ",
+		"name: x\nsteps: [{method: GET, path: /, expect: {json: [{path: $.a}]}}]":                          "needs exactly one of",
+		"name: x\nsteps: [{method: GET, path: /, expect: {json: [{path: $.a, equals: 1, exists: true}]}}]": "needs exactly one of",
+		"name: x\nsteps: [{method: GET, path: /, expect: {json: [{path: a, equals: 1}]}}]":                 `path "a" must start with $`,
+		"name: x\nsteps: [{method: GET, path: /, capture: [{name: a@b, path: $.a}]}]":                      `capture name "a@b" must be`,
+		"name: x\nsteps: [{method: GET, path: /, capture: [{name: a, path: $.a, type: text}]}]":            `capture a: unknown type "text"`,
+	} {
+		_, err := Load(write(t, t.TempDir(), "s.yaml", content))
+		if assert.Error(t, err, content) {
+			assert.Contains(t, err.Error(), want, content)
+		}
+	}
+}
+
+func TestRunSearchScenario(t *testing.T) {
+	s, err := Load(write(t, t.TempDir(), "s.yaml", searchScenario))
+	require.NoError(t, err)
+	r := &Runner{Envs: fakes(t), Auth: "Bearer secret:qa@example.com", Vars: vars.New()}
+	r.Vars.Set("query", "no-such-title")
+
+	failures, err := r.Step(s, s.Steps[0])
+	require.NoError(t, err)
+	assert.Empty(t, failures, "the fake Dev reproduces API-2")
+
+	// a real title is found on Release, the value is escaped into the query
+	r.Vars.Set("query", "Portal 2")
+	failures, err = r.Step(s, s.Steps[0])
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Release: $.meta.total is 1, want 0",
+		"Release: $.games has length 1, want 0",
+	}, failures)
+}
+
+func TestRunErrors(t *testing.T) {
+	r := &Runner{Envs: fakes(t), Auth: "Bearer secret:qa@example.com", Vars: vars.New()}
+	s := &Scenario{Name: "x"}
+
+	_, err := r.Step(s, Step{Method: "GET", Path: "/users/{{user.uuid}}"})
+	assert.EqualError(t, err, "GET /users/{{user.uuid}} on Release: {{user.uuid}} is not set: no fixture has that name and no earlier request captured it")
+	_, err = r.Step(s, Step{Method: "GET", Path: "/users", Envs: []string{"Staging"}})
+	assert.EqualError(t, err, `unknown environment "Staging"`)
+
+	failures, err := r.Step(s, Step{Method: "GET", Path: "/users/nope", Envs: []string{"dev"},
+		Expect:  &Expect{Status: 200, JSON: []Assertion{{Path: "$.uuid", Equals: "nope", checks: []string{"equals"}}}},
+		Compare: []Comparison{{Path: "status", Want: "same"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"Dev: status 404, want 200",
+		`Dev: $ has no key "uuid"`,
+		"compare status: needs two environments",
+	}, failures)
+}
+
+const chainScenario = `
+name: pay an order and read the payment
+task: api-19
+steps:
+  - name: find a game
+    method: GET
+    path: /games?limit=1
+    envs: [Release]
+    capture:
+      - {name: game.uuid, path: "$.games[0].uuid", type: uuid}
+  - name: order it
+    method: POST
+    path: /users/{{user.uuid}}/orders
+    envs: [Release]
+    body: {items: [{item_uuid: "{{game.uuid}}", quantity: 2}]}
+    capture:
+      - {name: order.uuid, path: $.uuid, type: uuid}
+      - {name: order.total, path: $.total_price, type: integer}
+  - name: pay it
+    method: POST
+    path: /users/{{user.uuid}}/payments
+    envs: [Release]
+    body: {order_uuid: "{{order.uuid}}", payment_method: mir_pay}
+    expect:
+      json:
+        - {path: $.amount, equals: "{{order.total}}"}
+    capture:
+      - {name: payment.uuid, path: $.uuid, type: uuid}
+      - {name: payment.missing, path: $.receipt}
+  - name: read it
+    method: GET
+    path: /payments/{{payment.uuid}}
+    expect:
+      status: 200
+      json:
+        - {path: $.order_uuid, equals: "{{order.uuid}}"}
+    expect_env:
+      Release:
+        json:
+          - {path: $.created_at, exists: true}
+      Dev:
+        json:
+          - {path: $.created_at, exists: false}
+`
+
+func TestRunChainScenario(t *testing.T) {
+	s, err := Load(write(t, t.TempDir(), "s.yaml", chainScenario))
+	require.NoError(t, err)
+	envs := fakes(t)
+	users, err := api.FetchAllUsers(envs[0].URL, "api-3")
+	require.NoError(t, err)
+	r := &Runner{Envs: envs, Auth: "Bearer secret:qa@example.com", Vars: vars.New()}
+	r.Vars.Set("user.uuid", users[0]["uuid"])
+	require.NoError(t, r.Check(s))
+
+	var all []string
+	for _, st := range s.Steps {
+		failures, err := r.Step(s, st)
+		require.NoError(t, err, st.Name)
+		all = append(all, failures...)
+	}
+	assert.Equal(t, []string{`capture payment.missing from $.receipt on Release: $ has no key "receipt"`}, all)
+}
+
+func TestCheck(t *testing.T) {
+	r := &Runner{Vars: vars.New()}
+	r.Vars.Set("user.uuid", "u1")
+	s := &Scenario{Name: "x", Steps: []Step{
+		{Name: "read", Method: "GET", Path: "/orders/{{order.uuid}}"},
+		{Name: "create", Method: "POST", Path: "/users/{{user.uuid}}/orders", Capture: []Capture{{Name: "order.uuid", Path: "$.uuid"}}},
+	}}
+	assert.EqualError(t, r.Check(s), "read: {{order.uuid}} is only captured by create")
+
+	s.Steps[0].Path = "/payments/{{payment.uuid}}"
+	assert.EqualError(t, r.Check(s), "read: {{payment.uuid}} is not a fixture and no step captures it")
+
+	s.Steps[0], s.Steps[1] = s.Steps[1], s.Steps[0]
+	s.Steps[1].Path = "/orders/{{order.uuid@Dev}}"
+	s.Steps[1].Expect = &Expect{JSON: []Assertion{{Path: "$.user_uuid", Equals: "{{user.uuid}}"}}}
+	assert.NoError(t, r.Check(s))
+}
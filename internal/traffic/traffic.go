@@ -0,0 +1,128 @@
+// Package traffic records the requests a run sends and the responses it gets
+// as JSON lines, so the same traffic can be replayed against an environment
+// later and the answers compared.
+package traffic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Exchange is one recorded request and its response. Path is relative to
+// the environment's base URL, bodies are kept only when they are JSON.
+type Exchange struct {
+	Env      string          `json:"env"`
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	TaskID   string          `json:"task_id,omitempty"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// Recorder is an http.RoundTripper that writes an Exchange for every round
+// trip Locate accepts. Locate returns the environment and relative path of
+// a request, ok false skips it. Requests with a body that is not JSON, such
+// as avatar uploads, are skipped as they cannot be replayed from the log.
+type Recorder struct {
+	Next   http.RoundTripper
+	Locate func(req *http.Request) (env, path string, ok bool)
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder returns a recorder writing to w.
+func NewRecorder(next http.RoundTripper, w io.Writer, locate func(req *http.Request) (string, string, bool)) *Recorder {
+	return &Recorder{Next: next, Locate: locate, enc: json.NewEncoder(w)}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	env, path, ok := r.Locate(req)
+	if !ok {
+		return r.Next.RoundTrip(req)
+	}
+	ex := Exchange{Env: env, Method: req.Method, Path: path, TaskID: req.Header.Get("X-Task-Id")}
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		if len(data) > 0 {
+			if !json.Valid(data) {
+				return r.Next.RoundTrip(req)
+			}
+			ex.Body = data
+		}
+	}
+
+	resp, err := r.Next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err // a truncated or reset body is the caller's failure, not a partial answer
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	ex.Status = resp.StatusCode
+	if json.Valid(data) {
+		ex.Response = data
+	}
+	r.write(ex)
+	return resp, nil
+}
+
+func (r *Recorder) write(ex Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(ex); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Err returns the first error writing the log.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// CloseIdleConnections forwards to the wrapped transport, see http.Client.CloseIdleConnections.
+func (r *Recorder) CloseIdleConnections() {
+	if c, ok := r.Next.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// Read parses a log written by a Recorder.
+func Read(rd io.Reader) ([]Exchange, error) {
+	var out []Exchange
+	sc := bufio.NewScanner(rd)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		var ex Exchange
+		if err := json.Unmarshal([]byte(text), &ex); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		if ex.Method == "" || ex.Path == "" {
+			return nil, fmt.Errorf("line %d: exchange needs a method and a path", line)
+		}
+		out = append(out, ex)
+	}
+	return out, sc.Err()
+}
@@ -0,0 +1,87 @@
+package traffic
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/api/v1/text" {
+			w.Write([]byte("plain"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	defer srv.Close()
+
+	var log bytes.Buffer
+	rec := NewRecorder(http.DefaultTransport, &log, func(req *http.Request) (string, string, bool) {
+		path, ok := strings.CutPrefix(req.URL.Path, "/api/v1")
+		return "Dev", path, ok
+	})
+	client := &http.Client{Transport: rec}
+
+	req, _ := http.NewRequest("POST", srv.URL+"/api/v1/users", strings.NewReader(`{"name":"x"}`))
+	req.Header.Set("X-Task-Id", "api-3")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.JSONEq(t, `{"echo":{"name":"x"}}`, string(body), "the caller still reads the body")
+
+	for _, path := range []string{"/api/v1/text", "/other"} {
+		resp, err := client.Get(srv.URL + path)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	resp, err = client.Post(srv.URL+"/api/v1/avatar", "image/png", strings.NewReader("\x89PNG"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.NoError(t, rec.Err())
+
+	exchanges, err := Read(&log)
+	require.NoError(t, err)
+	require.Len(t, exchanges, 2, "the path outside the environment and the binary upload are skipped")
+	assert.Equal(t, "Dev", exchanges[0].Env)
+	assert.Equal(t, "POST", exchanges[0].Method)
+	assert.Equal(t, "/users", exchanges[0].Path)
+	assert.Equal(t, "api-3", exchanges[0].TaskID)
+	assert.JSONEq(t, `{"name":"x"}`, string(exchanges[0].Body))
+	assert.Equal(t, 200, exchanges[0].Status)
+	assert.Equal(t, "/text", exchanges[1].Path)
+	assert.Nil(t, exchanges[1].Response)
+}
+
+func TestReadErrors(t *testing.T) {
+	_, err := Read(strings.NewReader("{\"method\":\"GET\",\"path\":\"/users\"}\nnot json\n"))
+	assert.EqualError(t, err, "line 2: invalid character 'o' in literal null (expecting 'u')")
+
+	_, err = Read(strings.NewReader(`{"env":"Dev"}`))
+	assert.Error(t, err)
+}
+
+func TestRecorderReturnsReadErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		w.Write([]byte(`{"games":[`))
+	}))
+	defer srv.Close()
+
+	var log bytes.Buffer
+	rec := NewRecorder(http.DefaultTransport, &log, func(req *http.Request) (string, string, bool) {
+		return "Dev", req.URL.Path, true
+	})
+	_, err := (&http.Client{Transport: rec}).Get(srv.URL + "/games")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF, "a short body is not passed on as an answer")
+	assert.Empty(t, log.String(), "nothing is recorded for a failed round trip")
+}
@@ -0,0 +1,307 @@
+// Package vars holds the named values that requests are built from: fixtures
+// set up front and values captured from earlier responses by JSONPath.
+//
+// Captured values belong to the environment that answered, so an order
+// created on Release and one created on Dev each keep their own uuid. A
+// {{name}} placeholder resolves to the value of its own environment, then to
+// a fixture, then to the value of the first environment that captured it, so
+// a resource created on Release can be read on Dev. {{name@Dev}} picks the
+// value of one environment explicitly.
+package vars
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"QA-Bug-Hunter-jr/internal/jsonpath"
+)
+
+// Type is the JSON type a captured value must have.
+type Type string
+
+const (
+	Any     Type = "any"
+	String  Type = "string"
+	Number  Type = "number"
+	Integer Type = "integer"
+	Boolean Type = "boolean"
+	UUID    Type = "uuid"
+	Array   Type = "array"
+	Object  Type = "object"
+)
+
+var types = map[Type]bool{Any: true, String: true, Number: true, Integer: true, Boolean: true, UUID: true, Array: true, Object: true}
+
+// ParseType checks a type name, "" is Any.
+func ParseType(s string) (Type, error) {
+	if s == "" {
+		return Any, nil
+	}
+	if !types[Type(s)] {
+		return "", fmt.Errorf("unknown type %q, want one of any, string, number, integer, boolean, uuid, array, object", s)
+	}
+	return Type(s), nil
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Check reports whether a decoded JSON value has the type.
+func (t Type) Check(v interface{}) error {
+	ok := false
+	switch t {
+	case Any:
+		ok = true
+	case String:
+		_, ok = v.(string)
+	case UUID:
+		s, isString := v.(string)
+		ok = isString && uuidPattern.MatchString(s)
+	case Number:
+		_, ok = v.(float64)
+	case Integer:
+		f, isNumber := v.(float64)
+		ok = isNumber && f == math.Trunc(f)
+	case Boolean:
+		_, ok = v.(bool)
+	case Array:
+		_, ok = v.([]interface{})
+	case Object:
+		_, ok = v.(map[string]interface{})
+	}
+	if !ok {
+		return fmt.Errorf("%s is %s, want %s", render(v), describe(v), t)
+	}
+	return nil
+}
+
+// CaptureError is a capture that did not find a value of the right type.
+type CaptureError struct {
+	Name, Env, Path string
+	Err             error
+}
+
+func (e *CaptureError) Error() string {
+	return fmt.Sprintf("capture %s from %s on %s: %v", e.Name, e.Path, e.Env, e.Err)
+}
+
+func (e *CaptureError) Unwrap() error {
+	return e.Err
+}
+
+// Set is a set of named values. The zero value is not usable, see New.
+type Set struct {
+	fixtures map[string]interface{}
+	captured map[string]map[string]interface{} // by name, then environment
+	envs     map[string][]string               // environments that captured a name, in order
+	failed   map[string]*CaptureError          // the last failed capture of a name
+}
+
+// New returns an empty set.
+func New() *Set {
+	return &Set{
+		fixtures: map[string]interface{}{},
+		captured: map[string]map[string]interface{}{},
+		envs:     map[string][]string{},
+		failed:   map[string]*CaptureError{},
+	}
+}
+
+// Set stores a fixture, visible from every environment.
+func (s *Set) Set(name string, v interface{}) {
+	s.fixtures[name] = number(v)
+}
+
+// Has reports whether name resolves from some environment.
+func (s *Set) Has(name string) bool {
+	_, fixture := s.fixtures[name]
+	return fixture || len(s.envs[name]) > 0
+}
+
+// Capture reads path from doc, the answer of env, checks the type and
+// stores the value under name for env. A failed capture is remembered, so
+// later lookups of the name say why it is missing.
+func (s *Set) Capture(env, name, path string, typ Type, doc interface{}) (interface{}, error) {
+	v, err := jsonpath.Get(doc, path)
+	if err == nil {
+		err = typ.Check(v)
+	}
+	if err != nil {
+		ce := &CaptureError{Name: name, Env: env, Path: path, Err: err}
+		s.failed[name] = ce
+		return nil, ce
+	}
+	s.Store(env, name, v)
+	return v, nil
+}
+
+// Store sets a captured value of env directly.
+func (s *Set) Store(env, name string, v interface{}) {
+	if s.captured[name] == nil {
+		s.captured[name] = map[string]interface{}{}
+	}
+	if _, seen := s.captured[name][env]; !seen {
+		s.envs[name] = append(s.envs[name], env)
+	}
+	s.captured[name][env] = number(v)
+	delete(s.failed, name)
+}
+
+// Lookup resolves a name as seen from env, see the package comment.
+func (s *Set) Lookup(env, name string) (interface{}, error) {
+	if base, other, ok := strings.Cut(name, "@"); ok {
+		if v, found := s.captured[base][other]; found {
+			return v, nil
+		}
+		return nil, s.missing(base, fmt.Sprintf(" on %s", other))
+	}
+	if v, ok := s.captured[name][env]; ok {
+		return v, nil
+	}
+	if v, ok := s.fixtures[name]; ok {
+		return v, nil
+	}
+	if envs := s.envs[name]; len(envs) > 0 {
+		return s.captured[name][envs[0]], nil
+	}
+	return nil, s.missing(name, "")
+}
+
+func (s *Set) missing(name, where string) error {
+	if ce := s.failed[name]; ce != nil {
+		return fmt.Errorf("{{%s}} is not set%s: %v", name, where, ce)
+	}
+	return fmt.Errorf("{{%s}} is not set%s: no fixture has that name and no earlier request captured it", name, where)
+}
+
+// placeholder matches {{name}} and {{name@Env}}
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.\-]+(?:@[A-Za-z0-9_\-]+)?)\s*\}\}`)
+
+// Names returns the names used by the placeholders of s, without @Env.
+func Names(s string) []string {
+	var out []string
+	for _, m := range placeholder.FindAllStringSubmatch(s, -1) {
+		name, _, _ := strings.Cut(m[1], "@")
+		out = append(out, name)
+	}
+	return out
+}
+
+// Expand fills the placeholders of a string as seen from env.
+func (s *Set) Expand(env, text string) (string, error) {
+	return s.ExpandWith(env, text, func(v string) string { return v })
+}
+
+// ExpandWith is Expand with every value passed through escape, e.g. url.PathEscape.
+func (s *Set) ExpandWith(env, text string, escape func(string) string) (string, error) {
+	var firstErr error
+	out := placeholder.ReplaceAllStringFunc(text, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		v, err := s.Lookup(env, name)
+		if err == nil {
+			var str string
+			if str, err = format(name, v); err == nil {
+				return escape(str)
+			}
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		return m
+	})
+	return out, firstErr
+}
+
+// ExpandValue fills the placeholders of every string inside a decoded JSON
+// value. A string that is a single placeholder takes the captured value with
+// its type, so "{{order.total}}" becomes a number in a request body.
+func (s *Set) ExpandValue(env string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		if m := placeholder.FindStringSubmatch(v); m != nil && m[0] == v {
+			return s.Lookup(env, m[1])
+		}
+		return s.Expand(env, v)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			e, err := s.ExpandValue(env, elem)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = e
+		}
+		return out, nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			e, err := s.ExpandValue(env, elem)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = e
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// format turns a value into the text put in place of its placeholder
+func format(name string, v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return strconv.FormatInt(int64(v), 10), nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	return "", fmt.Errorf("{{%s}} is %s, it cannot be put into text", name, describe(v))
+}
+
+// number stores Go integers as float64 like decoded JSON, so a fixture
+// equals the same value read from a response
+func number(v interface{}) interface{} {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return v
+}
+
+// describe names the JSON type of a value
+func describe(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return fmt.Sprintf("a %T", v)
+}
+
+func render(v interface{}) string {
+	s := fmt.Sprintf("%v", v)
+	if str, ok := v.(string); ok {
+		s = strconv.Quote(str)
+	}
+	if len(s) > 40 {
+		s = s[:37] + "..."
+	}
+	return s
+}
@@ -0,0 +1,116 @@
+package vars
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderUUID = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+
+func decode(t *testing.T, s string) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestTypes(t *testing.T) {
+	for typ, good := range map[Type]interface{}{
+		String: "x", UUID: orderUUID, Number: 1.5, Integer: 3.0, Boolean: true,
+		Array: []interface{}{}, Object: map[string]interface{}{}, Any: nil,
+	} {
+		assert.NoError(t, typ.Check(good), typ)
+	}
+	assert.EqualError(t, UUID.Check("nope"), `"nope" is a string, want uuid`)
+	assert.EqualError(t, Integer.Check(1.5), "1.5 is a number, want integer")
+	assert.EqualError(t, String.Check(nil), "<nil> is null, want string")
+
+	typ, err := ParseType("")
+	assert.NoError(t, err)
+	assert.Equal(t, Any, typ)
+	_, err = ParseType("text")
+	assert.Error(t, err)
+}
+
+func TestCaptureAndLookup(t *testing.T) {
+	s := New()
+	s.Set("user.uuid", "u1")
+
+	_, err := s.Capture("Release", "order.uuid", "$.uuid", UUID, decode(t, `{"uuid":"`+orderUUID+`"}`))
+	require.NoError(t, err)
+	_, err = s.Capture("Dev", "order.uuid", "$.uuid", String, decode(t, `{"uuid":"dev-order"}`))
+	require.NoError(t, err)
+
+	for _, c := range []struct{ env, name, want string }{
+		{"Release", "order.uuid", orderUUID},
+		{"Dev", "order.uuid", "dev-order"},
+		{"Staging", "order.uuid", orderUUID}, // first environment that captured it
+		{"Dev", "order.uuid@Release", orderUUID},
+		{"Dev", "user.uuid", "u1"},
+	} {
+		v, err := s.Lookup(c.env, c.name)
+		require.NoError(t, err, c.name)
+		assert.Equal(t, c.want, v, c.name)
+	}
+	assert.True(t, s.Has("order.uuid"))
+	assert.False(t, s.Has("payment.uuid"))
+
+	_, err = s.Lookup("Dev", "payment.uuid")
+	assert.EqualError(t, err, "{{payment.uuid}} is not set: no fixture has that name and no earlier request captured it")
+	_, err = s.Lookup("Dev", "user.uuid@Release")
+	assert.EqualError(t, err, "{{user.uuid}} is not set on Release: no fixture has that name and no earlier request captured it")
+}
+
+func TestFailedCapture(t *testing.T) {
+	s := New()
+	_, err := s.Capture("Dev", "payment.uuid", "$.uuid", UUID, decode(t, `{"code":404,"message":"order not found"}`))
+	var ce *CaptureError
+	require.True(t, errors.As(err, &ce))
+	assert.EqualError(t, err, `capture payment.uuid from $.uuid on Dev: $ has no key "uuid"`)
+
+	_, err = s.Lookup("Dev", "payment.uuid")
+	assert.EqualError(t, err, `{{payment.uuid}} is not set: capture payment.uuid from $.uuid on Dev: $ has no key "uuid"`)
+
+	_, err = s.Capture("Dev", "order.total", "$.total_price", Integer, decode(t, `{"total_price":"12"}`))
+	assert.EqualError(t, err, `capture order.total from $.total_price on Dev: "12" is a string, want integer`)
+}
+
+func TestExpand(t *testing.T) {
+	s := New()
+	s.Set("user.uuid", "u1")
+	s.Store("Release", "order.total", 5998.0)
+	s.Store("Release", "order.items", []interface{}{"a"})
+
+	got, err := s.Expand("Release", "/users/{{user.uuid}}/orders?min={{ order.total }}")
+	require.NoError(t, err)
+	assert.Equal(t, "/users/u1/orders?min=5998", got)
+
+	_, err = s.Expand("Release", "/orders/{{order.items}}")
+	assert.EqualError(t, err, "{{order.items}} is an array, it cannot be put into text")
+	_, err = s.Expand("Release", "/payments/{{payment.uuid}}")
+	assert.ErrorContains(t, err, "{{payment.uuid}} is not set")
+
+	body, err := s.ExpandValue("Release", decode(t, `{"amount":"{{order.total}}","note":"order of {{user.uuid}}","items":["{{order.items}}"]}`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"amount": 5998.0,
+		"note":   "order of u1",
+		"items":  []interface{}{[]interface{}{"a"}},
+	}, body, "a lone placeholder keeps the type of its value")
+
+	assert.Equal(t, []string{"user.uuid", "order.uuid"}, Names("/users/{{user.uuid}}/orders/{{order.uuid@Dev}}"))
+}
+
+func TestFixtureNumbers(t *testing.T) {
+	s := New()
+	s.Set("game.price", 2999)
+	v, err := s.Lookup("Dev", "game.price")
+	require.NoError(t, err)
+	assert.Equal(t, 2999.0, v, "fixtures compare like decoded JSON")
+	got, err := s.Expand("Dev", "price={{game.price}}")
+	require.NoError(t, err)
+	assert.Equal(t, "price=2999", got)
+}